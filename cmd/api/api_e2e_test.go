@@ -96,6 +96,8 @@ func (s *E2ETestSuite) TearDownSuite() {
 func (s *E2ETestSuite) cleanupTestData() {
 	log.Println("Cleaning up test data from tables...")
 	s.db.Unscoped().Exec("SET FOREIGN_KEY_CHECKS = 0;")
+	s.db.Unscoped().Exec("DELETE FROM conversation_messages")
+	s.db.Unscoped().Exec("DELETE FROM conversations")
 	s.db.Unscoped().Exec("DELETE FROM feedbacks")
 	s.db.Unscoped().Exec("DELETE FROM answer_sources")
 	s.db.Unscoped().Exec("DELETE FROM answers")
@@ -104,6 +106,7 @@ func (s *E2ETestSuite) cleanupTestData() {
 	s.db.Unscoped().Exec("DELETE FROM pages")
 	s.db.Unscoped().Exec("DELETE FROM documents")
 	s.db.Unscoped().Exec("DELETE FROM enrollments")
+	s.db.Unscoped().Exec("DELETE FROM course_settings")
 	s.db.Unscoped().Exec("DELETE FROM courses")
 	s.db.Unscoped().Exec("DELETE FROM users")
 	s.db.Unscoped().Exec("DELETE FROM semesters")
@@ -300,12 +303,14 @@ func (s *E2ETestSuite) TestD_QAFlow() {
 		defer resp.Body.Close()
 
 		s.Require().Equal(http.StatusOK, resp.StatusCode, "QA request should return 200 OK")
-		var qaResponse map[string]string
+		var qaResponse output.AskOutput
 		err = json.NewDecoder(resp.Body).Decode(&qaResponse)
 		s.Require().NoError(err)
-		s.Contains(qaResponse, "answer")
-		s.Contains(strings.ToLower(qaResponse["answer"]), "test content", "Answer should contain the text from the PDF")
-		log.Printf("QA success. Answer: %s", qaResponse["answer"])
+		s.NotEmpty(qaResponse.Answer)
+		s.Contains(strings.ToLower(qaResponse.Answer), "test content", "Answer should contain the text from the PDF")
+		s.NotZero(qaResponse.AnswerID, "Answer should have been logged")
+		s.NotEmpty(qaResponse.SessionID, "A new conversation should have been started")
+		log.Printf("QA success. Answer: %s", qaResponse.Answer)
 	})
 
 	s.Run("Failure_AskAboutUnenrolledCourse", func() {
@@ -2,14 +2,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
-	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/google"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/mysql"
-	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/qdrant"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/rerank"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/storage"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/router"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/interactor"
@@ -45,19 +48,59 @@ func main() {
 
 	// ★★★ runAutoMigration(db) の呼び出しを削除 ★★★
 
-	qdrantRepo, err := qdrant.NewQdrantRepository(cfg.VectorDB.Qdrant)
+	// Queries go to the active embedding index, embedded by its model.
+	indexRegistry := provider.NewIndexRegistry(cfg, db, mysql.NewEmbeddingIndexRepository(db),
+		time.Duration(cfg.VectorDB.IndexRefreshSeconds)*time.Second)
+	if err := indexRegistry.Bootstrap(context.Background()); err != nil {
+		log.Fatalf("Failed to prepare embedding index registry: %v", err)
+	}
+	vectorRepo := indexRegistry.VectorRepository()
+
+	// Without Redis the answer cache is turned off in cacheCfg.
+	cacheRepo, cacheCfg := provider.NewCacheRepository(cfg.Cache)
+
+	var semanticCacheRepo repository.SemanticCacheRepository // Nil disables the semantic answer cache
+	if cacheCfg.SemanticThreshold > 0 && cfg.VectorDB.Type != "" && cfg.VectorDB.Type != "qdrant" {
+		log.Printf("WARN: the semantic answer cache needs Qdrant and is disabled with the %s vector store", cfg.VectorDB.Type)
+	} else if cacheCfg.SemanticThreshold > 0 {
+		// Each embedding index has an answer cache of its own.
+		semanticCacheRepo = indexRegistry.SemanticCacheRepository()
+		if err := semanticCacheRepo.EnsureCollectionExists(context.Background()); err != nil {
+			log.Fatalf("Failed to prepare the semantic answer cache: %v", err)
+		}
+	}
+	// Each question is embedded and searched in one index, even across an index switch.
+	searchIndexes := indexRegistry.SearchIndexes(semanticCacheRepo != nil)
+
+	keywordSearch := model.KeywordSearch(cfg.Retrieval.KeywordSearch)
+	switch keywordSearch {
+	case "", model.KeywordSearchMySQL:
+		keywordSearch = model.KeywordSearchMySQL
+	case model.KeywordSearchSparse:
+		if !provider.SupportsHybridSearch(cfg) {
+			log.Printf("WARN: the %s vector store keeps no sparse vectors; falling back to MySQL full-text search", cfg.VectorDB.Type)
+			keywordSearch = model.KeywordSearchMySQL
+		}
+	default:
+		log.Fatalf("Unknown keyword search backend: %s", cfg.Retrieval.KeywordSearch)
+	}
+
+	configuredEmbeddingRepo, err := provider.NewEmbeddingRepository(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to Qdrant: %v", err)
+		log.Fatalf("Failed to create embedding repo: %v", err)
+	}
+	if err := provider.CheckEmbeddingDimension(context.Background(), cfg, configuredEmbeddingRepo); err != nil {
+		log.Fatalf("Invalid embedding configuration: %v", err)
 	}
 
-	googleEmbeddingRepo, err := google.NewGoogleEmbeddingRepository(cfg.Google)
+	llmRepo, err := provider.NewLLMRepository(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create Google Embedding repo: %v", err)
+		log.Fatalf("Failed to create LLM repo: %v", err)
 	}
 
-	googleLLMRepo, err := google.NewGoogleLLMRepository(cfg.Google)
+	reranker, err := rerank.NewReranker(cfg.Retrieval.Rerank, llmRepo)
 	if err != nil {
-		log.Fatalf("Failed to create Google LLM repo: %v", err)
+		log.Fatalf("Failed to create reranker: %v", err)
 	}
 
 	fileStorage, err := storage.NewLocalStorage(cfg.Storage.Local)
@@ -71,6 +114,8 @@ func main() {
 	courseRepo := mysql.NewCourseRepository(db)
 	feedbackRepo := mysql.NewFeedbackRepository(db)
 	enrollmentRepo := mysql.NewEnrollmentRepository(db)
+	qaLogRepo := mysql.NewQALogRepository(db)
+	conversationRepo := mysql.NewConversationRepository(db)
 
 	// JWT Manager
 	accessTokenDuration := time.Duration(cfg.Auth.JWT.AccessTokenExpiryHours) * time.Hour
@@ -83,9 +128,31 @@ func main() {
 
 	// Usecases
 	authUsecase := interactor.NewAuthInteractor(userRepo, jwtManager)
-	qaUsecase := interactor.NewQAInteractor(docRepo, qdrantRepo, googleEmbeddingRepo, googleLLMRepo)
-	fileUsecase := interactor.NewFileInteractor(docRepo, fileStorage, courseRepo)
+	qaUsecase := interactor.NewQAInteractor(
+		docRepo, courseRepo, searchIndexes, llmRepo, reranker, qaLogRepo, conversationRepo, cacheRepo,
+		model.RetrievalConfig{
+			BM25TopK:           cfg.Retrieval.BM25TopK,
+			VectorTopK:         cfg.Retrieval.VectorTopK,
+			BM25Weight:         cfg.Retrieval.BM25Weight,
+			VectorWeight:       cfg.Retrieval.VectorWeight,
+			RRFK:               cfg.Retrieval.RRFK,
+			RerankCandidates:   cfg.Retrieval.Rerank.Candidates,
+			ContextSize:        cfg.Retrieval.Rerank.TopN,
+			MinScore:           cfg.Retrieval.MinScore,
+			ContextTokenBudget: cfg.Retrieval.ContextTokenBudget,
+			NeighborChunks:     cfg.Retrieval.NeighborChunks,
+			MMRLambda:          cfg.Retrieval.MMRLambda,
+			KeywordSearch:      keywordSearch,
+		},
+		interactor.AnswerCacheConfig{
+			TTL:                 time.Duration(cacheCfg.AnswerTTLMinutes) * time.Minute,
+			SimilarityThreshold: cacheCfg.SemanticThreshold,
+		},
+	)
+	fileUsecase := interactor.NewFileInteractor(docRepo, fileStorage, courseRepo, vectorRepo, cacheRepo)
 	courseUsecase := interactor.NewCourseInteractor(courseRepo, enrollmentRepo)
+	conversationUsecase := interactor.NewConversationInteractor(conversationRepo, cacheRepo)
+	adminUsecase := interactor.NewAdminInteractor(userRepo, courseRepo, cacheRepo, vectorRepo, semanticCacheRepo)
 	_ = interactor.NewFeedbackInteractor(feedbackRepo)
 
 	// Handlers
@@ -93,10 +160,12 @@ func main() {
 	qaHandler := handler.NewQAHandler(qaUsecase, enrollmentRepo)
 	fileHandler := handler.NewFileHandler(fileUsecase)
 	courseHandler := handler.NewCourseHandler(courseUsecase)
+	conversationHandler := handler.NewConversationHandler(conversationUsecase)
+	adminHandler := handler.NewAdminHandler(adminUsecase)
 	healthHandler := handler.NewHealthHandler(db)
 
 	// Router
-	appRouter := router.NewRouter(cfg.Server, authHandler, qaHandler, fileHandler, courseHandler, healthHandler, jwtManager)
+	appRouter := router.NewRouter(cfg.Server, authHandler, qaHandler, fileHandler, courseHandler, conversationHandler, adminHandler, healthHandler, jwtManager)
 
 	serverAddr := fmt.Sprintf(":%s", cfg.Server.Port)
 	log.Printf("Starting server on %s\n", serverAddr)
@@ -28,15 +28,15 @@ func main() {
 
 	// 3. Get task name from command-line arguments
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run cmd/batch/*.go <task_name>")
-		fmt.Println("Available tasks: sync-documents")
+		fmt.Println("Usage: go run cmd/batch/*.go <task_name> [args...]")
+		fmt.Println("Available tasks: sync-documents, reembed, rollback-embedding-index, snapshot-vectors, restore-vectors [backup_dir]")
 		os.Exit(1)
 	}
 	taskName := os.Args[1]
 
 	// 4. Build and run the task
 	// ★★★ 新しいパッケージの NewTaskRunner を呼び出す
-	runner, err := batch.NewTaskRunner(taskName, cfg)
+	runner, err := batch.NewTaskRunner(taskName, cfg, os.Args[2:]...)
 	if err != nil {
 		log.Fatalf("Failed to create task runner: %v", err)
 	}
@@ -6,11 +6,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	// ★★★ UniPDFのコアパッケージ(model)と抽出パッケージ(extractor)をインポート ★★★
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
 	"github.com/unidoc/unipdf/v3/extractor"
 	unipdfmodel "github.com/unidoc/unipdf/v3/model"
 )
@@ -30,18 +33,30 @@ type pdfChunkProcessor struct {
 	chunkSize             int
 	overlapSize           int
 	embeddingModelVersion string
+	dimensions            int // Size of the embeddings, part of the VectorHash
 }
 
-// NewPDFChunkProcessor creates a new processor for PDF files.
-func NewPDFChunkProcessor(embeddingModelVersion string) ChunkProcessor {
+// NewPDFChunkProcessor creates a new processor for PDF files whose chunks are embedded
+// by the given model into vectors of the given dimensions.
+func NewPDFChunkProcessor(embeddingModelVersion string, dimensions int) ChunkProcessor {
 	return &pdfChunkProcessor{
 		chunkSize:             defaultChunkSize,
 		overlapSize:           defaultOverlapSize,
 		embeddingModelVersion: embeddingModelVersion,
+		dimensions:            dimensions,
 	}
 }
 
+// textSpan is a chunk of a page text and its byte offsets within the page.
+type textSpan struct {
+	text       string
+	start, end int
+}
+
 // Process extracts text from a PDF, creates page and chunk models.
+// Pages are processed in page order, so ChunkIndex follows the reading order of the
+// document. Each chunk references its page through Chunk.Page; the page ID is only
+// known once the pages have been stored.
 func (p *pdfChunkProcessor) Process(ctx context.Context, doc *model.Document, fileContent []byte) ([]*model.Page, []*model.Chunk, error) {
 	pagesContent, err := p.extractTextFromPDF(fileContent)
 	if err != nil {
@@ -54,7 +69,14 @@ func (p *pdfChunkProcessor) Process(ctx context.Context, doc *model.Document, fi
 	var chunks []*model.Chunk
 	var globalChunkIndex int
 
-	for pageNum, pageText := range pagesContent {
+	pageNumbers := make([]int, 0, len(pagesContent))
+	for pageNum := range pagesContent {
+		pageNumbers = append(pageNumbers, pageNum)
+	}
+	sort.Ints(pageNumbers)
+
+	for _, pageNum := range pageNumbers {
+		pageText := pagesContent[pageNum]
 		// 空のページはスキップ
 		if strings.TrimSpace(pageText) == "" {
 			continue
@@ -64,18 +86,23 @@ func (p *pdfChunkProcessor) Process(ctx context.Context, doc *model.Document, fi
 			DocumentID: doc.ID,
 			PageNumber: pageNum,
 			Text:       pageText,
+			TokenCount: tokenizer.EstimateTokens(pageText),
 		}
 		pages = append(pages, page)
 
-		pageChunks := p.splitTextIntoChunks(pageText)
-
-		for _, chunkText := range pageChunks {
+		for _, span := range p.splitTextIntoChunks(pageText) {
 			chunk := &model.Chunk{
 				DocumentID:            doc.ID,
 				CourseID:              doc.CourseID,
 				SemesterID:            doc.SemesterID,
 				ChunkIndex:            globalChunkIndex,
-				Text:                  chunkText,
+				StartOffset:           span.start,
+				EndOffset:             span.end,
+				Text:                  span.text,
+				TokenCount:            tokenizer.EstimateTokens(span.text),
+				VectorHash:            model.ComputeVectorHash(p.embeddingModelVersion, p.dimensions, span.text),
+				Page:                  *page,
+				Document:              *doc,
 				EmbeddingID:           uuid.New().String(),
 				EmbeddingModelVersion: p.embeddingModelVersion,
 			}
@@ -127,25 +154,38 @@ func (p *pdfChunkProcessor) extractTextFromPDF(data []byte) (map[int]string, err
 	return textByPage, nil
 }
 
-// splitTextIntoChunksは、指定されたテキストを固定サイズのチャンクに分割します。
+// splitTextIntoChunksは、指定されたテキストを固定サイズ（文字数）のチャンクに分割します。
 // チャンク間にはオーバーラップを持たせることができます。
-func (p *pdfChunkProcessor) splitTextIntoChunks(text string) []string {
-	if len(text) <= p.chunkSize {
-		return []string{text}
+// マルチバイト文字の途中で切らないよう文字単位で分割し、各チャンクのバイトオフセットを返します。
+func (p *pdfChunkProcessor) splitTextIntoChunks(text string) []textSpan {
+	// offsets[i] は i 文字目の開始バイト位置。末尾には len(text) を置きます。
+	offsets := make([]int, 0, utf8.RuneCountInString(text)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	numRunes := len(offsets)
+	offsets = append(offsets, len(text))
+
+	if numRunes <= p.chunkSize {
+		return []textSpan{{text: text, start: 0, end: len(text)}}
 	}
 
-	var chunks []string
+	var chunks []textSpan
 	start := 0
-	for start < len(text) {
+	for start < numRunes {
 		end := start + p.chunkSize
-		if end > len(text) {
-			end = len(text)
+		if end > numRunes {
+			end = numRunes
 		}
-		chunks = append(chunks, text[start:end])
+		chunks = append(chunks, textSpan{
+			text:  text[offsets[start]:offsets[end]],
+			start: offsets[start],
+			end:   offsets[end],
+		})
 
 		// 次のチャンクの開始位置を、オーバーラップを考慮して設定します。
 		start += p.chunkSize - p.overlapSize
-		if start >= len(text) {
+		if end == numRunes {
 			break
 		}
 	}
@@ -7,9 +7,8 @@ import (
 
 	"github.com/takumi-1234/OpenRAGLecture/internal/batch/processor"
 	"github.com/takumi-1234/OpenRAGLecture/internal/batch/task"
-	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/google"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/mysql"
-	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/qdrant"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/storage"
 	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
 )
@@ -19,22 +18,28 @@ type TaskRunner interface {
 	Run(ctx context.Context) error
 }
 
-// NewTaskRunner builds and returns a specific task runner based on the task name.
-func NewTaskRunner(taskName string, cfg config.Config) (TaskRunner, error) {
+// NewTaskRunner builds and returns a specific task runner based on the task name. args
+// are the arguments of the task that follow its name on the command line.
+func NewTaskRunner(taskName string, cfg config.Config, args ...string) (TaskRunner, error) {
 	// Initialize dependencies
 	db, err := mysql.NewGORMClient(cfg.Database.MySQL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	qdrantRepo, err := qdrant.NewQdrantRepository(cfg.VectorDB.Qdrant)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Qdrant: %w", err)
+	indexRepo := mysql.NewEmbeddingIndexRepository(db)
+	embeddingCache := mysql.NewEmbeddingCacheRepository(db)
+	registry := provider.NewIndexRegistry(cfg, db, indexRepo, 0)
+	if err := registry.Bootstrap(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to prepare embedding index registry: %w", err)
 	}
 
-	googleEmbeddingRepo, err := google.NewGoogleEmbeddingRepository(cfg.Google)
+	embeddingRepo, err := provider.NewEmbeddingRepository(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Google Embedding repo: %w", err)
+		return nil, fmt.Errorf("failed to create embedding repo: %w", err)
+	}
+	if err := provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo); err != nil {
+		return nil, err
 	}
 
 	fileStorage, err := storage.NewLocalStorage(cfg.Storage.Local)
@@ -42,13 +47,58 @@ func NewTaskRunner(taskName string, cfg config.Config) (TaskRunner, error) {
 		return nil, fmt.Errorf("failed to init file storage: %w", err)
 	}
 
-	// ★★★ 修正点: 設定ファイルからモデル名を渡す ★★★
-	chunkProc := processor.NewPDFChunkProcessor(cfg.Google.EmbeddingModel)
+	// The tasks only bump corpus versions, which are moot while the answer cache is off.
+	cacheRepo, _ := provider.NewCacheRepository(cfg.Cache)
 
 	// Return the requested task
 	switch taskName {
 	case "sync-documents":
-		return task.NewSyncTask(db, fileStorage, chunkProc, googleEmbeddingRepo, qdrantRepo), nil
+		// New documents go into the active index, embedded by its model.
+		index, err := registry.Active(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		vectorRepo, indexEmbeddingRepo, err := registry.Repositories(index)
+		if err != nil {
+			return nil, err
+		}
+		// ★★★ 修正点: 設定ファイルからモデル名を渡す ★★★
+		chunkProc := processor.NewPDFChunkProcessor(index.Model, index.Dimensions)
+		embedder := task.NewCachedEmbedder(embeddingCache, indexEmbeddingRepo, index.Model)
+		return task.NewSyncTask(db, fileStorage, chunkProc, embedder, vectorRepo, cacheRepo), nil
+	case "reembed":
+		// Builds and activates the index of the configured model.
+		target := provider.ConfiguredIndex(cfg)
+		vectorRepo, _, err := registry.Repositories(target)
+		if err != nil {
+			return nil, err
+		}
+		embedder := task.NewCachedEmbedder(embeddingCache, embeddingRepo, target.Model)
+		return task.NewReembedTask(db, indexRepo, target, embedder, vectorRepo, cacheRepo), nil
+	case "rollback-embedding-index":
+		return task.NewRollbackIndexTask(db, indexRepo, cacheRepo), nil
+	case "snapshot-vectors", "restore-vectors":
+		// Backups are taken of and restored into the active index.
+		index, err := registry.Active(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		vectorRepo, _, err := registry.Repositories(index)
+		if err != nil {
+			return nil, err
+		}
+		snapshotRepo, err := registry.SnapshotRepository(index)
+		if err != nil {
+			return nil, err
+		}
+		if taskName == "snapshot-vectors" {
+			return task.NewSnapshotVectorsTask(index, vectorRepo, snapshotRepo, cfg.VectorDB.BackupDir), nil
+		}
+		var backupPath string
+		if len(args) > 0 {
+			backupPath = args[0]
+		}
+		return task.NewRestoreVectorsTask(db, index, vectorRepo, snapshotRepo, cacheRepo, cfg.VectorDB.BackupDir, backupPath), nil
 	default:
 		return nil, fmt.Errorf("unknown task: %s", taskName)
 	}
@@ -0,0 +1,112 @@
+// open-rag-lecture/internal/batch/task/cached_embedder.go
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+// CachedEmbedder creates document embeddings, reusing the embeddings cached under the
+// VectorHash of a text. Only the misses are sent to the embedding model, each distinct
+// hash once. Cache failures are logged and cost only the reuse.
+type CachedEmbedder struct {
+	cache         repository.EmbeddingCacheRepository
+	embeddingRepo repository.EmbeddingRepository
+	model         string // Embedding model version the hashes are computed for
+
+	hits, misses int
+}
+
+// NewCachedEmbedder creates a new CachedEmbedder.
+func NewCachedEmbedder(cache repository.EmbeddingCacheRepository, embeddingRepo repository.EmbeddingRepository, model string) *CachedEmbedder {
+	return &CachedEmbedder{cache: cache, embeddingRepo: embeddingRepo, model: model}
+}
+
+// Embed returns the document embeddings of the texts, in order. hashes holds the
+// VectorHash of every text; texts with an empty hash are never cached.
+func (e *CachedEmbedder) Embed(ctx context.Context, texts, hashes []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+
+	cached, err := e.cache.FindByHashes(ctx, nonEmpty(hashes))
+	if err != nil {
+		log.Printf("WARN: Failed to read embedding cache: %v", err)
+		cached = nil
+	}
+
+	// Texts to embed, each distinct hash once, and the positions their embeddings go to.
+	var missTexts, missHashes []string
+	var missPositions [][]int
+	pending := make(map[string]int) // Index of a hash in the misses
+	for i, hash := range hashes {
+		if vector, ok := cached[hash]; ok && hash != "" {
+			vectors[i] = vector
+			e.hits++
+			continue
+		}
+		e.misses++
+		if j, ok := pending[hash]; ok && hash != "" {
+			missPositions[j] = append(missPositions[j], i)
+			continue
+		}
+		pending[hash] = len(missTexts)
+		missTexts = append(missTexts, texts[i])
+		missHashes = append(missHashes, hash)
+		missPositions = append(missPositions, []int{i})
+	}
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := e.embeddingRepo.CreateEmbeddings(ctx, missTexts, "RETRIEVAL_DOCUMENT")
+	if err != nil {
+		return nil, err
+	}
+	if len(embedded) != len(missTexts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(missTexts), len(embedded))
+	}
+
+	entries := make([]*model.EmbeddingCacheEntry, 0, len(embedded))
+	now := time.Now()
+	for j, vector := range embedded {
+		for _, i := range missPositions[j] {
+			vectors[i] = vector
+		}
+		if missHashes[j] != "" {
+			entries = append(entries, &model.EmbeddingCacheEntry{VectorHash: missHashes[j], Model: e.model, Vector: vector, CreatedAt: now})
+		}
+	}
+	if err := e.cache.Save(ctx, entries); err != nil {
+		log.Printf("WARN: Failed to write embedding cache: %v", err)
+	}
+	return vectors, nil
+}
+
+// Stats returns the number of texts served from the cache and embedded so far.
+func (e *CachedEmbedder) Stats() (hits, misses int) {
+	return e.hits, e.misses
+}
+
+// LogStats reports the hit rate of the cache.
+func (e *CachedEmbedder) LogStats() {
+	total := e.hits + e.misses
+	if total == 0 {
+		return
+	}
+	log.Printf("Embedding cache: %d hits, %d misses (%.1f%% hit rate).", e.hits, e.misses, 100*float64(e.hits)/float64(total))
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
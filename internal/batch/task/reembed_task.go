@@ -0,0 +1,322 @@
+// open-rag-lecture/internal/batch/task/reembed_task.go
+
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const reembedBatchSize = 100
+
+// ReembedTask builds the embedding index of a new model from the stored chunks and
+// switches queries over to it. The progress is recorded in the index, so an interrupted
+// run resumes where it stopped; chunks added meanwhile are picked up the same way.
+type ReembedTask struct {
+	db         *gorm.DB
+	indexRepo  repository.EmbeddingIndexRepository
+	target     *model.EmbeddingIndex
+	embedder   *CachedEmbedder             // Embeds with the model of the target index
+	vectorRepo repository.VectorRepository // Stores into the collection of the target index
+	cacheRepo  repository.CacheRepository
+}
+
+// NewReembedTask creates a new ReembedTask.
+func NewReembedTask(
+	db *gorm.DB,
+	indexRepo repository.EmbeddingIndexRepository,
+	target *model.EmbeddingIndex,
+	embedder *CachedEmbedder,
+	vectorRepo repository.VectorRepository,
+	cacheRepo repository.CacheRepository,
+) *ReembedTask {
+	return &ReembedTask{
+		db:         db,
+		indexRepo:  indexRepo,
+		target:     target,
+		embedder:   embedder,
+		vectorRepo: vectorRepo,
+		cacheRepo:  cacheRepo,
+	}
+}
+
+// Run executes the re-embedding task.
+func (t *ReembedTask) Run(ctx context.Context) error {
+	index, err := t.indexRepo.FindByVersion(ctx, t.target.Version)
+	switch {
+	case errors.Is(err, appErrors.ErrEmbeddingIndexNotFound):
+		index = t.target
+		if err := t.indexRepo.Create(ctx, index); err != nil {
+			return fmt.Errorf("failed to register embedding index %s: %w", index.Version, err)
+		}
+		log.Printf("Building embedding index %s in collection '%s'.", index.Version, index.CollectionName)
+	case err != nil:
+		return fmt.Errorf("failed to look up embedding index %s: %w", t.target.Version, err)
+	case index.Status == model.EmbeddingIndexActive:
+		log.Printf("Embedding index %s is already active. Nothing to do.", index.Version)
+		return nil
+	default:
+		log.Printf("Resuming embedding index %s after chunk ID %d.", index.Version, index.LastChunkID)
+	}
+
+	if err := t.vectorRepo.EnsureCollectionExists(ctx); err != nil {
+		return fmt.Errorf("failed to ensure collection of index %s exists: %w", index.Version, err)
+	}
+	if err := t.embedChunks(ctx, index); err != nil {
+		return err
+	}
+	if err := t.verify(ctx, index); err != nil {
+		return err
+	}
+
+	if err := t.indexRepo.Activate(ctx, index.Version); err != nil {
+		return fmt.Errorf("failed to activate embedding index %s: %w", index.Version, err)
+	}
+	bumpCorpusVersions(ctx, t.db, t.cacheRepo)
+	t.embedder.LogStats()
+	log.Printf("Embedding index %s is now active.", index.Version)
+	return nil
+}
+
+// embedChunks embeds the chunks after the checkpoint of the index in ID order and
+// advances the checkpoint after every batch.
+func (t *ReembedTask) embedChunks(ctx context.Context, index *model.EmbeddingIndex) error {
+	for {
+		var chunks []*model.Chunk
+		err := t.db.WithContext(ctx).
+			Where("id > ? AND deleted_at IS NULL", index.LastChunkID).
+			Preload("Page").
+			Preload("Document").
+			Order("id").
+			Limit(reembedBatchSize).
+			Find(&chunks).Error
+		if err != nil {
+			return fmt.Errorf("failed to load chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		vectors, err := t.embed(ctx, index, chunks)
+		if err != nil {
+			return fmt.Errorf("failed to create embeddings after chunk %d: %w", index.LastChunkID, err)
+		}
+		if err := t.vectorRepo.Upsert(ctx, chunks, vectors); err != nil {
+			return fmt.Errorf("failed to upsert vectors after chunk %d: %w", index.LastChunkID, err)
+		}
+
+		index.LastChunkID = chunks[len(chunks)-1].ID
+		if err := t.indexRepo.Update(ctx, index); err != nil {
+			return fmt.Errorf("failed to record progress of index %s: %w", index.Version, err)
+		}
+		log.Printf("Re-embedded %d chunks into index %s, up to chunk ID %d.", len(chunks), index.Version, index.LastChunkID)
+	}
+}
+
+// verify checks that the collection of the index holds a vector for every chunk and
+// marks the index ready. The vectors of chunks deleted after they were embedded are
+// removed first. If vectors are missing, e.g. of chunks committed out of ID order, they
+// are looked up and embedded before counting again.
+func (t *ReembedTask) verify(ctx context.Context, index *model.EmbeddingIndex) error {
+	if err := t.purgeDeletedChunks(ctx, index); err != nil {
+		return err
+	}
+	chunks, vectors, err := t.count(ctx, index)
+	if err != nil {
+		return err
+	}
+	if vectors < chunks {
+		log.Printf("Index %s holds %d vectors for %d chunks. Embedding the missing chunks...", index.Version, vectors, chunks)
+		if err := t.embedMissingChunks(ctx, index); err != nil {
+			return err
+		}
+		if chunks, vectors, err = t.count(ctx, index); err != nil {
+			return err
+		}
+	}
+	if vectors != chunks {
+		return fmt.Errorf("index %s holds %d vectors for %d chunks", index.Version, vectors, chunks)
+	}
+
+	index.Status = model.EmbeddingIndexReady
+	index.ChunkCount = vectors
+	if err := t.indexRepo.Update(ctx, index); err != nil {
+		return fmt.Errorf("failed to mark index %s ready: %w", index.Version, err)
+	}
+	return nil
+}
+
+// purgeDeletedChunks deletes the vectors of the soft-deleted chunks up to the checkpoint
+// from the index.
+func (t *ReembedTask) purgeDeletedChunks(ctx context.Context, index *model.EmbeddingIndex) error {
+	var lastID uint64
+	for {
+		var chunks []*model.Chunk
+		err := t.db.WithContext(ctx).
+			Select("id", "embedding_id").
+			Where("id > ? AND id <= ? AND deleted_at IS NOT NULL", lastID, index.LastChunkID).
+			Order("id").
+			Limit(reembedBatchSize).
+			Find(&chunks).Error
+		if err != nil {
+			return fmt.Errorf("failed to load deleted chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		lastID = chunks[len(chunks)-1].ID
+
+		ids := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			ids[i] = chunk.EmbeddingID
+		}
+		if err := t.vectorRepo.DeleteByIDs(ctx, ids); err != nil {
+			return fmt.Errorf("failed to delete vectors of deleted chunks from index %s: %w", index.Version, err)
+		}
+	}
+}
+
+// embed creates the embeddings of chunks with the model of the index. The chunks hold
+// the VectorHash of the model and dimensions they were first embedded with, so the
+// hashes of the index are computed here.
+func (t *ReembedTask) embed(ctx context.Context, index *model.EmbeddingIndex, chunks []*model.Chunk) ([][]float32, error) {
+	texts := make([]string, len(chunks))
+	hashes := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+		hashes[i] = model.ComputeVectorHash(index.Model, index.Dimensions, chunk.Text)
+	}
+	return t.embedder.Embed(ctx, texts, hashes)
+}
+
+// count returns the number of chunks and the number of vectors in the index.
+func (t *ReembedTask) count(ctx context.Context, index *model.EmbeddingIndex) (int, int, error) {
+	var chunks int64
+	if err := t.db.WithContext(ctx).Model(&model.Chunk{}).Where("deleted_at IS NULL").Count(&chunks).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count chunks: %w", err)
+	}
+	vectors, err := t.vectorRepo.Count(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count vectors of index %s: %w", index.Version, err)
+	}
+	return int(chunks), vectors, nil
+}
+
+// embedMissingChunks embeds the chunks up to the checkpoint that have no vector in the index.
+func (t *ReembedTask) embedMissingChunks(ctx context.Context, index *model.EmbeddingIndex) error {
+	var lastID uint64
+	for {
+		var chunks []*model.Chunk
+		err := t.db.WithContext(ctx).
+			Where("id > ? AND id <= ? AND deleted_at IS NULL", lastID, index.LastChunkID).
+			Preload("Page").
+			Preload("Document").
+			Order("id").
+			Limit(reembedBatchSize).
+			Find(&chunks).Error
+		if err != nil {
+			return fmt.Errorf("failed to load chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		lastID = chunks[len(chunks)-1].ID
+
+		ids := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			ids[i] = chunk.EmbeddingID
+		}
+		stored, err := t.vectorRepo.GetVectors(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to look up vectors of index %s: %w", index.Version, err)
+		}
+		found := make(map[string]bool, len(stored))
+		for _, cv := range stored {
+			found[cv.Chunk.EmbeddingID] = true
+		}
+		var missing []*model.Chunk
+		for _, chunk := range chunks {
+			if !found[chunk.EmbeddingID] {
+				missing = append(missing, chunk)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		vectors, err := t.embed(ctx, index, missing)
+		if err != nil {
+			return fmt.Errorf("failed to create embeddings of missing chunks: %w", err)
+		}
+		if err := t.vectorRepo.Upsert(ctx, missing, vectors); err != nil {
+			return fmt.Errorf("failed to upsert vectors of missing chunks: %w", err)
+		}
+		log.Printf("Embedded %d missing chunks into index %s.", len(missing), index.Version)
+	}
+}
+
+// RollbackIndexTask switches queries back to the previously active embedding index.
+type RollbackIndexTask struct {
+	db        *gorm.DB
+	indexRepo repository.EmbeddingIndexRepository
+	cacheRepo repository.CacheRepository
+}
+
+// NewRollbackIndexTask creates a new RollbackIndexTask.
+func NewRollbackIndexTask(db *gorm.DB, indexRepo repository.EmbeddingIndexRepository, cacheRepo repository.CacheRepository) *RollbackIndexTask {
+	return &RollbackIndexTask{db: db, indexRepo: indexRepo, cacheRepo: cacheRepo}
+}
+
+// Run executes the rollback task.
+func (t *RollbackIndexTask) Run(ctx context.Context) error {
+	active, err := t.indexRepo.FindActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to look up the active embedding index: %w", err)
+	}
+	indexes, err := t.indexRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list embedding indexes: %w", err)
+	}
+
+	// Indexes are listed most recently activated first.
+	var previous *model.EmbeddingIndex
+	for _, index := range indexes {
+		if index.ID != active.ID && index.Status == model.EmbeddingIndexReady && index.ActivatedAt != nil {
+			previous = index
+			break
+		}
+	}
+	if previous == nil {
+		return fmt.Errorf("no embedding index was active before %s", active.Version)
+	}
+
+	if err := t.indexRepo.Activate(ctx, previous.Version); err != nil {
+		return fmt.Errorf("failed to activate embedding index %s: %w", previous.Version, err)
+	}
+	bumpCorpusVersions(ctx, t.db, t.cacheRepo)
+	log.Printf("Rolled back from embedding index %s to %s. Chunks added while %s was active are missing from %s.",
+		active.Version, previous.Version, active.Version, previous.Version)
+	return nil
+}
+
+// bumpCorpusVersions invalidates the cached answers of all courses, whose cached query
+// embeddings belong to the index that was active before.
+func bumpCorpusVersions(ctx context.Context, db *gorm.DB, cacheRepo repository.CacheRepository) {
+	var courseIDs []uint64
+	if err := db.WithContext(ctx).Model(&model.Course{}).Pluck("id", &courseIDs).Error; err != nil {
+		log.Printf("ERROR: Failed to list courses to invalidate their answer caches: %v", err)
+		return
+	}
+	for _, courseID := range courseIDs {
+		if _, err := cacheRepo.Incr(ctx, repository.CorpusVersionKey(courseID)); err != nil {
+			log.Printf("ERROR: Failed to bump corpus version of course %d: %v", courseID, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -21,11 +22,12 @@ const (
 
 // SyncTask handles the synchronization of documents to the vector database.
 type SyncTask struct {
-	db            *gorm.DB
-	fileStorage   repository.FileStorage
-	chunkProc     processor.ChunkProcessor
-	embeddingRepo repository.EmbeddingRepository
-	vectorRepo    repository.VectorRepository
+	db          *gorm.DB
+	fileStorage repository.FileStorage
+	chunkProc   processor.ChunkProcessor
+	embedder    *CachedEmbedder
+	vectorRepo  repository.VectorRepository
+	cacheRepo   repository.CacheRepository
 }
 
 // NewSyncTask creates a new SyncTask.
@@ -33,15 +35,17 @@ func NewSyncTask(
 	db *gorm.DB,
 	fileStorage repository.FileStorage,
 	chunkProc processor.ChunkProcessor,
-	embeddingRepo repository.EmbeddingRepository,
+	embedder *CachedEmbedder,
 	vectorRepo repository.VectorRepository,
+	cacheRepo repository.CacheRepository,
 ) *SyncTask {
 	return &SyncTask{
-		db:            db,
-		fileStorage:   fileStorage,
-		chunkProc:     chunkProc,
-		embeddingRepo: embeddingRepo,
-		vectorRepo:    vectorRepo,
+		db:          db,
+		fileStorage: fileStorage,
+		chunkProc:   chunkProc,
+		embedder:    embedder,
+		vectorRepo:  vectorRepo,
+		cacheRepo:   cacheRepo,
 	}
 }
 
@@ -54,13 +58,15 @@ func (t *SyncTask) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to ensure Qdrant collection exists: %w", err)
 	}
 
+	var lastID uint64
 	for {
-		docs, err := t.findUnprocessedDocuments(ctx)
+		docs, err := t.findUnprocessedDocuments(ctx, lastID)
 		if err != nil {
 			return fmt.Errorf("failed to find unprocessed documents: %w", err)
 		}
 
 		if len(docs) == 0 {
+			t.embedder.LogStats()
 			log.Println("No new documents to process. Task finished.")
 			return nil
 		}
@@ -68,7 +74,13 @@ func (t *SyncTask) Run(ctx context.Context) error {
 		log.Printf("Found %d new documents to process.", len(docs))
 
 		for _, doc := range docs {
-			if err := t.processDocument(ctx, doc); err != nil {
+			lastID = doc.ID
+			stored, err := t.processDocument(ctx, doc)
+			// Even a failed document may have stored some of its chunks.
+			if stored {
+				t.bumpCorpusVersion(ctx, doc.CourseID)
+			}
+			if err != nil {
 				log.Printf("ERROR: Failed to process document ID %d: %v", doc.ID, err)
 				continue
 			}
@@ -80,35 +92,61 @@ func (t *SyncTask) Run(ctx context.Context) error {
 		}
 	}
 
+	t.embedder.LogStats()
 	log.Println("Document synchronization task completed.")
 	return nil
 }
 
-// findUnprocessedDocuments queries the database for documents that don't have corresponding chunks.
-func (t *SyncTask) findUnprocessedDocuments(ctx context.Context) ([]*model.Document, error) {
+// bumpCorpusVersion invalidates the cached answers of a course after its indexed
+// materials have changed.
+func (t *SyncTask) bumpCorpusVersion(ctx context.Context, courseID uint64) {
+	if _, err := t.cacheRepo.Incr(ctx, repository.CorpusVersionKey(courseID)); err != nil {
+		log.Printf("ERROR: Failed to bump corpus version of course %d: %v", courseID, err)
+	}
+}
+
+// findUnprocessedDocuments queries the database for documents after lastID that don't have
+// corresponding chunks. They are processed in upload order, so that a version replaces the one
+// before it. Documents that failed or yielded no chunks still have none, so a run continues after
+// the last document seen instead of selecting them again.
+func (t *SyncTask) findUnprocessedDocuments(ctx context.Context, lastID uint64) ([]*model.Document, error) {
 	var docs []*model.Document
 	err := t.db.WithContext(ctx).
-		Where("id NOT IN (SELECT DISTINCT document_id FROM chunks)").
+		Where("id > ? AND deleted_at IS NULL AND id NOT IN (SELECT DISTINCT document_id FROM chunks)", lastID).
+		Order("id").
 		Limit(documentBatchSize).
 		Find(&docs).Error
 	return docs, err
 }
 
-// processDocument handles the full pipeline for a single document.
-func (t *SyncTask) processDocument(ctx context.Context, doc *model.Document) error {
+// processDocument handles the full pipeline for a single document. It reports whether
+// any chunks were stored, which changes the answers of the course.
+func (t *SyncTask) processDocument(ctx context.Context, doc *model.Document) (bool, error) {
 	fileContent, err := t.fileStorage.Get(ctx, doc.SourceURI)
 	if err != nil {
-		return fmt.Errorf("failed to get file from storage for doc %d: %w", doc.ID, err)
+		return false, fmt.Errorf("failed to get file from storage for doc %d: %w", doc.ID, err)
 	}
 
 	pages, chunks, err := t.chunkProc.Process(ctx, doc, fileContent)
 	if err != nil {
-		return fmt.Errorf("failed to chunk document %d: %w", doc.ID, err)
+		return false, fmt.Errorf("failed to chunk document %d: %w", doc.ID, err)
 	}
 
 	if len(chunks) == 0 {
 		log.Printf("Document ID %d resulted in 0 chunks. Skipping.", doc.ID)
-		return nil
+		return false, nil
+	}
+
+	previous, err := t.findPreviousVersions(ctx, doc)
+	if err != nil {
+		return false, err
+	}
+	if len(previous) > 0 {
+		// The new version and the retirement of the previous ones are stored at once.
+		if err := t.replaceDocuments(ctx, doc, pages, chunks, previous); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
 
 	for i := 0; i < len(chunks); i += chunkBatchSize {
@@ -120,23 +158,24 @@ func (t *SyncTask) processDocument(ctx context.Context, doc *model.Document) err
 
 		log.Printf("Processing chunk batch for doc %d: %d-%d of %d", doc.ID, i, end-1, len(chunks))
 
-		texts := make([]string, len(chunkBatch))
-		for j, c := range chunkBatch {
-			texts[j] = c.Text
-		}
-		// ★★★ 修正点: taskTypeに "RETRIEVAL_DOCUMENT" を指定 ★★★
-		vectors, err := t.embeddingRepo.CreateEmbeddings(ctx, texts, "RETRIEVAL_DOCUMENT")
+		vectors, err := t.embedChunks(ctx, chunkBatch)
 		if err != nil {
-			return fmt.Errorf("failed to create embeddings for doc %d: %w", doc.ID, err)
+			return i > 0, fmt.Errorf("failed to create embeddings for doc %d: %w", doc.ID, err)
 		}
 
 		err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-			if err := tx.Create(&pages).Error; err != nil {
-				// This might fail if pages already exist, needs more robust logic
-				// For now, we assume pages are new for each document.
+			// Pages are stored with the first batch; a document is only picked up
+			// again if none of its chunks were stored, so they are new here.
+			if i == 0 {
+				if err := tx.Create(&pages).Error; err != nil {
+					return fmt.Errorf("failed to create pages for doc %d: %w", doc.ID, err)
+				}
+			}
+			if err := linkChunksToPages(chunkBatch, pages); err != nil {
+				return err
 			}
 
-			if err := tx.Create(&chunkBatch).Error; err != nil {
+			if err := tx.Omit(clause.Associations).Create(&chunkBatch).Error; err != nil {
 				return err
 			}
 
@@ -148,11 +187,117 @@ func (t *SyncTask) processDocument(ctx context.Context, doc *model.Document) err
 		})
 
 		if err != nil {
-			return fmt.Errorf("database transaction failed for doc %d: %w", doc.ID, err)
+			return i > 0, fmt.Errorf("database transaction failed for doc %d: %w", doc.ID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// embedChunks returns the vectors of the chunks. Only texts without a cached embedding
+// are sent to the embedding repository, which splits them and paces the requests to stay
+// within the API limits.
+func (t *SyncTask) embedChunks(ctx context.Context, chunks []*model.Chunk) ([][]float32, error) {
+	texts := make([]string, len(chunks))
+	hashes := make([]string, len(chunks))
+	for j, c := range chunks {
+		texts[j] = c.Text
+		hashes[j] = c.VectorHash
+	}
+	return t.embedder.Embed(ctx, texts, hashes)
+}
+
+// findPreviousVersions returns the live documents of the course with the same title and
+// a lower version, which a newly ingested version replaces.
+func (t *SyncTask) findPreviousVersions(ctx context.Context, doc *model.Document) ([]*model.Document, error) {
+	var previous []*model.Document
+	err := t.db.WithContext(ctx).
+		Where("deleted_at IS NULL AND course_id = ? AND title = ? AND version < ? AND id <> ?", doc.CourseID, doc.Title, doc.Version, doc.ID).
+		Find(&previous).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find previous versions of doc %d: %w", doc.ID, err)
+	}
+	return previous, nil
+}
+
+// replaceDocuments ingests a new version of a document. All of its chunks are embedded
+// first; the new rows are then stored, the previous versions soft-deleted and their
+// vectors swapped for the new ones in one transaction, so that searches move from the
+// old version to the new one at once.
+func (t *SyncTask) replaceDocuments(ctx context.Context, doc *model.Document, pages []*model.Page, chunks []*model.Chunk, previous []*model.Document) error {
+	vectors := make([][]float32, 0, len(chunks))
+	for i := 0; i < len(chunks); i += chunkBatchSize {
+		batchVectors, err := t.embedChunks(ctx, chunks[i:min(i+chunkBatchSize, len(chunks))])
+		if err != nil {
+			return fmt.Errorf("failed to create embeddings for doc %d: %w", doc.ID, err)
 		}
+		vectors = append(vectors, batchVectors...)
+	}
 
-		time.Sleep(500 * time.Millisecond)
+	previousIDs := make([]uint64, len(previous))
+	for i, old := range previous {
+		previousIDs[i] = old.ID
+	}
+
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&pages).Error; err != nil {
+			return fmt.Errorf("failed to create pages for doc %d: %w", doc.ID, err)
+		}
+		if err := linkChunksToPages(chunks, pages); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).CreateInBatches(&chunks, chunkBatchSize).Error; err != nil {
+			return err
+		}
+		if err := retireDocuments(tx, previousIDs); err != nil {
+			return err
+		}
+		if err := t.vectorRepo.ReplaceDocuments(ctx, previousIDs, chunks, vectors); err != nil {
+			return fmt.Errorf("failed to replace vectors of docs %v with doc %d: %w", previousIDs, doc.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("database transaction failed for doc %d: %w", doc.ID, err)
 	}
+	log.Printf("Document ID %d (version %d) replaced documents %v.", doc.ID, doc.Version, previousIDs)
 
+	for _, old := range previous {
+		if err := t.fileStorage.Delete(ctx, old.SourceURI); err != nil {
+			log.Printf("WARN: Failed to delete file %s of replaced doc %d: %v", old.SourceURI, old.ID, err)
+		}
+	}
+	return nil
+}
+
+// retireDocuments soft-deletes documents together with their pages and chunks.
+func retireDocuments(tx *gorm.DB, documentIDs []uint64) error {
+	now := time.Now()
+	if err := tx.Model(&model.Document{}).Where("id IN ? AND deleted_at IS NULL", documentIDs).Update("deleted_at", now).Error; err != nil {
+		return fmt.Errorf("failed to delete docs %v: %w", documentIDs, err)
+	}
+	if err := tx.Model(&model.Page{}).Where("document_id IN ? AND deleted_at IS NULL", documentIDs).Update("deleted_at", now).Error; err != nil {
+		return fmt.Errorf("failed to delete pages of docs %v: %w", documentIDs, err)
+	}
+	if err := tx.Model(&model.Chunk{}).Where("document_id IN ? AND deleted_at IS NULL", documentIDs).Update("deleted_at", now).Error; err != nil {
+		return fmt.Errorf("failed to delete chunks of docs %v: %w", documentIDs, err)
+	}
+	return nil
+}
+
+// linkChunksToPages sets the PageID of every chunk from the stored page with the
+// same page number.
+func linkChunksToPages(chunks []*model.Chunk, pages []*model.Page) error {
+	pageIDs := make(map[int]uint64, len(pages))
+	for _, page := range pages {
+		pageIDs[page.PageNumber] = page.ID
+	}
+	for _, chunk := range chunks {
+		pageID, ok := pageIDs[chunk.Page.PageNumber]
+		if !ok || pageID == 0 {
+			return fmt.Errorf("no stored page %d for chunk %d of doc %d", chunk.Page.PageNumber, chunk.ChunkIndex, chunk.DocumentID)
+		}
+		chunk.PageID = pageID
+	}
 	return nil
 }
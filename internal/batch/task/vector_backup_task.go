@@ -0,0 +1,386 @@
+// open-rag-lecture/internal/batch/task/vector_backup_task.go
+
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	vectorBackupBatchSize = 256
+	vectorBackupManifest  = "manifest.json"
+	vectorBackupPoints    = "points.jsonl"
+)
+
+// SnapshotVectorsTask backs up the vectors of the active embedding index into a new
+// directory under the backup directory, so that the index can be restored without
+// embedding every chunk again. Qdrant collections are backed up with their own
+// snapshots; other vector stores are streamed into a JSONL file. The manifest is
+// written last, so a directory without one holds an incomplete backup.
+type SnapshotVectorsTask struct {
+	index        *model.EmbeddingIndex
+	vectorRepo   repository.VectorRepository
+	snapshotRepo repository.VectorSnapshotRepository // May be nil
+	backupDir    string
+}
+
+// NewSnapshotVectorsTask creates a new SnapshotVectorsTask.
+func NewSnapshotVectorsTask(
+	index *model.EmbeddingIndex,
+	vectorRepo repository.VectorRepository,
+	snapshotRepo repository.VectorSnapshotRepository,
+	backupDir string,
+) *SnapshotVectorsTask {
+	return &SnapshotVectorsTask{
+		index:        index,
+		vectorRepo:   vectorRepo,
+		snapshotRepo: snapshotRepo,
+		backupDir:    backupDir,
+	}
+}
+
+// Run executes the snapshot task.
+func (t *SnapshotVectorsTask) Run(ctx context.Context) error {
+	createdAt := time.Now().UTC()
+	dir := filepath.Join(t.backupDir, createdAt.Format("20060102T150405Z")+"_"+t.index.Version)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	backup := &model.VectorBackup{
+		IndexVersion: t.index.Version,
+		Model:        t.index.Model,
+		Dimensions:   t.index.Dimensions,
+		CreatedAt:    createdAt,
+	}
+
+	if t.snapshotRepo != nil {
+		files, err := t.snapshotRepo.CreateSnapshots(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot index %s: %w", t.index.Version, err)
+		}
+		backup.Format = model.VectorBackupQdrantSnapshot
+		backup.Files = files
+		if backup.Points, err = t.vectorRepo.Count(ctx); err != nil {
+			return fmt.Errorf("failed to count vectors of index %s: %w", t.index.Version, err)
+		}
+	} else {
+		file, points, err := t.writePoints(ctx, dir)
+		if err != nil {
+			return err
+		}
+		backup.Format = model.VectorBackupJSONL
+		backup.Files = []model.VectorSnapshot{*file}
+		backup.Points = points
+	}
+
+	if err := writeManifest(dir, backup); err != nil {
+		return err
+	}
+	log.Printf("Backed up %d vectors of index %s to %s.", backup.Points, t.index.Version, dir)
+	return nil
+}
+
+// writePoints streams all points of the index into the JSONL file of the backup.
+func (t *SnapshotVectorsTask) writePoints(ctx context.Context, dir string) (*model.VectorSnapshot, int, error) {
+	file, err := os.Create(filepath.Join(dir, vectorBackupPoints))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	w := io.MultiWriter(file, hash)
+	var points int
+	err = t.vectorRepo.Scan(ctx, vectorBackupBatchSize, func(batch []model.ChunkVector) error {
+		points += len(batch)
+		return WriteVectorPoints(w, batch)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to back up vectors of index %s: %w", t.index.Version, err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, 0, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		return nil, 0, err
+	}
+	return &model.VectorSnapshot{File: vectorBackupPoints, Size: info.Size(), Checksum: hex.EncodeToString(hash.Sum(nil))}, points, nil
+}
+
+// RestoreVectorsTask restores the vectors of the active embedding index from a backup
+// of an index of the same model. Only vectors whose chunk still exists in MySQL with
+// the embedding ID and text (vector hash) it had when it was backed up are kept; chunks
+// added since are left without vectors and reported.
+type RestoreVectorsTask struct {
+	db           *gorm.DB
+	index        *model.EmbeddingIndex
+	vectorRepo   repository.VectorRepository
+	snapshotRepo repository.VectorSnapshotRepository // May be nil
+	cacheRepo    repository.CacheRepository
+	backupDir    string
+	backupPath   string // Directory of the backup to restore; empty restores the latest
+}
+
+// NewRestoreVectorsTask creates a new RestoreVectorsTask.
+func NewRestoreVectorsTask(
+	db *gorm.DB,
+	index *model.EmbeddingIndex,
+	vectorRepo repository.VectorRepository,
+	snapshotRepo repository.VectorSnapshotRepository,
+	cacheRepo repository.CacheRepository,
+	backupDir string,
+	backupPath string,
+) *RestoreVectorsTask {
+	return &RestoreVectorsTask{
+		db:           db,
+		index:        index,
+		vectorRepo:   vectorRepo,
+		snapshotRepo: snapshotRepo,
+		cacheRepo:    cacheRepo,
+		backupDir:    backupDir,
+		backupPath:   backupPath,
+	}
+}
+
+// Run executes the restore task.
+func (t *RestoreVectorsTask) Run(ctx context.Context) error {
+	dir := t.backupPath
+	if dir == "" {
+		latest, err := latestBackup(t.backupDir)
+		if err != nil {
+			return err
+		}
+		dir = latest
+	}
+	backup, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+	if backup.Model != t.index.Model || backup.Dimensions != t.index.Dimensions {
+		return fmt.Errorf("backup of index %s (%s, %d dimensions) cannot be restored into index %s (%s, %d dimensions)",
+			backup.IndexVersion, backup.Model, backup.Dimensions, t.index.Version, t.index.Model, t.index.Dimensions)
+	}
+	for _, file := range backup.Files {
+		if err := verifyChecksum(dir, file); err != nil {
+			return err
+		}
+	}
+	log.Printf("Restoring index %s from the backup in %s, taken %s of index %s.", t.index.Version, dir, backup.CreatedAt.Format(time.RFC3339), backup.IndexVersion)
+
+	var restored, dropped int
+	switch backup.Format {
+	case model.VectorBackupQdrantSnapshot:
+		restored, dropped, err = t.restoreSnapshots(ctx, dir, backup)
+	case model.VectorBackupJSONL:
+		restored, dropped, err = t.restorePoints(ctx, dir)
+	default:
+		err = fmt.Errorf("unknown backup format: %s", backup.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	var chunks int64
+	if err := t.db.WithContext(ctx).Model(&model.Chunk{}).Where("deleted_at IS NULL").Count(&chunks).Error; err != nil {
+		return fmt.Errorf("failed to count chunks: %w", err)
+	}
+	if missing := int(chunks) - restored; missing > 0 {
+		log.Printf("WARN: %d chunks have no vector in the backup and cannot be retrieved until they are embedded again.", missing)
+	}
+	bumpCorpusVersions(ctx, t.db, t.cacheRepo)
+	log.Printf("Restored %d vectors into index %s; dropped %d vectors of chunks that were deleted or changed.", restored, t.index.Version, dropped)
+	return nil
+}
+
+// restoreSnapshots recovers the collections from their snapshots and then deletes the
+// points that no longer match a chunk.
+func (t *RestoreVectorsTask) restoreSnapshots(ctx context.Context, dir string, backup *model.VectorBackup) (int, int, error) {
+	if t.snapshotRepo == nil {
+		return 0, 0, errors.New("snapshot backups can only be restored into Qdrant")
+	}
+	if err := t.snapshotRepo.RestoreSnapshots(ctx, dir, backup.Files); err != nil {
+		return 0, 0, fmt.Errorf("failed to restore snapshots of index %s: %w", backup.IndexVersion, err)
+	}
+
+	var restored int
+	var stale []string
+	err := t.vectorRepo.Scan(ctx, vectorBackupBatchSize, func(points []model.ChunkVector) error {
+		chunks, err := t.matchingChunks(ctx, points)
+		if err != nil {
+			return err
+		}
+		for _, point := range points {
+			if _, ok := chunks[point.Chunk.ID]; ok {
+				restored++
+			} else {
+				stale = append(stale, point.Chunk.EmbeddingID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to check restored vectors: %w", err)
+	}
+	for ids := range slices.Chunk(stale, vectorBackupBatchSize) {
+		if err := t.vectorRepo.DeleteByIDs(ctx, ids); err != nil {
+			return 0, 0, fmt.Errorf("failed to delete vectors of deleted chunks: %w", err)
+		}
+	}
+	return restored, len(stale), nil
+}
+
+// restorePoints replaces the collection with the points of the JSONL file whose chunks
+// still match. The payloads are taken from the chunks, so they are current.
+func (t *RestoreVectorsTask) restorePoints(ctx context.Context, dir string) (int, int, error) {
+	file, err := os.Open(filepath.Join(dir, vectorBackupPoints))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	if err := t.vectorRepo.RecreateCollection(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to recreate collection of index %s: %w", t.index.Version, err)
+	}
+	var restored, dropped int
+	err = ReadVectorPoints(file, vectorBackupBatchSize, func(points []model.ChunkVector) error {
+		chunks, err := t.matchingChunks(ctx, points)
+		if err != nil {
+			return err
+		}
+		var matched []*model.Chunk
+		var vectors [][]float32
+		for _, point := range points {
+			if chunk, ok := chunks[point.Chunk.ID]; ok {
+				matched = append(matched, chunk)
+				vectors = append(vectors, point.Vector)
+			}
+		}
+		dropped += len(points) - len(matched)
+		if len(matched) == 0 {
+			return nil
+		}
+		if err := t.vectorRepo.Upsert(ctx, matched, vectors); err != nil {
+			return fmt.Errorf("failed to upsert restored vectors: %w", err)
+		}
+		restored += len(matched)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return restored, dropped, nil
+}
+
+// matchingChunks looks up the chunks of points and returns those that still match their
+// point by ID: a chunk matches if it is not deleted and has the embedding ID of the point
+// and, if both are known, its vector hash, i.e. its text was not changed since.
+func (t *RestoreVectorsTask) matchingChunks(ctx context.Context, points []model.ChunkVector) (map[uint64]*model.Chunk, error) {
+	ids := make([]uint64, len(points))
+	for i, point := range points {
+		ids[i] = point.Chunk.ID
+	}
+	var chunks []*model.Chunk
+	err := t.db.WithContext(ctx).
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Preload("Page").
+		Preload("Document").
+		Find(&chunks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunks: %w", err)
+	}
+	byID := make(map[uint64]*model.Chunk, len(chunks))
+	for _, chunk := range chunks {
+		byID[chunk.ID] = chunk
+	}
+
+	matching := make(map[uint64]*model.Chunk, len(points))
+	for _, point := range points {
+		chunk, ok := byID[point.Chunk.ID]
+		if !ok || chunk.EmbeddingID != point.Chunk.EmbeddingID {
+			continue
+		}
+		if chunk.VectorHash != "" && point.Chunk.VectorHash != "" && chunk.VectorHash != point.Chunk.VectorHash {
+			continue
+		}
+		matching[chunk.ID] = chunk
+	}
+	return matching, nil
+}
+
+// latestBackup returns the directory of the most recent complete backup. Backup
+// directories are named after the time they were taken.
+func latestBackup(backupDir string) (string, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+	for _, entry := range slices.Backward(entries) {
+		dir := filepath.Join(backupDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, vectorBackupManifest)); entry.IsDir() && err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no complete backup in %s", backupDir)
+}
+
+func writeManifest(dir string, backup *model.VectorBackup) error {
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, vectorBackupManifest+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, vectorBackupManifest))
+}
+
+func readManifest(dir string) (*model.VectorBackup, error) {
+	data, err := os.ReadFile(filepath.Join(dir, vectorBackupManifest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s holds no complete backup", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	var backup model.VectorBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest in %s: %w", dir, err)
+	}
+	return &backup, nil
+}
+
+// verifyChecksum checks that a file of a backup is intact before anything is replaced.
+func verifyChecksum(dir string, snapshot model.VectorSnapshot) error {
+	file, err := os.Open(filepath.Join(dir, snapshot.File))
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read backup file %s: %w", snapshot.File, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != snapshot.Checksum {
+		return fmt.Errorf("backup file %s is corrupted: checksum mismatch", snapshot.File)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// open-rag-lecture/internal/batch/task/vector_points.go
+
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// vectorPoint is a line of a JSONL vector backup. The payload keeps the field names of
+// the Qdrant payload.
+type vectorPoint struct {
+	ChunkID     uint64             `json:"chunk_id"`
+	EmbeddingID string             `json:"embedding_id"`
+	Vector      []float32          `json:"vector"`
+	Payload     vectorPointPayload `json:"payload"`
+}
+
+type vectorPointPayload struct {
+	DocumentID uint64        `json:"doc_id"`
+	CourseID   uint64        `json:"course_id"`
+	SemesterID uint64        `json:"semester_id"`
+	PageNumber int           `json:"page_number"`
+	Language   string        `json:"language,omitempty"`
+	DocType    model.DocType `json:"doc_type,omitempty"`
+	DocVersion int           `json:"doc_version"`
+	Text       string        `json:"text"`
+	VectorHash string        `json:"vector_hash,omitempty"`
+}
+
+// WriteVectorPoints writes points to w as JSON lines.
+func WriteVectorPoints(w io.Writer, points []model.ChunkVector) error {
+	encoder := json.NewEncoder(w)
+	for _, point := range points {
+		chunk := point.Chunk
+		err := encoder.Encode(vectorPoint{
+			ChunkID:     chunk.ID,
+			EmbeddingID: chunk.EmbeddingID,
+			Vector:      point.Vector,
+			Payload: vectorPointPayload{
+				DocumentID: chunk.DocumentID,
+				CourseID:   chunk.CourseID,
+				SemesterID: chunk.SemesterID,
+				PageNumber: chunk.Page.PageNumber,
+				Language:   chunk.Page.Language,
+				DocType:    chunk.Document.DocType,
+				DocVersion: chunk.Document.Version,
+				Text:       chunk.Text,
+				VectorHash: chunk.VectorHash,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write point %s: %w", chunk.EmbeddingID, err)
+		}
+	}
+	return nil
+}
+
+// ReadVectorPoints reads the JSON lines written by WriteVectorPoints from r and passes
+// the points to fn in batches of up to batchSize points.
+func ReadVectorPoints(r io.Reader, batchSize int, fn func(points []model.ChunkVector) error) error {
+	reader := bufio.NewReader(r)
+	batch := make([]model.ChunkVector, 0, max(batchSize, 1))
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read line %d: %w", line, err)
+		}
+		if len(data) > 0 {
+			var point vectorPoint
+			if err := json.Unmarshal(data, &point); err != nil {
+				return fmt.Errorf("invalid point on line %d: %w", line, err)
+			}
+			batch = append(batch, point.chunkVector())
+		}
+		if len(batch) > 0 && (len(batch) == cap(batch) || err == io.EOF) {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]model.ChunkVector, 0, max(batchSize, 1))
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func (p *vectorPoint) chunkVector() model.ChunkVector {
+	return model.ChunkVector{
+		Chunk: model.Chunk{
+			Base:        model.Base{ID: p.ChunkID},
+			DocumentID:  p.Payload.DocumentID,
+			CourseID:    p.Payload.CourseID,
+			SemesterID:  p.Payload.SemesterID,
+			Text:        p.Payload.Text,
+			EmbeddingID: p.EmbeddingID,
+			VectorHash:  p.Payload.VectorHash,
+			Page:        model.Page{PageNumber: p.Payload.PageNumber, Language: p.Payload.Language},
+			Document:    model.Document{DocType: p.Payload.DocType, Version: p.Payload.DocVersion},
+		},
+		Vector: p.Vector,
+	}
+}
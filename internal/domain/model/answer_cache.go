@@ -0,0 +1,15 @@
+// OpenRAGLecture/internal/domain/model/answer_cache.go
+package model
+
+import "time"
+
+// SemanticCacheEntry is a cached answer stored under the embedding of the question it
+// answers, so that paraphrases of the question can be served from the cache.
+type SemanticCacheEntry struct {
+	CourseID      uint64
+	CorpusVersion string // Version of the course materials the answer was generated from
+	Query         string
+	Vector        []float32 // Embedding of Query
+	Answer        string    // Serialized answer; opaque to the repository
+	CreatedAt     time.Time
+}
@@ -1,6 +1,15 @@
 // OpenRAGLecture/internal/domain/model/chunk.go
 package model
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
 // Chunk represents a piece of text to be vectorized.
 type Chunk struct {
 	Base
@@ -24,6 +33,22 @@ type Chunk struct {
 	Semester Semester `gorm:"foreignKey:SemesterID"`
 }
 
+// ComputeVectorHash returns the VectorHash of a chunk text embedded with the given
+// model version into vectors of the given dimensions, which some models let choose.
+// Chunks with the same hash have the same embedding. The text is normalized first, so
+// that texts differing only in Unicode composition or whitespace share the hash.
+func ComputeVectorHash(embeddingModelVersion string, dimensions int, text string) string {
+	normalized := strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+	sum := sha256.Sum256([]byte(embeddingModelVersion + "\x00" + strconv.Itoa(dimensions) + "\x00" + normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChunkVector is a chunk as stored in the vector database, together with its embedding.
+type ChunkVector struct {
+	Chunk  Chunk
+	Vector []float32
+}
+
 // RetrievedChunk is a struct holding a chunk and its retrieval score.
 type RetrievedChunk struct {
 	Chunk Chunk
@@ -0,0 +1,32 @@
+// OpenRAGLecture/internal/domain/model/conversation.go
+package model
+
+// MessageRole defines who authored a conversation message.
+type MessageRole string
+
+const (
+	MessageRoleUser      MessageRole = "user"
+	MessageRoleAssistant MessageRole = "assistant"
+)
+
+// Conversation is a multi-turn QA session of a user within a course.
+type Conversation struct {
+	Base
+	SessionID string `gorm:"type:char(36);not null;uniqueIndex"`
+	UserID    uint64 `gorm:"not null;index"`
+	CourseID  uint64 `gorm:"not null"`
+	Title     string `gorm:"size:255"`
+
+	User   User   `gorm:"foreignKey:UserID"`
+	Course Course `gorm:"foreignKey:CourseID"`
+}
+
+// ConversationMessage is a single turn (question or answer) in a conversation.
+type ConversationMessage struct {
+	Base
+	ConversationID uint64      `gorm:"not null;index"`
+	Role           MessageRole `gorm:"type:enum('user','assistant');not null"`
+	Content        string      `gorm:"type:longtext;not null"`
+
+	Conversation Conversation `gorm:"foreignKey:ConversationID"`
+}
@@ -21,11 +21,22 @@ type Course struct {
 	InstructorID uint64
 	Description  string `gorm:"type:text"`
 	IsActive     bool   `gorm:"not null;default:true"`
+	// UseLLMExpansion enables LLM query expansion and ambiguity scoring for questions in this course.
+	UseLLMExpansion bool `gorm:"not null;default:false"`
 
 	Semester   Semester `gorm:"foreignKey:SemesterID"`
 	Instructor User     `gorm:"foreignKey:InstructorID"`
 }
 
+// CourseSettings holds the per-course overrides of the QA pipeline settings.
+type CourseSettings struct {
+	Base
+	CourseID  uint64             `gorm:"not null;uniqueIndex"`
+	Retrieval RetrievalOverrides `gorm:"embedded;embeddedPrefix:retrieval_"`
+
+	Course Course
+}
+
 // Enrollment represents a user's enrollment in a course.
 type Enrollment struct {
 	Base
@@ -0,0 +1,13 @@
+// OpenRAGLecture/internal/domain/model/embedding_cache.go
+package model
+
+import "time"
+
+// EmbeddingCacheEntry is the document embedding of a chunk text, stored under the
+// VectorHash of the text so that it is never paid for twice.
+type EmbeddingCacheEntry struct {
+	VectorHash string    `gorm:"size:128;primaryKey"`
+	Model      string    `gorm:"size:128;not null"` // Embedding model version the hash was computed for
+	Vector     []float32 `gorm:"type:json;serializer:json;not null"`
+	CreatedAt  time.Time `gorm:"not null"`
+}
@@ -0,0 +1,28 @@
+// OpenRAGLecture/internal/domain/model/embedding_index.go
+package model
+
+import "time"
+
+// Embedding index states. An index is built, then checked and made ready; exactly one
+// index is active, and a ready index can be activated again to roll back.
+const (
+	EmbeddingIndexBuilding = "building"
+	EmbeddingIndexReady    = "ready"
+	EmbeddingIndexActive   = "active"
+)
+
+// EmbeddingIndex is a vector collection holding the chunks embedded by one model.
+// Queries are embedded with the model of the active index.
+type EmbeddingIndex struct {
+	Base
+	Version        string `gorm:"size:191;not null;uniqueIndex"`
+	Provider       string `gorm:"size:32;not null"`
+	Model          string `gorm:"size:128;not null"` // Embedding model name, as recorded in Chunk.EmbeddingModelVersion
+	Dimensions     int    `gorm:"not null"`
+	SparseVector   string `gorm:"size:64"` // Qdrant sparse vector of BM25 term weights; empty if the collection has none
+	CollectionName string `gorm:"size:255;not null"`
+	Status         string `gorm:"size:16;not null;index"`
+	LastChunkID    uint64 // Chunks up to this ID have been embedded into the index
+	ChunkCount     int    // Points counted in the collection when the index was checked
+	ActivatedAt    *time.Time
+}
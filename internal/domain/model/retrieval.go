@@ -0,0 +1,188 @@
+// OpenRAGLecture/internal/domain/model/retrieval.go
+package model
+
+import (
+	"slices"
+	"sort"
+)
+
+const (
+	maxRetrievalTopK    = 50
+	maxRetrievalWeight  = 10
+	maxRetrievalContext = 20
+	defaultRRFK         = 60
+	defaultMMRLambda    = 0.7
+	minContextTokens    = 100
+	maxContextTokens    = 32000
+	maxNeighborChunks   = 5
+)
+
+// RetrievalConfig holds the parameters of the retrieval stage of the QA pipeline.
+type RetrievalConfig struct {
+	BM25TopK           int     `json:"bm25_top_k"`           // Full-text hits per search text; 0 disables full-text search
+	VectorTopK         int     `json:"vector_top_k"`         // Vector hits per search text; 0 disables vector search
+	BM25Weight         float64 `json:"bm25_weight"`          // Weight of full-text results in rank fusion
+	VectorWeight       float64 `json:"vector_weight"`        // Weight of vector results in rank fusion
+	RRFK               float64 `json:"rrf_k"`                // Rank constant of reciprocal rank fusion
+	RerankCandidates   int     `json:"rerank_candidates"`    // Fused candidates passed to the reranker
+	ContextSize        int     `json:"context_size"`         // Reranked chunks considered for the LLM context
+	MinScore           float32 `json:"min_score"`            // Minimum similarity of a vector hit
+	ContextTokenBudget int     `json:"context_token_budget"` // Estimated tokens the LLM context may use
+	NeighborChunks     int     `json:"neighbor_chunks"`      // Adjacent chunks added on each side of a retrieved chunk
+	MMRLambda          float64 `json:"mmr_lambda"`           // Relevance weight of MMR diversification; 1 disables it, 0 selects the default
+
+	// KeywordSearch selects the backend of the keyword search. It depends on the
+	// deployment, so courses and requests cannot override it.
+	KeywordSearch KeywordSearch `json:"-"`
+}
+
+// KeywordSearch is a backend of the keyword half of hybrid search.
+type KeywordSearch string
+
+const (
+	// KeywordSearchMySQL runs a FULLTEXT search on MySQL next to the vector search.
+	KeywordSearchMySQL KeywordSearch = "mysql"
+	// KeywordSearchSparse runs one hybrid query on the dense and sparse vectors of the
+	// vector store.
+	KeywordSearchSparse KeywordSearch = "sparse"
+)
+
+// DefaultRetrievalConfig returns the retrieval parameters used when nothing is configured.
+func DefaultRetrievalConfig() RetrievalConfig {
+	return RetrievalConfig{
+		BM25TopK:           5,
+		VectorTopK:         5,
+		BM25Weight:         1,
+		VectorWeight:       1,
+		RRFK:               defaultRRFK,
+		RerankCandidates:   10,
+		ContextSize:        3,
+		ContextTokenBudget: 3000,
+		NeighborChunks:     1,
+		MMRLambda:          defaultMMRLambda,
+	}
+}
+
+// RetrievalOverrides overrides individual RetrievalConfig values. Nil fields keep the base value.
+type RetrievalOverrides struct {
+	BM25TopK           *int     `json:"bm25_top_k,omitempty" gorm:"column:bm25_top_k"`
+	VectorTopK         *int     `json:"vector_top_k,omitempty" gorm:"column:vector_top_k"`
+	BM25Weight         *float64 `json:"bm25_weight,omitempty" gorm:"column:bm25_weight"`
+	VectorWeight       *float64 `json:"vector_weight,omitempty" gorm:"column:vector_weight"`
+	RRFK               *float64 `json:"rrf_k,omitempty" gorm:"column:rrf_k"`
+	RerankCandidates   *int     `json:"rerank_candidates,omitempty" gorm:"column:rerank_candidates"`
+	ContextSize        *int     `json:"context_size,omitempty" gorm:"column:context_size"`
+	MinScore           *float32 `json:"min_score,omitempty" gorm:"column:min_score"`
+	ContextTokenBudget *int     `json:"context_token_budget,omitempty" gorm:"column:context_token_budget"`
+	NeighborChunks     *int     `json:"neighbor_chunks,omitempty" gorm:"column:neighbor_chunks"`
+	MMRLambda          *float64 `json:"mmr_lambda,omitempty" gorm:"column:mmr_lambda"`
+}
+
+// Apply returns the config with the non-nil overrides applied and all values
+// clamped to their supported ranges. A nil overrides only clamps.
+func (c RetrievalConfig) Apply(o *RetrievalOverrides) RetrievalConfig {
+	if o != nil {
+		setIfNotNil(&c.BM25TopK, o.BM25TopK)
+		setIfNotNil(&c.VectorTopK, o.VectorTopK)
+		setIfNotNil(&c.BM25Weight, o.BM25Weight)
+		setIfNotNil(&c.VectorWeight, o.VectorWeight)
+		setIfNotNil(&c.RRFK, o.RRFK)
+		setIfNotNil(&c.RerankCandidates, o.RerankCandidates)
+		setIfNotNil(&c.ContextSize, o.ContextSize)
+		setIfNotNil(&c.MinScore, o.MinScore)
+		setIfNotNil(&c.ContextTokenBudget, o.ContextTokenBudget)
+		setIfNotNil(&c.NeighborChunks, o.NeighborChunks)
+		setIfNotNil(&c.MMRLambda, o.MMRLambda)
+	}
+
+	c.BM25TopK = clamp(c.BM25TopK, 0, maxRetrievalTopK)
+	c.VectorTopK = clamp(c.VectorTopK, 0, maxRetrievalTopK)
+	c.BM25Weight = clamp(c.BM25Weight, 0, maxRetrievalWeight)
+	c.VectorWeight = clamp(c.VectorWeight, 0, maxRetrievalWeight)
+	if c.RRFK <= 0 {
+		c.RRFK = defaultRRFK
+	}
+	c.ContextSize = clamp(c.ContextSize, 1, maxRetrievalContext)
+	c.RerankCandidates = clamp(c.RerankCandidates, c.ContextSize, maxRetrievalTopK)
+	c.MinScore = clamp(c.MinScore, 0, 1)
+	c.ContextTokenBudget = clamp(c.ContextTokenBudget, minContextTokens, maxContextTokens)
+	c.NeighborChunks = clamp(c.NeighborChunks, 0, maxNeighborChunks)
+	if c.MMRLambda <= 0 {
+		c.MMRLambda = defaultMMRLambda
+	}
+	c.MMRLambda = min(c.MMRLambda, 1)
+	return c
+}
+
+// SearchFilter restricts a search to the chunks of a course and, optionally, to some of
+// its documents, a semester or a document type. Zero values do not restrict.
+type SearchFilter struct {
+	CourseID    uint64   `json:"-"`
+	DocumentIDs []uint64 `json:"document_ids,omitempty" binding:"max=100"`
+	SemesterID  uint64   `json:"semester_id,omitempty"`
+	DocType     DocType  `json:"doc_type,omitempty" binding:"omitempty,oneof=slides pdf notes webpage other"`
+}
+
+// Narrowed reports whether the filter restricts the search beyond the course.
+func (f SearchFilter) Narrowed() bool {
+	return len(f.DocumentIDs) > 0 || f.SemesterID != 0 || f.DocType != ""
+}
+
+// Matches reports whether a chunk passes the filter. The document type is taken from
+// chunk.Document.
+func (f SearchFilter) Matches(chunk Chunk) bool {
+	if chunk.CourseID != f.CourseID {
+		return false
+	}
+	if len(f.DocumentIDs) > 0 && !slices.Contains(f.DocumentIDs, chunk.DocumentID) {
+		return false
+	}
+	if f.SemesterID != 0 && chunk.SemesterID != f.SemesterID {
+		return false
+	}
+	return f.DocType == "" || chunk.Document.DocType == f.DocType
+}
+
+// HybridQuery is a query on both the dense vectors and the sparse term vectors of a
+// vector store, whose hits are fused by weighted reciprocal rank fusion.
+type HybridQuery struct {
+	Text         string    // Text whose terms form the sparse query
+	Vector       []float32 // Dense query embedding
+	DenseLimit   int       // Dense hits fused; 0 disables the dense search
+	SparseLimit  int       // Sparse hits fused; 0 disables the sparse search
+	DenseWeight  float64
+	SparseWeight float64
+	RRFK         float64
+	MinScore     float32 // Minimum similarity of a dense hit
+}
+
+// FuseRankings combines ranked lists of hits by weighted reciprocal rank fusion. The
+// hits are returned by descending fused score, which replaces their Score.
+func FuseRankings(rrfK float64, weights []float64, lists ...[]RetrievedChunk) []RetrievedChunk {
+	scores := make(map[uint64]float64)
+	var fused []RetrievedChunk
+	for l, list := range lists {
+		for rank, hit := range list {
+			id := hit.Chunk.ID
+			if _, ok := scores[id]; !ok {
+				fused = append(fused, hit)
+			}
+			scores[id] += weights[l] / (float64(rank) + rrfK)
+		}
+	}
+	for i := range fused {
+		fused[i].Score = float32(scores[fused[i].Chunk.ID])
+	}
+	sort.SliceStable(fused, func(a, b int) bool { return fused[a].Score > fused[b].Score })
+	return fused
+}
+
+func setIfNotNil[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func clamp[T int | float32 | float64](value, lo, hi T) T {
+	return min(max(value, lo), hi)
+}
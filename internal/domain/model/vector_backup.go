@@ -0,0 +1,30 @@
+// OpenRAGLecture/internal/domain/model/vector_backup.go
+package model
+
+import "time"
+
+// Formats of a vector backup.
+const (
+	VectorBackupQdrantSnapshot = "qdrant-snapshot" // Qdrant collection snapshots, one file per collection
+	VectorBackupJSONL          = "jsonl"           // One JSON object per point with its chunk ID, vector and payload
+)
+
+// VectorBackup is the manifest of a backup of the vectors of an embedding index. Vectors
+// can only be restored into an index of the same model and dimensions.
+type VectorBackup struct {
+	Format       string           `json:"format"`
+	IndexVersion string           `json:"index_version"`
+	Model        string           `json:"model"`
+	Dimensions   int              `json:"dimensions"`
+	Points       int              `json:"points"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Files        []VectorSnapshot `json:"files"`
+}
+
+// VectorSnapshot is a file of a vector backup.
+type VectorSnapshot struct {
+	CourseID uint64 `json:"course_id,omitempty"` // Course of the collection, with a collection per course
+	File     string `json:"file"`                // Name of the file in the backup directory
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"` // Hex-encoded SHA-256 of the file
+}
@@ -0,0 +1,19 @@
+// OpenRAGLecture/internal/domain/model/vector_record.go
+package model
+
+import "time"
+
+// VectorRecord is the embedding of a chunk in a collection of the MySQL vector store.
+// The vector is packed as little-endian float32 values.
+type VectorRecord struct {
+	CollectionName string    `gorm:"size:255;primaryKey;index:idx_vector_records_course,priority:1;index:idx_vector_records_document,priority:1"`
+	ChunkID        uint64    `gorm:"primaryKey;autoIncrement:false"`
+	CourseID       uint64    `gorm:"not null;index:idx_vector_records_course,priority:2"`
+	DocumentID     uint64    `gorm:"not null;index:idx_vector_records_document,priority:2"`
+	SemesterID     uint64    `gorm:"not null"`
+	DocType        DocType   `gorm:"size:16;not null"`
+	EmbeddingID    string    `gorm:"size:128;not null;index"`
+	VectorHash     string    `gorm:"size:128"`
+	Vector         []byte    `gorm:"type:longblob;not null"`
+	UpdatedAt      time.Time `gorm:"not null"`
+}
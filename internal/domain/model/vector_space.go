@@ -0,0 +1,19 @@
+// OpenRAGLecture/internal/domain/model/vector_space.go
+package model
+
+// Tenancy strategies separating the vectors of courses in a vector store.
+const (
+	TenancyShared     = "shared"     // One collection, courses told apart by the course_id payload
+	TenancyCollection = "collection" // One collection per course
+	TenancyShardKey   = "shard_key"  // One collection with a custom shard key per course
+)
+
+// VectorSpaceStats describes where the vectors of a course are kept and how many there are.
+type VectorSpaceStats struct {
+	CourseID   uint64 `json:"course_id"`
+	Tenancy    string `json:"tenancy"`
+	Collection string `json:"collection"`
+	ShardKey   string `json:"shard_key,omitempty"`
+	Exists     bool   `json:"exists"` // Whether the course's collection or shard key exists
+	Points     int    `json:"points"`
+}
@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -10,4 +11,14 @@ type CacheRepository interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	Delete(ctx context.Context, key string) error
+	// Incr atomically increments the integer stored at key, starting from zero, and
+	// returns the new value.
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// CorpusVersionKey returns the cache key of the version of a course's indexed
+// materials. The version is incremented whenever documents of the course are
+// ingested or deleted, so that cached answers of older versions are never served.
+func CorpusVersionKey(courseID uint64) string {
+	return fmt.Sprintf("course:%d:corpus_version", courseID)
 }
@@ -0,0 +1,26 @@
+// OpenRAGLecture/internal/domain/repository/conversation_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// ConversationRepository defines the persistence of conversation sessions and their messages.
+type ConversationRepository interface {
+	// Create creates a new conversation session.
+	Create(ctx context.Context, conversation *model.Conversation) error
+	// FindBySessionID returns the conversation with the given session ID.
+	FindBySessionID(ctx context.Context, sessionID string) (*model.Conversation, error)
+	// ListByUser returns the conversations of a user, most recently active first.
+	// A courseID of zero returns the conversations of all courses.
+	ListByUser(ctx context.Context, userID, courseID uint64) ([]*model.Conversation, error)
+	// Delete soft-deletes a conversation together with its messages.
+	Delete(ctx context.Context, conversationID uint64) error
+	// AddMessages appends messages to a conversation and marks it as updated.
+	AddMessages(ctx context.Context, conversationID uint64, messages []*model.ConversationMessage) error
+	// ListMessages returns the latest messages of a conversation in chronological order.
+	// A limit of zero or less returns all messages.
+	ListMessages(ctx context.Context, conversationID uint64, limit int) ([]*model.ConversationMessage, error)
+}
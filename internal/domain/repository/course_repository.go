@@ -9,5 +9,9 @@ import (
 
 type CourseRepository interface {
 	FindByID(ctx context.Context, id uint64) (*model.Course, error)
+	// FindSettings returns the settings of a course, or nil if none have been saved.
+	FindSettings(ctx context.Context, courseID uint64) (*model.CourseSettings, error)
+	// SaveSettings creates or replaces the settings of a course.
+	SaveSettings(ctx context.Context, settings *model.CourseSettings) error
 	// CheckEnrollment は EnrollmentRepository に移管されました
 }
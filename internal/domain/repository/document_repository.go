@@ -7,9 +7,22 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 )
 
+// DocumentRepository reads and writes documents and their chunks. Soft-deleted rows are
+// never returned.
 type DocumentRepository interface {
 	FindByID(ctx context.Context, id uint64) (*model.Document, error)
+	// FindLatestVersion returns the document of a course with the given title and the
+	// highest version, or ErrDocumentNotFound.
+	FindLatestVersion(ctx context.Context, courseID uint64, title string) (*model.Document, error)
 	Create(ctx context.Context, doc *model.Document) error
+	// Delete soft-deletes a document together with its pages and chunks.
+	Delete(ctx context.Context, id uint64) error
 	// FullTextSearch performs a BM25-like search on the `pages` table.
-	FullTextSearch(ctx context.Context, query string, courseID uint64, limit int) ([]model.RetrievedChunk, error)
+	// Only chunks passing the filter are returned.
+	FullTextSearch(ctx context.Context, query string, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error)
+	// FindChunksByIDs returns the chunks with the given IDs, with their Page and Document preloaded.
+	FindChunksByIDs(ctx context.Context, ids []uint64) ([]*model.Chunk, error)
+	// FindChunksByIndexRange returns the chunks of a document whose ChunkIndex lies in
+	// [fromIndex, toIndex], ordered by ChunkIndex, with their Page and Document preloaded.
+	FindChunksByIndexRange(ctx context.Context, documentID uint64, fromIndex, toIndex int) ([]*model.Chunk, error)
 }
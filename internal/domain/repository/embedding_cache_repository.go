@@ -0,0 +1,17 @@
+// OpenRAGLecture/internal/domain/repository/embedding_cache_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// EmbeddingCacheRepository stores document embeddings by the VectorHash of their text.
+type EmbeddingCacheRepository interface {
+	// FindByHashes returns the cached embeddings of the given hashes, keyed by hash.
+	// Hashes without an embedding are left out.
+	FindByHashes(ctx context.Context, hashes []string) (map[string][]float32, error)
+	// Save stores embeddings. Entries whose hash is already cached are skipped.
+	Save(ctx context.Context, entries []*model.EmbeddingCacheEntry) error
+}
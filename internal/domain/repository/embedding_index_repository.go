@@ -0,0 +1,25 @@
+// OpenRAGLecture/internal/domain/repository/embedding_index_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// EmbeddingIndexRepository stores the registry of embedding indexes.
+type EmbeddingIndexRepository interface {
+	// Create records a new index.
+	Create(ctx context.Context, index *model.EmbeddingIndex) error
+	// FindByVersion returns the index with the given version.
+	FindByVersion(ctx context.Context, version string) (*model.EmbeddingIndex, error)
+	// FindActive returns the index that is queried.
+	FindActive(ctx context.Context) (*model.EmbeddingIndex, error)
+	// List returns all indexes, most recently activated first.
+	List(ctx context.Context) ([]*model.EmbeddingIndex, error)
+	// Update saves the progress and status of an index.
+	Update(ctx context.Context, index *model.EmbeddingIndex) error
+	// Activate makes the index with the given version the active one and the previously
+	// active index ready, in a single transaction.
+	Activate(ctx context.Context, version string) error
+}
@@ -12,6 +12,8 @@ type GenerateContentParams struct {
 	SystemPrompt  string
 	UserPrompt    string
 	ContextChunks []model.RetrievedChunk
+	// History holds the previous turns of the conversation, oldest first.
+	History []model.ConversationMessage
 }
 
 type LLMRepository interface {
@@ -19,4 +21,26 @@ type LLMRepository interface {
 	GenerateContent(ctx context.Context, params GenerateContentParams) (string, error)
 	// GenerateContentStream generates a response as a stream.
 	GenerateContentStream(ctx context.Context, params GenerateContentParams, writer io.Writer) error
+	// ModelName returns the name of the model that generates the responses.
+	ModelName() string
+}
+
+// GenerationInfo describes how a response was generated. Repositories that can answer
+// with one of several models report the one that answered in the GenerationInfo of the
+// request context, if there is one.
+type GenerationInfo struct {
+	Model string
+}
+
+type generationInfoKey struct{}
+
+// WithGenerationInfo returns a context that collects the GenerationInfo of the requests made with it.
+func WithGenerationInfo(ctx context.Context, info *GenerationInfo) context.Context {
+	return context.WithValue(ctx, generationInfoKey{}, info)
+}
+
+// GenerationInfoFromContext returns the GenerationInfo attached to the context, or nil.
+func GenerationInfoFromContext(ctx context.Context) *GenerationInfo {
+	info, _ := ctx.Value(generationInfoKey{}).(*GenerationInfo)
+	return info
 }
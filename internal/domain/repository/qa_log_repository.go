@@ -0,0 +1,18 @@
+// OpenRAGLecture/internal/domain/repository/qa_log_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// QALogRepository persists the questions asked, the answers given and the chunks they were based on.
+type QALogRepository interface {
+	// CreateQuestion stores a question. If SemesterID is zero it is resolved from the course.
+	CreateQuestion(ctx context.Context, question *model.Question) error
+	// CreateAnswer stores an answer for an already stored question.
+	CreateAnswer(ctx context.Context, answer *model.Answer) error
+	// CreateAnswerSources stores the chunks an answer was generated from.
+	CreateAnswerSources(ctx context.Context, sources []*model.AnswerSource) error
+}
@@ -0,0 +1,15 @@
+// OpenRAGLecture/internal/domain/repository/reranker.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// Reranker rescores retrieval candidates against the query before they are used as context.
+type Reranker interface {
+	// Rerank returns at most topN of the candidates, most relevant first.
+	// Candidates arrive in the order produced by rank fusion.
+	Rerank(ctx context.Context, query string, candidates []model.RetrievedChunk, topN int) ([]model.RetrievedChunk, error)
+}
@@ -0,0 +1,20 @@
+// OpenRAGLecture/internal/domain/repository/search_index.go
+package repository
+
+import "context"
+
+// SearchIndex holds the repositories of one embedding index. Vectors made by its
+// EmbeddingRepo can only be searched in its VectorRepo and AnswerCache.
+type SearchIndex struct {
+	EmbeddingRepo EmbeddingRepository
+	VectorRepo    VectorRepository
+	AnswerCache   SemanticCacheRepository // Nil if the semantic answer cache is disabled
+	HybridSearch  bool                    // Whether VectorRepo keeps the sparse vectors HybridSearch needs
+}
+
+// SearchIndexProvider resolves the embedding index that answers a question.
+type SearchIndexProvider interface {
+	// ActiveSearchIndex returns the repositories of the active index. A question uses
+	// the returned index throughout, even if another index is activated meanwhile.
+	ActiveSearchIndex(ctx context.Context) (*SearchIndex, error)
+}
@@ -0,0 +1,23 @@
+// OpenRAGLecture/internal/domain/repository/semantic_cache_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// SemanticCacheRepository stores answers under the embeddings of their questions.
+type SemanticCacheRepository interface {
+	// FindNearest returns the entry of the course and corpus version whose query vector
+	// is the most similar to the given one, together with its cosine similarity. Entries
+	// created before notBefore are ignored. It returns a nil entry if nothing matches.
+	FindNearest(ctx context.Context, courseID uint64, corpusVersion string, vector []float32, notBefore time.Time) (*model.SemanticCacheEntry, float32, error)
+	// Save stores a new entry.
+	Save(ctx context.Context, entry *model.SemanticCacheEntry) error
+	// DeleteByCourse removes all entries of a course.
+	DeleteByCourse(ctx context.Context, courseID uint64) error
+	// EnsureCollectionExists creates the underlying storage if it does not exist.
+	EnsureCollectionExists(ctx context.Context) error
+}
@@ -10,12 +10,40 @@ import (
 type VectorRepository interface {
 	// Upsert inserts or updates vectors (chunks) into the vector database.
 	Upsert(ctx context.Context, chunks []*model.Chunk, vectors [][]float32) error
-	// Search finds similar vectors based on a query vector.
-	Search(ctx context.Context, queryVector []float32, courseID uint64, limit int) ([]model.RetrievedChunk, error)
+	// Search finds similar vectors based on a query vector among the chunks passing the filter.
+	Search(ctx context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error)
+	// HybridSearch runs a dense and a sparse (BM25-weighted term) search among the chunks
+	// passing the filter as one query and returns the fused hits, with the fused score.
+	// It fails with ErrHybridSearchUnsupported if the store keeps no sparse vectors.
+	HybridSearch(ctx context.Context, query model.HybridQuery, filter model.SearchFilter) ([]model.RetrievedChunk, error)
+	// GetVectors returns the stored vectors and payloads of the given points (Chunk.EmbeddingID).
+	// Points that do not exist are left out.
+	GetVectors(ctx context.Context, embeddingIDs []string) ([]model.ChunkVector, error)
+	// DeleteByIDs removes the given points (Chunk.EmbeddingID). Points that do not exist are ignored.
+	DeleteByIDs(ctx context.Context, embeddingIDs []string) error
+	// DeleteByDocument removes the points of all chunks of a document.
+	DeleteByDocument(ctx context.Context, documentID uint64) error
+	// ReplaceDocuments upserts the chunks of a new document version and removes the points
+	// of the documents it replaces in a single operation, so that searches switch from the
+	// old version to the new one at once.
+	ReplaceDocuments(ctx context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error
+	// Scan passes all stored vectors and payloads to fn in batches of up to batchSize
+	// points, stopping at the first error fn returns.
+	Scan(ctx context.Context, batchSize int, fn func(points []model.ChunkVector) error) error
+	// Count returns the number of stored vectors.
+	Count(ctx context.Context) (int, error)
 	// RecreateCollection deletes a collection if it exists and creates a new one.
 	// This is useful for ensuring a clean state, especially for testing.
 	RecreateCollection(ctx context.Context) error
 	// EnsureCollectionExists checks if a collection exists, and creates it if it does not.
 	// This is useful for application startup or batch jobs.
 	EnsureCollectionExists(ctx context.Context) error
+	// CreateCourseSpace prepares the vector space of a course, i.e. its collection or
+	// shard key depending on the tenancy strategy. It is a no-op if the space exists.
+	CreateCourseSpace(ctx context.Context, courseID uint64) error
+	// DropCourseSpace removes all vectors of a course together with its collection or
+	// shard key. Dropping a missing space is not an error.
+	DropCourseSpace(ctx context.Context, courseID uint64) error
+	// CourseSpaceStats reports where the vectors of a course are kept and how many there are.
+	CourseSpaceStats(ctx context.Context, courseID uint64) (*model.VectorSpaceStats, error)
 }
@@ -0,0 +1,19 @@
+// OpenRAGLecture/internal/domain/repository/vector_snapshot_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// VectorSnapshotRepository backs up the collections of a vector store that has a
+// snapshot mechanism of its own.
+type VectorSnapshotRepository interface {
+	// CreateSnapshots snapshots every collection holding points and saves the snapshot
+	// files into dir.
+	CreateSnapshots(ctx context.Context, dir string) ([]model.VectorSnapshot, error)
+	// RestoreSnapshots replaces the collections with the snapshot files in dir. Collections
+	// without a snapshot are left as they are.
+	RestoreSnapshots(ctx context.Context, dir string, snapshots []model.VectorSnapshot) error
+}
@@ -0,0 +1,93 @@
+// open-rag-lecture/internal/interface/handler/admin_handler.go
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+type AdminHandler struct {
+	adminUsecase port.AdminUsecase
+}
+
+func NewAdminHandler(adminUsecase port.AdminUsecase) *AdminHandler {
+	return &AdminHandler{adminUsecase: adminUsecase}
+}
+
+// InvalidateAnswerCache drops the cached answers of a course.
+func (h *AdminHandler) InvalidateAnswerCache(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUsecase.InvalidateAnswerCache(c.Request.Context(), userID, courseID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateVectorSpace creates the vector space of a course.
+func (h *AdminHandler) CreateVectorSpace(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUsecase.CreateVectorSpace(c.Request.Context(), userID, courseID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DropVectorSpace deletes the vector space of a course with all of its vectors.
+func (h *AdminHandler) DropVectorSpace(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUsecase.DropVectorSpace(c.Request.Context(), userID, courseID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VectorSpaceStats reports the vector space of a course.
+func (h *AdminHandler) VectorSpaceStats(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.adminUsecase.VectorSpaceStats(c.Request.Context(), userID, courseID)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, appErrors.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+	case errors.Is(err, appErrors.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can perform this operation"})
+	case errors.Is(err, appErrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": appErrors.ErrInternalServerError.Error()})
+	}
+}
@@ -0,0 +1,89 @@
+// OpenRAGLecture/internal/interface/handler/conversation_handler.go
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/auth"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+type ConversationHandler struct {
+	conversationUsecase port.ConversationUsecase
+}
+
+func NewConversationHandler(conversationUsecase port.ConversationUsecase) *ConversationHandler {
+	return &ConversationHandler{conversationUsecase: conversationUsecase}
+}
+
+// List returns the conversations of the current user, optionally filtered by ?course_id=.
+func (h *ConversationHandler) List(c *gin.Context) {
+	userID, ok := auth.GetUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+		return
+	}
+
+	var courseID uint64
+	if courseIDStr := c.Query("course_id"); courseIDStr != "" {
+		var err error
+		courseID, err = strconv.ParseUint(courseIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course_id format"})
+			return
+		}
+	}
+
+	conversations, err := h.conversationUsecase.List(c.Request.Context(), userID, courseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": appErrors.ErrInternalServerError.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+}
+
+// Get returns a conversation of the current user with all of its messages.
+func (h *ConversationHandler) Get(c *gin.Context) {
+	userID, ok := auth.GetUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+		return
+	}
+
+	conversation, err := h.conversationUsecase.Get(c.Request.Context(), userID, c.Param("session_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// Delete deletes a conversation of the current user.
+func (h *ConversationHandler) Delete(c *gin.Context) {
+	userID, ok := auth.GetUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+		return
+	}
+
+	if err := h.conversationUsecase.Delete(c.Request.Context(), userID, c.Param("session_id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ConversationHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, appErrors.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": appErrors.ErrInternalServerError.Error()})
+}
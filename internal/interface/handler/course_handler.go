@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
 	"github.com/takumi-1234/OpenRAGLecture/pkg/auth"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
@@ -55,3 +56,56 @@ func (h *CourseHandler) Enroll(c *gin.Context) {
 	// 4. Return success response.
 	c.Status(http.StatusCreated)
 }
+
+// courseSettingsBody is the request and response body of the course settings endpoints.
+type courseSettingsBody struct {
+	Retrieval model.RetrievalOverrides `json:"retrieval"`
+}
+
+// GetSettings returns the retrieval settings of a course to its instructor.
+func (h *CourseHandler) GetSettings(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	retrieval, err := h.courseUsecase.GetRetrievalSettings(c.Request.Context(), userID, courseID)
+	if err != nil {
+		h.respondSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, courseSettingsBody{Retrieval: *retrieval})
+}
+
+// UpdateSettings replaces the retrieval settings of a course. Omitted values fall back to the global config.
+func (h *CourseHandler) UpdateSettings(c *gin.Context) {
+	userID, courseID, ok := bindCourseParams(c)
+	if !ok {
+		return
+	}
+
+	var body courseSettingsBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": appErrors.ErrBadRequest.Error()})
+		return
+	}
+
+	if err := h.courseUsecase.UpdateRetrievalSettings(c.Request.Context(), userID, courseID, body.Retrieval); err != nil {
+		h.respondSettingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
+
+func (h *CourseHandler) respondSettingsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, appErrors.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+	case errors.Is(err, appErrors.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the course instructor can manage its settings"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": appErrors.ErrInternalServerError.Error()})
+	}
+}
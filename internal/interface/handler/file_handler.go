@@ -2,11 +2,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/auth"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 )
 
 type FileHandler struct {
@@ -51,3 +54,32 @@ func (h *FileHandler) Upload(c *gin.Context) {
 		"document_id": doc.ID,
 	})
 }
+
+// Delete removes a document and everything derived from it.
+func (h *FileHandler) Delete(c *gin.Context) {
+	documentID, err := strconv.ParseUint(c.Param("document_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document_id format"})
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+		return
+	}
+
+	if err := h.fileUsecase.Delete(c.Request.Context(), userID, documentID); err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		case errors.Is(err, appErrors.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the course instructor can delete its documents"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": appErrors.ErrInternalServerError.Error()})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -2,16 +2,28 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
 	"github.com/takumi-1234/OpenRAGLecture/pkg/auth"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 )
 
+// Server-Sent Event names emitted by AskStream.
+const (
+	sseEventSources = "sources"
+	sseEventToken   = "token"
+	sseEventDone    = "done"
+	sseEventError   = "error"
+)
+
 type QAHandler struct {
 	qaUsecase      port.QAUsecase
 	enrollmentRepo repository.EnrollmentRepository // ★ 修正: CourseRepoからEnrollmentRepoへ
@@ -26,16 +38,84 @@ func NewQAHandler(qaUsecase port.QAUsecase, enrollmentRepo repository.Enrollment
 }
 
 func (h *QAHandler) Ask(c *gin.Context) {
+	in, ok := h.bindAskInput(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.qaUsecase.Ask(c.Request.Context(), in)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrConversationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get answer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AskStream answers a question and streams the result as Server-Sent Events.
+// The stream starts with one "sources" event and any number of "token" events.
+// It ends with a "done" event or an "error" event. The "done" event carries the
+// answer, query and session IDs and the citations. It also tells whether the
+// question needs clarification and whether the answer came from the cache.
+// Generation stops when the client disconnects.
+func (h *QAHandler) AskStream(c *gin.Context) {
+	in, ok := h.bindAskInput(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// The request context is cancelled when the client goes away, which in turn
+	// cancels the upstream LLM stream.
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	writer := &sseStreamWriter{c: c}
+	response, err := h.qaUsecase.AskStream(ctx, in, writer)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // Client disconnected; nobody is listening anymore.
+		}
+		if errors.Is(err, appErrors.ErrConversationNotFound) {
+			writer.writeEvent(sseEventError, gin.H{"error": "Conversation not found"})
+			return
+		}
+		writer.writeEvent(sseEventError, gin.H{"error": "Failed to get answer"})
+		return
+	}
+
+	writer.writeEvent(sseEventDone, gin.H{
+		"answer_id":           response.AnswerID,
+		"query_id":            response.QueryID,
+		"session_id":          response.SessionID,
+		"needs_clarification": response.NeedsClarification,
+		"cached":              response.Cached,
+		"citations":           response.Citations,
+	})
+}
+
+// bindAskInput parses the request body and verifies that the caller is enrolled in the course.
+// It writes the error response itself and returns false when the request must not proceed.
+func (h *QAHandler) bindAskInput(c *gin.Context) (input.AskInput, bool) {
 	var in input.AskInput
 	if err := c.ShouldBindJSON(&in); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": appErrors.ErrBadRequest.Error()})
-		return
+		return in, false
 	}
 
 	userID, ok := auth.GetUserIDFromContext(c)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
-		return
+		return in, false
 	}
 	in.UserID = userID
 
@@ -43,18 +123,58 @@ func (h *QAHandler) Ask(c *gin.Context) {
 	isEnrolled, err := h.enrollmentRepo.IsEnrolled(c.Request.Context(), in.UserID, in.CourseID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check enrollment"})
-		return
+		return in, false
 	}
 	if !isEnrolled {
 		c.JSON(http.StatusForbidden, gin.H{"error": appErrors.ErrNotEnrolled.Error()})
-		return
+		return in, false
 	}
 
-	response, err := h.qaUsecase.Ask(c.Request.Context(), in)
+	return in, true
+}
+
+// bindCourseParams reads the course ID from the URL and the user ID from the JWT claims.
+// It writes the error response itself and returns false when the request must not proceed.
+func bindCourseParams(c *gin.Context) (userID, courseID uint64, ok bool) {
+	courseID, err := strconv.ParseUint(c.Param("course_id"), 10, 64)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get answer"})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course_id format"})
+		return 0, 0, false
+	}
+
+	userID, ok = auth.GetUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": appErrors.ErrUnauthorized.Error()})
+		return 0, 0, false
+	}
+	return userID, courseID, true
+}
+
+// sseStreamWriter adapts a gin response to port.AskStreamWriter by turning
+// every write into a Server-Sent Event and flushing it immediately.
+type sseStreamWriter struct {
+	c *gin.Context
+}
+
+func (w *sseStreamWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.writeEvent(sseEventToken, gin.H{"text": string(p)}); err != nil {
+		return 0, err
 	}
+	return len(p), nil
+}
 
-	c.JSON(http.StatusOK, gin.H{"answer": response})
+func (w *sseStreamWriter) WriteSources(sources []output.CitationOutput) error {
+	return w.writeEvent(sseEventSources, gin.H{"sources": sources})
+}
+
+func (w *sseStreamWriter) writeEvent(event string, data interface{}) error {
+	if err := w.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	w.c.SSEvent(event, data)
+	w.c.Writer.Flush()
+	return nil
 }
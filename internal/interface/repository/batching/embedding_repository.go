@@ -0,0 +1,140 @@
+// OpenRAGLecture/internal/interface/repository/batching/embedding_repository.go
+package batching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
+)
+
+type batchingEmbeddingRepository struct {
+	inner        repository.EmbeddingRepository
+	maxInstances int
+	maxTokens    int
+	concurrency  int
+	limiter      *rate.Limiter // Nil if requests are not rate limited
+}
+
+// NewEmbeddingRepository creates an EmbeddingRepository that splits the texts into
+// requests within the instance and token limits of the embedding API, sends them to
+// inner concurrently under a token rate limit and splits requests again that the API
+// rejects as too large. Token counts are estimates, so the limits should leave a margin.
+func NewEmbeddingRepository(inner repository.EmbeddingRepository, cfg config.EmbeddingBatchConfig) repository.EmbeddingRepository {
+	r := &batchingEmbeddingRepository{
+		inner:        inner,
+		maxInstances: cfg.MaxInstances,
+		maxTokens:    cfg.MaxTokens,
+		concurrency:  max(cfg.Concurrency, 1),
+	}
+	if cfg.TokensPerMinute > 0 {
+		burst := max(cfg.MaxTokens, cfg.TokensPerMinute/60, 1)
+		r.limiter = rate.NewLimiter(rate.Limit(float64(cfg.TokensPerMinute)/60), burst)
+	}
+	return r
+}
+
+// batch is a range of the texts sent in one request.
+type batch struct {
+	from, to int // Half-open range of text indexes
+	tokens   int // Estimated tokens of the texts
+}
+
+func (r *batchingEmbeddingRepository) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	tokens := make([]int, len(texts))
+	for i, text := range texts {
+		tokens[i] = tokenizer.EstimateTokens(text)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(r.concurrency)
+	for _, b := range r.split(tokens) {
+		g.Go(func() error {
+			return r.embed(gctx, texts, tokens, b, taskType, embeddings)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// split packs consecutive texts into batches of at most maxInstances texts and
+// maxTokens estimated tokens. A text exceeding the token limit on its own is sent alone.
+func (r *batchingEmbeddingRepository) split(tokens []int) []batch {
+	var batches []batch
+	current := batch{}
+	for i, n := range tokens {
+		full := r.maxInstances > 0 && current.to-current.from >= r.maxInstances
+		tooLarge := r.maxTokens > 0 && current.tokens+n > r.maxTokens
+		if current.to > current.from && (full || tooLarge) {
+			batches = append(batches, current)
+			current = batch{from: i, to: i}
+		}
+		current.to = i + 1
+		current.tokens += n
+	}
+	return append(batches, current)
+}
+
+// embed requests the embeddings of a batch and writes them to their positions in
+// embeddings. If the API rejects the batch as too large, its halves are sent instead.
+func (r *batchingEmbeddingRepository) embed(ctx context.Context, texts []string, tokens []int, b batch, taskType string, embeddings [][]float32) error {
+	if r.limiter != nil {
+		if err := r.limiter.WaitN(ctx, min(b.tokens, r.limiter.Burst())); err != nil {
+			return fmt.Errorf("embedding rate limit wait failed: %w", err)
+		}
+	}
+
+	vectors, err := r.inner.CreateEmbeddings(ctx, texts[b.from:b.to], taskType)
+	if err != nil {
+		if b.to-b.from > 1 && isTooLarge(err) {
+			mid := (b.from + b.to) / 2
+			log.Printf("WARN: embedding request of %d texts was too large, splitting it: %v", b.to-b.from, err)
+			for _, half := range []batch{{from: b.from, to: mid}, {from: mid, to: b.to}} {
+				for _, n := range tokens[half.from:half.to] {
+					half.tokens += n
+				}
+				if err := r.embed(ctx, texts, tokens, half, taskType, embeddings); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return err
+	}
+	if len(vectors) != b.to-b.from {
+		return fmt.Errorf("expected %d embeddings, got %d", b.to-b.from, len(vectors))
+	}
+	copy(embeddings[b.from:b.to], vectors)
+	return nil
+}
+
+// isTooLarge reports whether an error rejects a request for its size: HTTP 413, an
+// HTTP 400 or a gRPC InvalidArgument, which is what the embedding APIs return for
+// requests over their instance or token limits.
+func isTooLarge(err error) bool {
+	var statusErr *appErrors.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusRequestEntityTooLarge || statusErr.StatusCode == http.StatusBadRequest
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code() == codes.InvalidArgument
+	}
+	return false
+}
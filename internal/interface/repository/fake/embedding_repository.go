@@ -0,0 +1,91 @@
+// OpenRAGLecture/internal/interface/repository/fake/embedding_repository.go
+package fake
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+type fakeEmbeddingRepository struct {
+	dimensions int
+}
+
+// NewFakeEmbeddingRepository creates an EmbeddingRepository that needs no model: every
+// text is embedded as a hashed bag of its words. The vectors are deterministic and texts
+// sharing words are similar, which is enough for local development and tests.
+func NewFakeEmbeddingRepository(dimensions int) (repository.EmbeddingRepository, error) {
+	if dimensions <= 0 {
+		return nil, errors.New("fake embedding dimension must be positive")
+	}
+	return &fakeEmbeddingRepository{dimensions: dimensions}, nil
+}
+
+// CreateEmbeddings embeds every text independently of taskType.
+func (r *fakeEmbeddingRepository) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = r.embed(text)
+	}
+	return embeddings, nil
+}
+
+// embed adds ±1 for every word at the position its hash selects and normalizes the
+// result to unit length. The sign, also taken from the hash, keeps unrelated words
+// from adding up when they collide.
+func (r *fakeEmbeddingRepository) embed(text string) []float32 {
+	vector := make([]float32, r.dimensions)
+	for _, word := range words(text) {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(r.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+	return vector
+}
+
+// words splits text into lower-cased words. Every CJK character counts as a word of
+// its own, since those scripts do not separate words by spaces.
+func words(text string) []string {
+	var result []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			result = append(result, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			result = append(result, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return result
+}
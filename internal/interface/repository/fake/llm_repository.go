@@ -0,0 +1,165 @@
+// OpenRAGLecture/internal/interface/repository/fake/llm_repository.go
+package fake
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+const (
+	fakeLLMModelName    = "fake-extractive"
+	defaultMaxSentences = 3
+	noContextAnswer     = "I could not find any relevant information in the provided materials to answer your question."
+)
+
+type fakeLLMRepository struct {
+	latency      time.Duration // Delay before the response starts
+	chunkDelay   time.Duration // Delay between streamed words
+	maxSentences int
+}
+
+// NewFakeLLMRepository creates an LLMRepository that needs no model. It answers with
+// the context sentences sharing the most words with the prompt, cited by their
+// reference numbers, and echoes the prompt when there is no context, which turns
+// follow-up questions into themselves.
+func NewFakeLLMRepository(cfg config.FakeLLMConfig) repository.LLMRepository {
+	maxSentences := cfg.MaxSentences
+	if maxSentences <= 0 {
+		maxSentences = defaultMaxSentences
+	}
+	return &fakeLLMRepository{
+		latency:      time.Duration(cfg.LatencyMillis) * time.Millisecond,
+		chunkDelay:   time.Duration(cfg.StreamChunkMillis) * time.Millisecond,
+		maxSentences: maxSentences,
+	}
+}
+
+func (r *fakeLLMRepository) GenerateContent(ctx context.Context, params repository.GenerateContentParams) (string, error) {
+	if err := sleep(ctx, r.latency); err != nil {
+		return "", err
+	}
+	return r.answer(params), nil
+}
+
+// GenerateContentStream writes the same answer as GenerateContent word by word.
+func (r *fakeLLMRepository) GenerateContentStream(ctx context.Context, params repository.GenerateContentParams, writer io.Writer) error {
+	if err := sleep(ctx, r.latency); err != nil {
+		return err
+	}
+	for i, word := range strings.SplitAfter(r.answer(params), " ") {
+		if i > 0 {
+			if err := sleep(ctx, r.chunkDelay); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(writer, word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeLLMRepository) ModelName() string {
+	return fakeLLMModelName
+}
+
+// sentence is a sentence of a context chunk.
+type sentence struct {
+	text      string
+	reference int // 1-based number of the context chunk
+	position  int // Position in the context, used to keep the original order
+	overlap   int // Number of distinct prompt words in the sentence
+}
+
+// answer builds the templated extractive answer.
+func (r *fakeLLMRepository) answer(params repository.GenerateContentParams) string {
+	if len(params.ContextChunks) == 0 {
+		return strings.TrimSpace(params.UserPrompt)
+	}
+
+	promptWords := make(map[string]bool)
+	for _, word := range words(params.UserPrompt) {
+		promptWords[word] = true
+	}
+
+	var sentences []sentence
+	for i, chunk := range params.ContextChunks {
+		for _, text := range splitSentences(chunk.Chunk.Text) {
+			seen := make(map[string]bool)
+			overlap := 0
+			for _, word := range words(text) {
+				if promptWords[word] && !seen[word] {
+					seen[word] = true
+					overlap++
+				}
+			}
+			sentences = append(sentences, sentence{text: text, reference: i + 1, position: len(sentences), overlap: overlap})
+		}
+	}
+
+	sort.SliceStable(sentences, func(a, b int) bool { return sentences[a].overlap > sentences[b].overlap })
+	if len(sentences) == 0 || sentences[0].overlap == 0 {
+		return noContextAnswer
+	}
+	selected := sentences[:0:0]
+	for _, s := range sentences {
+		if s.overlap == 0 || len(selected) == r.maxSentences {
+			break
+		}
+		selected = append(selected, s)
+	}
+	sort.Slice(selected, func(a, b int) bool { return selected[a].position < selected[b].position })
+
+	var sb strings.Builder
+	sb.WriteString("According to the materials:")
+	for _, s := range selected {
+		sb.WriteString(fmt.Sprintf(" %s [%d]", s.text, s.reference))
+	}
+	return sb.String()
+}
+
+// splitSentences splits text after sentence-ending punctuation and line breaks.
+func splitSentences(text string) []string {
+	var result []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(current.String()), " "); s != "" {
+			result = append(result, s)
+		}
+		current.Reset()
+	}
+	for _, r := range text {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		if strings.ContainsRune(".!?。！？", r) {
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// sleep waits for d unless the context is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+// OpenRAGLecture/internal/interface/repository/flatindex/flat_index.go
+package flatindex
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// Entry is a chunk vector of a course held in memory. The chunk text is not kept.
+type Entry struct {
+	ChunkID     uint64
+	DocumentID  uint64
+	SemesterID  uint64
+	DocType     model.DocType
+	EmbeddingID string
+	VectorHash  string
+	Vector      []float32
+	norm        float64
+}
+
+// NewEntry creates the entry of a chunk vector.
+func NewEntry(chunk model.Chunk, vector []float32) Entry {
+	return Entry{
+		ChunkID:     chunk.ID,
+		DocumentID:  chunk.DocumentID,
+		SemesterID:  chunk.SemesterID,
+		DocType:     chunk.Document.DocType,
+		EmbeddingID: chunk.EmbeddingID,
+		VectorHash:  chunk.VectorHash,
+		Vector:      vector,
+		norm:        vectorNorm(vector),
+	}
+}
+
+// Chunk returns the chunk of the entry, without its text.
+func (e *Entry) Chunk(courseID uint64) model.Chunk {
+	return model.Chunk{
+		Base:        model.Base{ID: e.ChunkID},
+		DocumentID:  e.DocumentID,
+		CourseID:    courseID,
+		SemesterID:  e.SemesterID,
+		EmbeddingID: e.EmbeddingID,
+		VectorHash:  e.VectorHash,
+		Document:    model.Document{DocType: e.DocType},
+	}
+}
+
+// Search ranks the entries of a course passing the filter by cosine similarity to the
+// query and returns the best limit of them, see Rank.
+func Search(entries []Entry, query []float32, filter model.SearchFilter, limit int) []model.RetrievedChunk {
+	q := NewQuery(query)
+	retrieved := make([]model.RetrievedChunk, 0, len(entries))
+	for idx := range entries {
+		entry := &entries[idx]
+		chunk := entry.Chunk(filter.CourseID)
+		if !filter.Matches(chunk) {
+			continue
+		}
+		retrieved = append(retrieved, model.RetrievedChunk{Chunk: chunk, Score: q.Cosine(entry)})
+	}
+	return Rank(retrieved, limit)
+}
+
+// Rank sorts scored chunks by descending score and returns the best limit of them.
+// Ties go to the lower chunk ID, then to the lower embedding ID.
+func Rank(retrieved []model.RetrievedChunk, limit int) []model.RetrievedChunk {
+	sort.Slice(retrieved, func(a, b int) bool {
+		if retrieved[a].Score != retrieved[b].Score {
+			return retrieved[a].Score > retrieved[b].Score
+		}
+		if retrieved[a].Chunk.ID != retrieved[b].Chunk.ID {
+			return retrieved[a].Chunk.ID < retrieved[b].Chunk.ID
+		}
+		return retrieved[a].Chunk.EmbeddingID < retrieved[b].Chunk.EmbeddingID
+	})
+	return retrieved[:min(len(retrieved), max(limit, 0))]
+}
+
+// Query is a query vector prepared for scoring entries.
+type Query struct {
+	vector []float32
+	norm   float64
+}
+
+// NewQuery prepares a query vector.
+func NewQuery(vector []float32) Query {
+	return Query{vector: vector, norm: vectorNorm(vector)}
+}
+
+// Cosine returns the cosine similarity of an entry and the query, or 0 if either is a
+// zero vector or their sizes differ.
+func (q Query) Cosine(entry *Entry) float32 {
+	if entry.norm == 0 || q.norm == 0 || len(entry.Vector) != len(q.vector) {
+		return 0
+	}
+	var dot float64
+	for i, v := range entry.Vector {
+		dot += float64(v) * float64(q.vector[i])
+	}
+	return float32(dot / (entry.norm * q.norm))
+}
+
+// Fingerprint identifies the stored vectors of a course. It changes whenever one of
+// them is written or deleted.
+type Fingerprint struct {
+	Count     int64
+	UpdatedAt time.Time
+}
+
+// Cache keeps the entries of the searched courses until their fingerprint changes.
+type Cache struct {
+	mu      sync.Mutex
+	courses map[uint64]*cachedCourse
+}
+
+type cachedCourse struct {
+	fingerprint Fingerprint
+	entries     []Entry
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{courses: make(map[uint64]*cachedCourse)}
+}
+
+// Entries returns the cached entries of a course if they were loaded with the given
+// fingerprint. Otherwise it calls load, which returns the entries and the fingerprint
+// of the loaded vectors, and caches the result.
+func (c *Cache) Entries(courseID uint64, current Fingerprint, load func() ([]Entry, Fingerprint, error)) ([]Entry, error) {
+	c.mu.Lock()
+	cached := c.courses[courseID]
+	c.mu.Unlock()
+	if cached != nil && cached.fingerprint.Count == current.Count && cached.fingerprint.UpdatedAt.Equal(current.UpdatedAt) {
+		return cached.entries, nil
+	}
+
+	entries, fingerprint, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.courses[courseID] = &cachedCourse{fingerprint: fingerprint, entries: entries}
+	c.mu.Unlock()
+	return entries, nil
+}
+
+// Evict drops the entries of a course.
+func (c *Cache) Evict(courseID uint64) {
+	c.mu.Lock()
+	delete(c.courses, courseID)
+	c.mu.Unlock()
+}
+
+// Clear drops the entries of all courses.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	c.courses = make(map[uint64]*cachedCourse)
+	c.mu.Unlock()
+}
+
+// PackVector encodes a vector as little-endian float32 values.
+func PackVector(vector []float32) []byte {
+	packed := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(packed[4*i:], math.Float32bits(v))
+	}
+	return packed
+}
+
+// UnpackVector decodes a vector encoded by PackVector.
+func UnpackVector(packed []byte) []float32 {
+	vector := make([]float32, len(packed)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(packed[4*i:]))
+	}
+	return vector
+}
+
+func vectorNorm(vector []float32) float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
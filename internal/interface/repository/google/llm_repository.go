@@ -41,22 +41,7 @@ func NewGoogleLLMRepository(cfg config.GoogleConfig) (repository.LLMRepository,
 }
 
 func (r *googleLLMRepository) GenerateContent(ctx context.Context, params repository.GenerateContentParams) (string, error) {
-	// 結合プロンプトを作成（システム指示、コンテキスト、ユーザープロンプト）
-	promptParts := make([]string, 0, 3)
-	if params.SystemPrompt != "" {
-		promptParts = append(promptParts, "[System]\n"+params.SystemPrompt)
-	}
-
-	contextText := buildContextString(params.ContextChunks)
-	if contextText != "" {
-		promptParts = append(promptParts, "[Context]\n"+contextText)
-	}
-
-	if params.UserPrompt != "" {
-		promptParts = append(promptParts, "[User]\n"+params.UserPrompt)
-	}
-
-	combinedPrompt := strings.Join(promptParts, "\n\n")
+	combinedPrompt := buildCombinedPrompt(params)
 
 	// GenerateContent を呼ぶ（公式サンプルに合わせる）
 	res, err := r.client.Models.GenerateContent(ctx, r.modelName, genai.Text(combinedPrompt), nil)
@@ -73,22 +58,7 @@ func (r *googleLLMRepository) GenerateContent(ctx context.Context, params reposi
 }
 
 func (r *googleLLMRepository) GenerateContentStream(ctx context.Context, params repository.GenerateContentParams, writer io.Writer) error {
-	// 結合プロンプトを作成
-	promptParts := make([]string, 0, 3)
-	if params.SystemPrompt != "" {
-		promptParts = append(promptParts, "[System]\n"+params.SystemPrompt)
-	}
-
-	contextText := buildContextString(params.ContextChunks)
-	if contextText != "" {
-		promptParts = append(promptParts, "[Context]\n"+contextText)
-	}
-
-	if params.UserPrompt != "" {
-		promptParts = append(promptParts, "[User]\n"+params.UserPrompt)
-	}
-
-	combinedPrompt := strings.Join(promptParts, "\n\n")
+	combinedPrompt := buildCombinedPrompt(params)
 
 	// ストリーミング呼び出し（公式サンプルに準拠）
 	iter := r.client.Models.GenerateContentStream(ctx, r.modelName, genai.Text(combinedPrompt), nil)
@@ -106,6 +76,43 @@ func (r *googleLLMRepository) GenerateContentStream(ctx context.Context, params
 	return nil
 }
 
+func (r *googleLLMRepository) ModelName() string {
+	return r.modelName
+}
+
+// buildCombinedPrompt joins the system instruction, context, conversation history
+// and user prompt into a single prompt.
+func buildCombinedPrompt(params repository.GenerateContentParams) string {
+	promptParts := make([]string, 0, 4)
+	if params.SystemPrompt != "" {
+		promptParts = append(promptParts, "[System]\n"+params.SystemPrompt)
+	}
+
+	contextText := buildContextString(params.ContextChunks)
+	if contextText != "" {
+		promptParts = append(promptParts, "[Context]\n"+contextText)
+	}
+
+	historyText := buildHistoryString(params.History)
+	if historyText != "" {
+		promptParts = append(promptParts, "[History]\n"+historyText)
+	}
+
+	if params.UserPrompt != "" {
+		promptParts = append(promptParts, "[User]\n"+params.UserPrompt)
+	}
+
+	return strings.Join(promptParts, "\n\n")
+}
+
+func buildHistoryString(history []model.ConversationMessage) string {
+	var sb strings.Builder
+	for _, message := range history {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", message.Role, message.Content))
+	}
+	return sb.String()
+}
+
 func buildContextString(chunks []model.RetrievedChunk) string {
 	if len(chunks) == 0 {
 		return ""
@@ -0,0 +1,510 @@
+// OpenRAGLecture/internal/interface/repository/memory/vector_repository.go
+package memory
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/flatindex"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
+)
+
+// point is a stored vector together with the chunk fields Qdrant keeps in its payload.
+// Snapshots written before a field was added decode it as its zero value.
+type point struct {
+	EmbeddingID string
+	Vector      []float32
+	ChunkID     uint64
+	DocumentID  uint64
+	CourseID    uint64
+	SemesterID  uint64
+	PageNumber  int
+	DocType     model.DocType
+	DocVersion  int
+	Language    string
+	Text        string
+	VectorHash  string
+
+	entry flatindex.Entry      // Vector of the point for cosine scoring, built when the point is stored
+	terms tokenizer.TermCounts // Terms of Text, counted when the point is stored
+}
+
+// snapshot is the on-disk representation of a collection.
+type snapshot struct {
+	VectorSize uint64
+	Points     []*point
+}
+
+// collection holds the points of a collection. Repositories of the same collection
+// share it, as they would share a Qdrant collection.
+type collection struct {
+	mu         sync.RWMutex
+	name       string
+	path       string // Snapshot file; empty keeps the collection in memory only
+	vectorSize uint64
+	exists     bool
+	points     map[string]*point
+
+	// State of the snapshot file when it was last read or written, used to notice that
+	// another process rewrote it.
+	fileModTime time.Time
+	fileSize    int64
+}
+
+var collections = struct {
+	sync.Mutex
+	byKey map[string]*collection
+}{byKey: make(map[string]*collection)}
+
+type memoryRepository struct {
+	c *collection
+}
+
+// NewMemoryVectorRepository creates a VectorRepository that keeps the collection in
+// memory and searches it exhaustively. If cfg.SnapshotDir is set, the collection is
+// loaded from its snapshot there and the snapshot is rewritten after every change; a
+// snapshot rewritten by another process, e.g. the batch job, is picked up on the next
+// read. It suits tests and deployments small enough for a full scan per query.
+func NewMemoryVectorRepository(cfg config.MemoryVectorConfig, collectionName string, vectorSize uint64) (repository.VectorRepository, error) {
+	path := ""
+	if cfg.SnapshotDir != "" {
+		if err := os.MkdirAll(cfg.SnapshotDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create vector snapshot directory: %w", err)
+		}
+		path = filepath.Join(cfg.SnapshotDir, collectionName+".gob")
+	}
+
+	collections.Lock()
+	defer collections.Unlock()
+	key := path
+	if key == "" {
+		key = "\x00" + collectionName
+	}
+	c, ok := collections.byKey[key]
+	if !ok {
+		c = &collection{name: collectionName, path: path, vectorSize: vectorSize, points: make(map[string]*point)}
+		if err := c.reloadIfChanged(); err != nil {
+			return nil, err
+		}
+		collections.byKey[key] = c
+	}
+	return &memoryRepository{c: c}, nil
+}
+
+func (r *memoryRepository) Upsert(_ context.Context, chunks []*model.Chunk, vectors [][]float32) error {
+	if len(chunks) != len(vectors) {
+		return fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	return r.c.update(func(c *collection) error {
+		return c.upsert(chunks, vectors)
+	})
+}
+
+// upsert stores the points, or none of them if a vector is invalid.
+func (c *collection) upsert(chunks []*model.Chunk, vectors [][]float32) error {
+	for i, vector := range vectors {
+		if err := c.checkVector(vector); err != nil {
+			return err
+		}
+		if chunks[i].EmbeddingID == "" {
+			return fmt.Errorf("chunk %d has no embedding ID", chunks[i].ID)
+		}
+	}
+	for i, chunk := range chunks {
+		c.points[chunk.EmbeddingID] = newPoint(chunk, vectors[i])
+	}
+	return nil
+}
+
+func (r *memoryRepository) Search(_ context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := c.checkVector(queryVector); err != nil {
+		return nil, err
+	}
+
+	q := flatindex.NewQuery(queryVector)
+	return c.rank(filter, limit, func(p *point) (float32, bool) {
+		return q.Cosine(&p.entry), true
+	}), nil
+}
+
+// HybridSearch ranks the points by cosine similarity and by BM25, and fuses both
+// rankings. Like Qdrant with the IDF modifier, BM25 takes the document frequencies of
+// the query terms from the whole collection, and the average length of its points.
+func (r *memoryRepository) HybridSearch(_ context.Context, query model.HybridQuery, filter model.SearchFilter) ([]model.RetrievedChunk, error) {
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var dense, sparse []model.RetrievedChunk
+	if query.DenseLimit > 0 {
+		if err := c.checkVector(query.Vector); err != nil {
+			return nil, err
+		}
+		q := flatindex.NewQuery(query.Vector)
+		dense = c.rank(filter, query.DenseLimit, func(p *point) (float32, bool) {
+			score := q.Cosine(&p.entry)
+			return score, score >= query.MinScore
+		})
+	}
+	if query.SparseLimit > 0 {
+		corpus := make([]tokenizer.TermCounts, 0, len(c.points))
+		for _, p := range c.points {
+			corpus = append(corpus, p.terms)
+		}
+		scorer := tokenizer.NewBM25Scorer(query.Text, corpus)
+		sparse = c.rank(filter, query.SparseLimit, func(p *point) (float32, bool) {
+			score := scorer.Score(p.terms)
+			return score, score > 0
+		})
+	}
+	return model.FuseRankings(query.RRFK, []float64{query.DenseWeight, query.SparseWeight}, dense, sparse), nil
+}
+
+// rank scores the points passing the filter and returns the best limit of those the
+// score function keeps, ranked like the flat indexes of the MySQL vector store.
+func (c *collection) rank(filter model.SearchFilter, limit int, score func(p *point) (float32, bool)) []model.RetrievedChunk {
+	var retrieved []model.RetrievedChunk
+	for _, p := range c.points {
+		chunk := p.chunk()
+		if !filter.Matches(chunk) {
+			continue
+		}
+		if s, ok := score(p); ok {
+			retrieved = append(retrieved, model.RetrievedChunk{Chunk: chunk, Score: s})
+		}
+	}
+	return flatindex.Rank(retrieved, limit)
+}
+
+func (r *memoryRepository) GetVectors(_ context.Context, embeddingIDs []string) ([]model.ChunkVector, error) {
+	if len(embeddingIDs) == 0 {
+		return nil, nil
+	}
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var vectors []model.ChunkVector
+	for _, id := range embeddingIDs {
+		if p, ok := c.points[id]; ok {
+			vectors = append(vectors, model.ChunkVector{Chunk: p.chunk(), Vector: append([]float32(nil), p.Vector...)})
+		}
+	}
+	return vectors, nil
+}
+
+func (r *memoryRepository) DeleteByIDs(_ context.Context, embeddingIDs []string) error {
+	if len(embeddingIDs) == 0 {
+		return nil
+	}
+	return r.c.update(func(c *collection) error {
+		for _, id := range embeddingIDs {
+			delete(c.points, id)
+		}
+		return nil
+	})
+}
+
+func (r *memoryRepository) DeleteByDocument(_ context.Context, documentID uint64) error {
+	return r.c.update(func(c *collection) error {
+		c.deleteDocuments([]uint64{documentID})
+		return nil
+	})
+}
+
+// ReplaceDocuments applies the upsert and the deletion under one lock and writes them in
+// one snapshot.
+func (r *memoryRepository) ReplaceDocuments(_ context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error {
+	if len(chunks) != len(vectors) {
+		return fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	return r.c.update(func(c *collection) error {
+		if err := c.upsert(chunks, vectors); err != nil {
+			return err
+		}
+		c.deleteDocuments(oldDocumentIDs)
+		return nil
+	})
+}
+
+func (c *collection) deleteDocuments(documentIDs []uint64) {
+	for id, p := range c.points {
+		if slices.Contains(documentIDs, p.DocumentID) {
+			delete(c.points, id)
+		}
+	}
+}
+
+// Scan passes the points in the order of their IDs. The points are copied up front, so
+// fn may write to the repository.
+func (r *memoryRepository) Scan(_ context.Context, batchSize int, fn func(points []model.ChunkVector) error) error {
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return err
+	}
+	ids := slices.Sorted(maps.Keys(c.points))
+	points := make([]model.ChunkVector, len(ids))
+	for i, id := range ids {
+		p := c.points[id]
+		points[i] = model.ChunkVector{Chunk: p.chunk(), Vector: append([]float32(nil), p.Vector...)}
+	}
+	unlock()
+
+	for batch := range slices.Chunk(points, max(batchSize, 1)) {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) Count(_ context.Context) (int, error) {
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	return len(c.points), nil
+}
+
+// CreateCourseSpace is a no-op: the points of all courses share the collection.
+func (r *memoryRepository) CreateCourseSpace(_ context.Context, _ uint64) error {
+	return nil
+}
+
+// DropCourseSpace deletes the points of a course.
+func (r *memoryRepository) DropCourseSpace(_ context.Context, courseID uint64) error {
+	return r.c.update(func(c *collection) error {
+		for id, p := range c.points {
+			if p.CourseID == courseID {
+				delete(c.points, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *memoryRepository) CourseSpaceStats(_ context.Context, courseID uint64) (*model.VectorSpaceStats, error) {
+	c, unlock, err := r.c.read()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	stats := &model.VectorSpaceStats{CourseID: courseID, Tenancy: model.TenancyShared, Collection: c.name, Exists: true}
+	for _, p := range c.points {
+		if p.CourseID == courseID {
+			stats.Points++
+		}
+	}
+	return stats, nil
+}
+
+// RecreateCollection drops all points of the collection.
+func (r *memoryRepository) RecreateCollection(_ context.Context) error {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+
+	r.c.exists = true
+	r.c.points = make(map[string]*point)
+	if err := r.c.save(); err != nil {
+		return err
+	}
+	log.Printf("In-memory collection '%s' recreated.", r.c.name)
+	return nil
+}
+
+// EnsureCollectionExists creates the collection only if it does not exist.
+func (r *memoryRepository) EnsureCollectionExists(_ context.Context) error {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+
+	if err := r.c.reloadIfChanged(); err != nil {
+		return err
+	}
+	if r.c.exists {
+		return nil
+	}
+	r.c.exists = true
+	if err := r.c.save(); err != nil {
+		return err
+	}
+	log.Printf("In-memory collection '%s' created.", r.c.name)
+	return nil
+}
+
+// read read-locks the collection after picking up a changed snapshot. The collection
+// must exist.
+func (c *collection) read() (*collection, func(), error) {
+	c.mu.Lock()
+	err := c.reloadIfChanged()
+	c.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.RLock()
+	if !c.exists {
+		c.mu.RUnlock()
+		return nil, nil, fmt.Errorf("collection '%s' does not exist", c.name)
+	}
+	return c, c.mu.RUnlock, nil
+}
+
+// update applies fn to the existing collection and writes the snapshot. The points are
+// left unchanged if fn fails.
+func (c *collection) update(fn func(c *collection) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.reloadIfChanged(); err != nil {
+		return err
+	}
+	if !c.exists {
+		return fmt.Errorf("collection '%s' does not exist", c.name)
+	}
+	if err := fn(c); err != nil {
+		return err
+	}
+	return c.save()
+}
+
+func (c *collection) checkVector(vector []float32) error {
+	if uint64(len(vector)) != c.vectorSize {
+		return fmt.Errorf("vector of size %d does not match collection '%s' of size %d", len(vector), c.name, c.vectorSize)
+	}
+	return nil
+}
+
+// reloadIfChanged loads the snapshot if it was written since it was last read or
+// written. A missing snapshot leaves the collection as it is.
+func (c *collection) reloadIfChanged() error {
+	if c.path == "" {
+		return nil
+	}
+	info, err := os.Stat(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat vector snapshot: %w", err)
+	}
+	if info.ModTime().Equal(c.fileModTime) && info.Size() == c.fileSize {
+		return nil
+	}
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to open vector snapshot: %w", err)
+	}
+	defer file.Close()
+	var snap snapshot
+	if err := gob.NewDecoder(file).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to read vector snapshot %s: %w", c.path, err)
+	}
+	if snap.VectorSize != c.vectorSize {
+		return fmt.Errorf("vector snapshot %s has vectors of size %d, expected %d", c.path, snap.VectorSize, c.vectorSize)
+	}
+
+	c.points = make(map[string]*point, len(snap.Points))
+	for _, p := range snap.Points {
+		p.entry = flatindex.NewEntry(p.chunk(), p.Vector)
+		p.terms = tokenizer.CountTerms(p.Text)
+		c.points[p.EmbeddingID] = p
+	}
+	c.exists = true
+	c.fileModTime, c.fileSize = info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes the snapshot to a temporary file and renames it over the old one, so
+// that readers never see a partial snapshot.
+func (c *collection) save() error {
+	if c.path == "" {
+		return nil
+	}
+	snap := snapshot{VectorSize: c.vectorSize, Points: make([]*point, 0, len(c.points))}
+	for _, p := range c.points {
+		snap.Points = append(snap.Points, p)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create vector snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(&snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write vector snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write vector snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace vector snapshot: %w", err)
+	}
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat vector snapshot: %w", err)
+	}
+	c.fileModTime, c.fileSize = info.ModTime(), info.Size()
+	return nil
+}
+
+func newPoint(chunk *model.Chunk, vector []float32) *point {
+	vector = append([]float32(nil), vector...)
+	p := &point{
+		EmbeddingID: chunk.EmbeddingID,
+		Vector:      vector,
+		ChunkID:     chunk.ID,
+		DocumentID:  chunk.DocumentID,
+		CourseID:    chunk.CourseID,
+		SemesterID:  chunk.SemesterID,
+		PageNumber:  chunk.Page.PageNumber,
+		DocType:     chunk.Document.DocType,
+		DocVersion:  chunk.Document.Version,
+		Language:    chunk.Page.Language,
+		Text:        chunk.Text,
+		VectorHash:  chunk.VectorHash,
+		terms:       tokenizer.CountTerms(chunk.Text),
+	}
+	p.entry = flatindex.NewEntry(p.chunk(), vector)
+	return p
+}
+
+// chunk rebuilds the chunk fields stored with the point.
+func (p *point) chunk() model.Chunk {
+	return model.Chunk{
+		Base:        model.Base{ID: p.ChunkID},
+		DocumentID:  p.DocumentID,
+		CourseID:    p.CourseID,
+		SemesterID:  p.SemesterID,
+		Text:        p.Text,
+		EmbeddingID: p.EmbeddingID,
+		VectorHash:  p.VectorHash,
+		Page:        model.Page{PageNumber: p.PageNumber, Language: p.Language},
+		Document:    model.Document{DocType: p.DocType, Version: p.DocVersion},
+	}
+}
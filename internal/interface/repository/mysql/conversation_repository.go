@@ -0,0 +1,92 @@
+// OpenRAGLecture/internal/interface/repository/mysql/conversation_repository.go
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type conversationRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository creates a new ConversationRepository implementation.
+func NewConversationRepository(db *gorm.DB) repository.ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+func (r *conversationRepository) Create(ctx context.Context, conversation *model.Conversation) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(conversation).Error
+}
+
+func (r *conversationRepository) FindBySessionID(ctx context.Context, sessionID string) (*model.Conversation, error) {
+	var conversation model.Conversation
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).First(&conversation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrConversationNotFound
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *conversationRepository) ListByUser(ctx context.Context, userID, courseID uint64) ([]*model.Conversation, error) {
+	var conversations []*model.Conversation
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if courseID != 0 {
+		query = query.Where("course_id = ?", courseID)
+	}
+	err := query.Order("updated_at DESC").Find(&conversations).Error
+	return conversations, err
+}
+
+func (r *conversationRepository) Delete(ctx context.Context, conversationID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ?", conversationID).Delete(&model.ConversationMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Conversation{}, conversationID).Error
+	})
+}
+
+func (r *conversationRepository) AddMessages(ctx context.Context, conversationID uint64, messages []*model.ConversationMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, message := range messages {
+			message.ConversationID = conversationID
+		}
+		if err := tx.Omit(clause.Associations).Create(&messages).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Conversation{}).
+			Where("id = ?", conversationID).
+			Update("updated_at", time.Now()).Error
+	})
+}
+
+func (r *conversationRepository) ListMessages(ctx context.Context, conversationID uint64, limit int) ([]*model.ConversationMessage, error) {
+	var messages []*model.ConversationMessage
+	query := r.db.WithContext(ctx).
+		Where("conversation_id = ?", conversationID).
+		Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	// Fetched newest first so that the limit keeps the latest turns; restore chronological order.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
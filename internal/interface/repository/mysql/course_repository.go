@@ -9,6 +9,7 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type courseRepository struct {
@@ -31,4 +32,32 @@ func (r *courseRepository) FindByID(ctx context.Context, id uint64) (*model.Cour
 	return &course, nil
 }
 
+func (r *courseRepository) FindSettings(ctx context.Context, courseID uint64) (*model.CourseSettings, error) {
+	var settings model.CourseSettings
+	if err := r.db.WithContext(ctx).Where("course_id = ?", courseID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *courseRepository) SaveSettings(ctx context.Context, settings *model.CourseSettings) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.CourseSettings
+		err := tx.Where("course_id = ?", settings.CourseID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Omit(clause.Associations).Create(settings).Error
+		case err != nil:
+			return err
+		}
+		// Save writes every column, so overrides that were removed are reset to NULL.
+		settings.ID = existing.ID
+		settings.CreatedAt = existing.CreatedAt
+		return tx.Omit(clause.Associations).Save(settings).Error
+	})
+}
+
 // CheckEnrollment は enrollmentRepository に移管されました
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
@@ -21,9 +22,30 @@ func NewDocumentRepository(db *gorm.DB) repository.DocumentRepository {
 	return &documentRepository{db: db}
 }
 
+// notDeleted scopes a query to rows that are not soft-deleted. Base.DeletedAt is a plain
+// sql.NullTime, so GORM does not add this condition by itself.
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where("deleted_at IS NULL")
+}
+
 func (r *documentRepository) FindByID(ctx context.Context, id uint64) (*model.Document, error) {
 	var doc model.Document
-	if err := r.db.WithContext(ctx).First(&doc, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(notDeleted).First(&doc, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrDocumentNotFound
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *documentRepository) FindLatestVersion(ctx context.Context, courseID uint64, title string) (*model.Document, error) {
+	var doc model.Document
+	err := r.db.WithContext(ctx).Scopes(notDeleted).
+		Where("course_id = ? AND title = ?", courseID, title).
+		Order("version DESC").
+		First(&doc).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, appErrors.ErrDocumentNotFound
 		}
@@ -36,13 +58,82 @@ func (r *documentRepository) Create(ctx context.Context, doc *model.Document) er
 	return r.db.WithContext(ctx).Create(doc).Error
 }
 
+func (r *documentRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.Document{}).Scopes(notDeleted).Where("id = ?", id).Update("deleted_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete document %d: %w", id, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrDocumentNotFound
+		}
+		if err := tx.Model(&model.Chunk{}).Scopes(notDeleted).Where("document_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return fmt.Errorf("failed to delete chunks of document %d: %w", id, err)
+		}
+		if err := tx.Model(&model.Page{}).Scopes(notDeleted).Where("document_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return fmt.Errorf("failed to delete pages of document %d: %w", id, err)
+		}
+		return nil
+	})
+}
+
+func (r *documentRepository) FindChunksByIDs(ctx context.Context, ids []uint64) ([]*model.Chunk, error) {
+	var chunks []*model.Chunk
+	if len(ids) == 0 {
+		return chunks, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Page").
+		Preload("Document").
+		Scopes(notDeleted).
+		Where("id IN ?", ids).
+		Find(&chunks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find chunks: %w", err)
+	}
+	return chunks, nil
+}
+
+func (r *documentRepository) FindChunksByIndexRange(ctx context.Context, documentID uint64, fromIndex, toIndex int) ([]*model.Chunk, error) {
+	var chunks []*model.Chunk
+	err := r.db.WithContext(ctx).
+		Preload("Page").
+		Preload("Document").
+		Scopes(notDeleted).
+		Where("document_id = ? AND chunk_index BETWEEN ? AND ?", documentID, fromIndex, toIndex).
+		Order("chunk_index").
+		Find(&chunks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find chunks by index range: %w", err)
+	}
+	return chunks, nil
+}
+
 // FullTextSearch performs a natural language full-text search.
-func (r *documentRepository) FullTextSearch(ctx context.Context, query string, courseID uint64, limit int) ([]model.RetrievedChunk, error) {
+func (r *documentRepository) FullTextSearch(ctx context.Context, query string, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
 	var results []struct {
 		model.Chunk
 		Score float32
 	}
 
+	// The document conditions of the filter apply to the pages searched.
+	conditions := "d.course_id = ? AND d.deleted_at IS NULL AND p.deleted_at IS NULL"
+	args := []any{query, filter.CourseID}
+	if len(filter.DocumentIDs) > 0 {
+		conditions += " AND d.id IN ?"
+		args = append(args, filter.DocumentIDs)
+	}
+	if filter.SemesterID != 0 {
+		conditions += " AND d.semester_id = ?"
+		args = append(args, filter.SemesterID)
+	}
+	if filter.DocType != "" {
+		conditions += " AND d.doc_type = ?"
+		args = append(args, filter.DocType)
+	}
+	args = append(args, query, limit)
+
 	// This subquery finds the relevant pages using FULLTEXT index.
 	// Then we join with chunks associated with those pages.
 	sql := `
@@ -52,12 +143,13 @@ func (r *documentRepository) FullTextSearch(ctx context.Context, query string, c
 			SELECT p.id as page_id, MATCH(p.text) AGAINST(? IN NATURAL LANGUAGE MODE) as score
 			FROM pages p
 			INNER JOIN documents d ON p.document_id = d.id
-			WHERE d.course_id = ? AND MATCH(p.text) AGAINST(? IN NATURAL LANGUAGE MODE) > 0
+			WHERE ` + conditions + ` AND MATCH(p.text) AGAINST(? IN NATURAL LANGUAGE MODE) > 0
 		) as p_score ON c.page_id = p_score.page_id
+		WHERE c.deleted_at IS NULL
 		ORDER BY p_score.score DESC
 		LIMIT ?;
 	`
-	err := r.db.WithContext(ctx).Raw(sql, query, courseID, query, limit).Scan(&results).Error
+	err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&results).Error
 	if err != nil {
 		return nil, fmt.Errorf("full-text search failed: %w", err)
 	}
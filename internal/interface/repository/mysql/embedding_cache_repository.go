@@ -0,0 +1,42 @@
+// OpenRAGLecture/internal/interface/repository/mysql/embedding_cache_repository.go
+package mysql
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type embeddingCacheRepository struct {
+	db *gorm.DB
+}
+
+// NewEmbeddingCacheRepository creates a new EmbeddingCacheRepository implementation.
+func NewEmbeddingCacheRepository(db *gorm.DB) repository.EmbeddingCacheRepository {
+	return &embeddingCacheRepository{db: db}
+}
+
+func (r *embeddingCacheRepository) FindByHashes(ctx context.Context, hashes []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return vectors, nil
+	}
+	var entries []*model.EmbeddingCacheEntry
+	if err := r.db.WithContext(ctx).Where("vector_hash IN ?", hashes).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		vectors[entry.VectorHash] = entry.Vector
+	}
+	return vectors, nil
+}
+
+func (r *embeddingCacheRepository) Save(ctx context.Context, entries []*model.EmbeddingCacheEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
@@ -0,0 +1,78 @@
+// OpenRAGLecture/internal/interface/repository/mysql/embedding_index_repository.go
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type embeddingIndexRepository struct {
+	db *gorm.DB
+}
+
+// NewEmbeddingIndexRepository creates a new EmbeddingIndexRepository implementation.
+func NewEmbeddingIndexRepository(db *gorm.DB) repository.EmbeddingIndexRepository {
+	return &embeddingIndexRepository{db: db}
+}
+
+func (r *embeddingIndexRepository) Create(ctx context.Context, index *model.EmbeddingIndex) error {
+	return r.db.WithContext(ctx).Create(index).Error
+}
+
+func (r *embeddingIndexRepository) FindByVersion(ctx context.Context, version string) (*model.EmbeddingIndex, error) {
+	return r.first(r.db.WithContext(ctx).Where("version = ?", version))
+}
+
+func (r *embeddingIndexRepository) FindActive(ctx context.Context) (*model.EmbeddingIndex, error) {
+	return r.first(r.db.WithContext(ctx).Where("status = ?", model.EmbeddingIndexActive))
+}
+
+func (r *embeddingIndexRepository) first(query *gorm.DB) (*model.EmbeddingIndex, error) {
+	var index model.EmbeddingIndex
+	if err := query.First(&index).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrEmbeddingIndexNotFound
+		}
+		return nil, err
+	}
+	return &index, nil
+}
+
+func (r *embeddingIndexRepository) List(ctx context.Context) ([]*model.EmbeddingIndex, error) {
+	var indexes []*model.EmbeddingIndex
+	err := r.db.WithContext(ctx).Order("activated_at IS NULL, activated_at DESC, id DESC").Find(&indexes).Error
+	return indexes, err
+}
+
+func (r *embeddingIndexRepository) Update(ctx context.Context, index *model.EmbeddingIndex) error {
+	return r.db.WithContext(ctx).Save(index).Error
+}
+
+func (r *embeddingIndexRepository) Activate(ctx context.Context, version string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var index model.EmbeddingIndex
+		if err := tx.Where("version = ?", version).First(&index).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.ErrEmbeddingIndexNotFound
+			}
+			return err
+		}
+		err := tx.Model(&model.EmbeddingIndex{}).
+			Where("status = ? AND id <> ?", model.EmbeddingIndexActive, index.ID).
+			Update("status", model.EmbeddingIndexReady).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Model(&index).Updates(map[string]interface{}{
+			"status":       model.EmbeddingIndexActive,
+			"activated_at": &now,
+		}).Error
+	})
+}
@@ -0,0 +1,47 @@
+// OpenRAGLecture/internal/interface/repository/mysql/qa_log_repository.go
+package mysql
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type qaLogRepository struct {
+	db *gorm.DB
+}
+
+// NewQALogRepository creates a new QALogRepository implementation.
+func NewQALogRepository(db *gorm.DB) repository.QALogRepository {
+	return &qaLogRepository{db: db}
+}
+
+func (r *qaLogRepository) CreateQuestion(ctx context.Context, question *model.Question) error {
+	db := r.db.WithContext(ctx)
+	if question.SemesterID == 0 {
+		// semester_id is a nullable foreign key, so it must be a real semester or nothing.
+		var semesterID uint64
+		if err := db.Model(&model.Course{}).Select("semester_id").Where("id = ?", question.CourseID).Scan(&semesterID).Error; err != nil {
+			return err
+		}
+		question.SemesterID = semesterID
+	}
+	if question.SemesterID == 0 {
+		return db.Omit(clause.Associations, "SemesterID").Create(question).Error
+	}
+	return db.Omit(clause.Associations).Create(question).Error
+}
+
+func (r *qaLogRepository) CreateAnswer(ctx context.Context, answer *model.Answer) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(answer).Error
+}
+
+func (r *qaLogRepository) CreateAnswerSources(ctx context.Context, sources []*model.AnswerSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(&sources).Error
+}
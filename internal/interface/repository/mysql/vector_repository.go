@@ -0,0 +1,368 @@
+// OpenRAGLecture/internal/interface/repository/mysql/vector_repository.go
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/flatindex"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// vectorRecordBatchSize bounds the rows written by one INSERT.
+const vectorRecordBatchSize = 500
+
+type vectorRepository struct {
+	db             *gorm.DB
+	collectionName string
+	vectorSize     uint64
+	courses        *flatindex.Cache // Cached vectors by course ID
+}
+
+// courseFingerprint holds the count and latest update of the rows of a course, which
+// change whenever one of them is written or deleted.
+type courseFingerprint struct {
+	Count     int64
+	UpdatedAt *time.Time
+}
+
+// NewVectorRepository creates a VectorRepository that stores the vectors in MySQL and
+// searches the vectors of a course exhaustively. The vectors of the searched courses
+// are cached in process and reloaded when their rows change, e.g. by the batch job.
+// The chunk texts are read from the chunks table.
+func NewVectorRepository(db *gorm.DB, collectionName string, vectorSize uint64) repository.VectorRepository {
+	return &vectorRepository{
+		db:             db,
+		collectionName: collectionName,
+		vectorSize:     vectorSize,
+		courses:        flatindex.NewCache(),
+	}
+}
+
+func (r *vectorRepository) Upsert(ctx context.Context, chunks []*model.Chunk, vectors [][]float32) error {
+	records, err := r.vectorRecords(chunks, vectors)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	return r.storeRecords(r.db.WithContext(ctx), records)
+}
+
+func (r *vectorRepository) vectorRecords(chunks []*model.Chunk, vectors [][]float32) ([]*model.VectorRecord, error) {
+	if len(chunks) != len(vectors) {
+		return nil, fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	records := make([]*model.VectorRecord, len(chunks))
+	for i, chunk := range chunks {
+		if err := r.checkVector(vectors[i]); err != nil {
+			return nil, err
+		}
+		records[i] = &model.VectorRecord{
+			CollectionName: r.collectionName,
+			ChunkID:        chunk.ID,
+			CourseID:       chunk.CourseID,
+			DocumentID:     chunk.DocumentID,
+			SemesterID:     chunk.SemesterID,
+			DocType:        chunk.Document.DocType,
+			EmbeddingID:    chunk.EmbeddingID,
+			VectorHash:     chunk.VectorHash,
+			Vector:         flatindex.PackVector(vectors[i]),
+		}
+	}
+	return records, nil
+}
+
+func (r *vectorRepository) storeRecords(db *gorm.DB, records []*model.VectorRecord) error {
+	err := db.Clauses(clause.OnConflict{UpdateAll: true}).
+		CreateInBatches(records, vectorRecordBatchSize).Error
+	if err != nil {
+		return fmt.Errorf("failed to store vectors: %w", err)
+	}
+	return nil
+}
+
+func (r *vectorRepository) Search(ctx context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
+	if err := r.checkVector(queryVector); err != nil {
+		return nil, err
+	}
+	entries, err := r.courseVectors(ctx, filter.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	retrieved := flatindex.Search(entries, queryVector, filter, limit)
+
+	chunks := make([]*model.Chunk, len(retrieved))
+	for i := range retrieved {
+		chunks[i] = &retrieved[i].Chunk
+	}
+	if err := r.loadTexts(ctx, chunks); err != nil {
+		return nil, err
+	}
+	return retrieved, nil
+}
+
+// HybridSearch is not supported: the table holds no sparse vectors, and MySQL runs the
+// keyword search itself with its FULLTEXT index.
+func (r *vectorRepository) HybridSearch(_ context.Context, _ model.HybridQuery, _ model.SearchFilter) ([]model.RetrievedChunk, error) {
+	return nil, appErrors.ErrHybridSearchUnsupported
+}
+
+func (r *vectorRepository) GetVectors(ctx context.Context, embeddingIDs []string) ([]model.ChunkVector, error) {
+	if len(embeddingIDs) == 0 {
+		return nil, nil
+	}
+	var records []*model.VectorRecord
+	err := r.db.WithContext(ctx).
+		Where("collection_name = ? AND embedding_id IN ?", r.collectionName, embeddingIDs).
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vectors: %w", err)
+	}
+
+	vectors := make([]model.ChunkVector, len(records))
+	chunks := make([]*model.Chunk, len(records))
+	for i, record := range records {
+		vectors[i] = model.ChunkVector{Chunk: recordChunk(record), Vector: flatindex.UnpackVector(record.Vector)}
+		chunks[i] = &vectors[i].Chunk
+	}
+	if err := r.loadTexts(ctx, chunks); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// Scan reads the vectors in chunk ID order, one batch per query.
+func (r *vectorRepository) Scan(ctx context.Context, batchSize int, fn func(points []model.ChunkVector) error) error {
+	var lastChunkID uint64
+	for {
+		var records []*model.VectorRecord
+		err := r.db.WithContext(ctx).
+			Where("collection_name = ? AND chunk_id > ?", r.collectionName, lastChunkID).
+			Order("chunk_id").
+			Limit(max(batchSize, 1)).
+			Find(&records).Error
+		if err != nil {
+			return fmt.Errorf("failed to scan vectors: %w", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		lastChunkID = records[len(records)-1].ChunkID
+
+		points := make([]model.ChunkVector, len(records))
+		chunks := make([]*model.Chunk, len(records))
+		for i, record := range records {
+			points[i] = model.ChunkVector{Chunk: recordChunk(record), Vector: flatindex.UnpackVector(record.Vector)}
+			chunks[i] = &points[i].Chunk
+		}
+		if err := r.loadTexts(ctx, chunks); err != nil {
+			return err
+		}
+		if err := fn(points); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *vectorRepository) DeleteByIDs(ctx context.Context, embeddingIDs []string) error {
+	if len(embeddingIDs) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("collection_name = ? AND embedding_id IN ?", r.collectionName, embeddingIDs).
+		Delete(&model.VectorRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return nil
+}
+
+func (r *vectorRepository) DeleteByDocument(ctx context.Context, documentID uint64) error {
+	return r.deleteDocuments(r.db.WithContext(ctx), []uint64{documentID})
+}
+
+// ReplaceDocuments stores the new vectors and deletes the old ones in one transaction.
+func (r *vectorRepository) ReplaceDocuments(ctx context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error {
+	records, err := r.vectorRecords(chunks, vectors)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(records) > 0 {
+			if err := r.storeRecords(tx, records); err != nil {
+				return err
+			}
+		}
+		return r.deleteDocuments(tx, oldDocumentIDs)
+	})
+}
+
+func (r *vectorRepository) deleteDocuments(db *gorm.DB, documentIDs []uint64) error {
+	if len(documentIDs) == 0 {
+		return nil
+	}
+	err := db.Where("collection_name = ? AND document_id IN ?", r.collectionName, documentIDs).
+		Delete(&model.VectorRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors of documents %v: %w", documentIDs, err)
+	}
+	return nil
+}
+
+func (r *vectorRepository) Count(ctx context.Context) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.VectorRecord{}).
+		Where("collection_name = ?", r.collectionName).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count vectors: %w", err)
+	}
+	return int(count), nil
+}
+
+// CreateCourseSpace is a no-op: the vectors of all courses share the table.
+func (r *vectorRepository) CreateCourseSpace(_ context.Context, _ uint64) error {
+	return nil
+}
+
+// DropCourseSpace deletes the vectors of a course and evicts them from the cache.
+func (r *vectorRepository) DropCourseSpace(ctx context.Context, courseID uint64) error {
+	err := r.db.WithContext(ctx).
+		Where("collection_name = ? AND course_id = ?", r.collectionName, courseID).
+		Delete(&model.VectorRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete vectors of course %d: %w", courseID, err)
+	}
+	r.courses.Evict(courseID)
+	return nil
+}
+
+func (r *vectorRepository) CourseSpaceStats(ctx context.Context, courseID uint64) (*model.VectorSpaceStats, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.VectorRecord{}).
+		Where("collection_name = ? AND course_id = ?", r.collectionName, courseID).
+		Count(&count).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count vectors of course %d: %w", courseID, err)
+	}
+	return &model.VectorSpaceStats{
+		CourseID:   courseID,
+		Tenancy:    model.TenancyShared,
+		Collection: r.collectionName,
+		Exists:     true,
+		Points:     int(count),
+	}, nil
+}
+
+// RecreateCollection deletes all vectors of the collection.
+func (r *vectorRepository) RecreateCollection(ctx context.Context) error {
+	err := r.db.WithContext(ctx).
+		Where("collection_name = ?", r.collectionName).
+		Delete(&model.VectorRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to clear vector collection '%s': %w", r.collectionName, err)
+	}
+	r.courses.Clear()
+	log.Printf("MySQL vector collection '%s' cleared.", r.collectionName)
+	return nil
+}
+
+// EnsureCollectionExists has nothing to do: the collections share the vector_records
+// table created by the migrations.
+func (r *vectorRepository) EnsureCollectionExists(_ context.Context) error {
+	return nil
+}
+
+func (r *vectorRepository) checkVector(vector []float32) error {
+	if uint64(len(vector)) != r.vectorSize {
+		return fmt.Errorf("vector of size %d does not match collection '%s' of size %d", len(vector), r.collectionName, r.vectorSize)
+	}
+	return nil
+}
+
+// courseVectors returns the vectors of a course, loading them if they are not cached
+// or their rows changed since they were loaded.
+func (r *vectorRepository) courseVectors(ctx context.Context, courseID uint64) ([]flatindex.Entry, error) {
+	var current courseFingerprint
+	err := r.db.WithContext(ctx).Model(&model.VectorRecord{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Where("collection_name = ? AND course_id = ?", r.collectionName, courseID).
+		Scan(&current).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check vectors of course %d: %w", courseID, err)
+	}
+	fingerprint := flatindex.Fingerprint{Count: current.Count}
+	if current.UpdatedAt != nil {
+		fingerprint.UpdatedAt = *current.UpdatedAt
+	}
+
+	return r.courses.Entries(courseID, fingerprint, func() ([]flatindex.Entry, flatindex.Fingerprint, error) {
+		var records []*model.VectorRecord
+		err := r.db.WithContext(ctx).
+			Select("chunk_id", "course_id", "document_id", "semester_id", "doc_type", "embedding_id", "vector_hash", "vector", "updated_at").
+			Where("collection_name = ? AND course_id = ?", r.collectionName, courseID).
+			Find(&records).Error
+		if err != nil {
+			return nil, flatindex.Fingerprint{}, fmt.Errorf("failed to load vectors of course %d: %w", courseID, err)
+		}
+		entries := make([]flatindex.Entry, len(records))
+		loaded := flatindex.Fingerprint{Count: int64(len(records))}
+		for i, record := range records {
+			entries[i] = flatindex.NewEntry(recordChunk(record), flatindex.UnpackVector(record.Vector))
+			if record.UpdatedAt.After(loaded.UpdatedAt) {
+				loaded.UpdatedAt = record.UpdatedAt
+			}
+		}
+		return entries, loaded, nil
+	})
+}
+
+// loadTexts fills in the texts of the chunks from the chunks table. Like the payload of a
+// Qdrant point, the text outlives a soft-deleted chunk until its vector is deleted.
+func (r *vectorRepository) loadTexts(ctx context.Context, chunks []*model.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(chunks))
+	for i, chunk := range chunks {
+		ids[i] = chunk.ID
+	}
+	var rows []struct {
+		ID   uint64
+		Text string
+	}
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.Chunk{}).
+		Select("id", "text").
+		Where("id IN ?", ids).
+		Find(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to load chunk texts: %w", err)
+	}
+	texts := make(map[uint64]string, len(rows))
+	for _, row := range rows {
+		texts[row.ID] = row.Text
+	}
+	for _, chunk := range chunks {
+		chunk.Text = texts[chunk.ID]
+	}
+	return nil
+}
+
+// recordChunk returns the chunk of a stored vector, without its text.
+func recordChunk(record *model.VectorRecord) model.Chunk {
+	return model.Chunk{
+		Base:        model.Base{ID: record.ChunkID},
+		DocumentID:  record.DocumentID,
+		CourseID:    record.CourseID,
+		SemesterID:  record.SemesterID,
+		EmbeddingID: record.EmbeddingID,
+		VectorHash:  record.VectorHash,
+		Document:    model.Document{DocType: record.DocType},
+	}
+}
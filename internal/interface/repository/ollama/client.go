@@ -0,0 +1,95 @@
+// OpenRAGLecture/internal/interface/repository/ollama/client.go
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+const (
+	defaultBaseURL = "http://localhost:11434"
+	// Local models may have to be loaded into memory before the first response.
+	defaultTimeout = 5 * time.Minute
+	// errorBodyMaxBytes limits how much of an error response is read into the error message.
+	errorBodyMaxBytes = 4096
+)
+
+// client sends JSON requests to the Ollama HTTP API.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func newClient(cfg config.OllamaConfig) *client {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	timeout := defaultTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return &client{
+		httpClient: &http.Client{Timeout: timeout},
+		baseURL:    baseURL,
+	}
+}
+
+// post sends body as JSON to the endpoint path and returns the response if its
+// status is 2xx. The caller must close the response body.
+func (c *client) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		defer res.Body.Close()
+		return nil, fmt.Errorf("request to %s failed: %w", path, &appErrors.StatusError{StatusCode: res.StatusCode, Message: errorMessage(res.Body)})
+	}
+	return res, nil
+}
+
+// postJSON sends body to the endpoint path and decodes the JSON response into out.
+func (c *client) postJSON(ctx context.Context, path string, body, out interface{}) error {
+	res, err := c.post(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message of an Ollama error response, falling back to
+// the raw body.
+func errorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, errorBodyMaxBytes))
+	var res struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &res); err == nil && res.Error != "" {
+		return res.Error
+	}
+	return strings.TrimSpace(string(raw))
+}
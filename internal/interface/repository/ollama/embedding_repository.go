@@ -0,0 +1,56 @@
+// OpenRAGLecture/internal/interface/repository/ollama/embedding_repository.go
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+const embedPath = "/api/embed"
+
+type ollamaEmbeddingRepository struct {
+	client    *client
+	modelName string
+}
+
+// NewOllamaEmbeddingRepository creates an EmbeddingRepository for the embed API of an
+// Ollama server.
+func NewOllamaEmbeddingRepository(cfg config.OllamaConfig) (repository.EmbeddingRepository, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("Ollama embedding model must be configured")
+	}
+	return &ollamaEmbeddingRepository{
+		client:    newClient(cfg),
+		modelName: cfg.Model,
+	}, nil
+}
+
+type embedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// CreateEmbeddings generates vector embeddings for a batch of texts. The API has no
+// notion of task types, so taskType is ignored.
+func (r *ollamaEmbeddingRepository) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	var res embedResponse
+	if err := r.client.postJSON(ctx, embedPath, embedRequest{Model: r.modelName, Input: texts}, &res); err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	if len(res.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(res.Embeddings))
+	}
+	return res.Embeddings, nil
+}
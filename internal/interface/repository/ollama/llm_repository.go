@@ -0,0 +1,139 @@
+// OpenRAGLecture/internal/interface/repository/ollama/llm_repository.go
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+const chatPath = "/api/chat"
+
+type ollamaLLMRepository struct {
+	client    *client
+	modelName string
+}
+
+// NewOllamaLLMRepository creates an LLMRepository for the chat API of an Ollama server.
+func NewOllamaLLMRepository(cfg config.OllamaConfig) (repository.LLMRepository, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("Ollama LLM model must be configured")
+	}
+	return &ollamaLLMRepository{
+		client:    newClient(cfg),
+		modelName: cfg.Model,
+	}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"` // Ollama streams unless told otherwise
+}
+
+// chatResponse is a complete response, or one line of a streamed response.
+type chatResponse struct {
+	Message chatMessage `json:"message"`
+	Done    bool        `json:"done"`
+	Error   string      `json:"error"`
+}
+
+func (r *ollamaLLMRepository) GenerateContent(ctx context.Context, params repository.GenerateContentParams) (string, error) {
+	var res chatResponse
+	err := r.client.postJSON(ctx, chatPath, chatRequest{
+		Model:    r.modelName,
+		Messages: buildMessages(params),
+	}, &res)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	if res.Error != "" {
+		return "", fmt.Errorf("failed to generate content: %s", res.Error)
+	}
+	return res.Message.Content, nil
+}
+
+// GenerateContentStream requests a streamed response and writes the content of every
+// line of the newline-delimited JSON stream to the writer as it arrives.
+func (r *ollamaLLMRepository) GenerateContentStream(ctx context.Context, params repository.GenerateContentParams, writer io.Writer) error {
+	res, err := r.client.post(ctx, chatPath, chatRequest{
+		Model:    r.modelName,
+		Messages: buildMessages(params),
+		Stream:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start content stream: %w", err)
+	}
+	defer res.Body.Close()
+
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var chunk chatResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("chat stream ended before completion")
+			}
+			return fmt.Errorf("chat stream error: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("chat stream error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			if _, err := io.WriteString(writer, chunk.Message.Content); err != nil {
+				return err
+			}
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+}
+
+func (r *ollamaLLMRepository) ModelName() string {
+	return r.modelName
+}
+
+// buildMessages maps the parameters to chat messages: the system prompt together
+// with the context chunks, the conversation history and the user prompt.
+func buildMessages(params repository.GenerateContentParams) []chatMessage {
+	messages := make([]chatMessage, 0, len(params.History)+2)
+
+	system := params.SystemPrompt
+	if contextText := buildContextString(params); contextText != "" {
+		system = strings.TrimSpace(system + "\n\n" + contextText)
+	}
+	if system != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: system})
+	}
+	for _, message := range params.History {
+		messages = append(messages, chatMessage{Role: string(message.Role), Content: message.Content})
+	}
+	if params.UserPrompt != "" {
+		messages = append(messages, chatMessage{Role: "user", Content: params.UserPrompt})
+	}
+	return messages
+}
+
+func buildContextString(params repository.GenerateContentParams) string {
+	if len(params.ContextChunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("--- Context Information ---\n")
+	for i, chunk := range params.ContextChunks {
+		sb.WriteString(fmt.Sprintf("\n[Reference %d]\n%s\n", i+1, chunk.Chunk.Text))
+	}
+	sb.WriteString("--- End of Context ---\n")
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+// OpenRAGLecture/internal/interface/repository/openai/client.go
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultTimeout = 60 * time.Second
+	// errorBodyMaxBytes limits how much of an error response is read into the error message.
+	errorBodyMaxBytes = 4096
+)
+
+// client sends JSON requests to an OpenAI-compatible API.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func newClient(cfg config.OpenAIConfig) *client {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	timeout := defaultTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return &client{
+		httpClient: &http.Client{Timeout: timeout},
+		baseURL:    baseURL,
+		apiKey:     cfg.APIKey,
+	}
+}
+
+// post sends body as JSON to the endpoint path and returns the response if its
+// status is 2xx. The caller must close the response body.
+func (c *client) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		defer res.Body.Close()
+		return nil, fmt.Errorf("request to %s failed: %w", path, &appErrors.StatusError{StatusCode: res.StatusCode, Message: errorMessage(res.Body)})
+	}
+	return res, nil
+}
+
+// postJSON sends body to the endpoint path and decodes the JSON response into out.
+func (c *client) postJSON(ctx context.Context, path string, body, out interface{}) error {
+	res, err := c.post(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message of an OpenAI error response, falling back to
+// the raw body.
+func errorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, errorBodyMaxBytes))
+	var res struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &res); err == nil && res.Error.Message != "" {
+		return res.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
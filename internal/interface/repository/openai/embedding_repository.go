@@ -0,0 +1,76 @@
+// OpenRAGLecture/internal/interface/repository/openai/embedding_repository.go
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+const embeddingsPath = "/embeddings"
+
+type openAIEmbeddingRepository struct {
+	client     *client
+	modelName  string
+	dimensions int
+}
+
+// NewOpenAIEmbeddingRepository creates an EmbeddingRepository for the embeddings API
+// of OpenAI and compatible inference servers.
+func NewOpenAIEmbeddingRepository(cfg config.OpenAIConfig) (repository.EmbeddingRepository, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("OpenAI embedding model must be configured")
+	}
+	return &openAIEmbeddingRepository{
+		client:     newClient(cfg),
+		modelName:  cfg.Model,
+		dimensions: cfg.Dimensions,
+	}, nil
+}
+
+type embeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// CreateEmbeddings generates vector embeddings for a batch of texts. The API has no
+// notion of task types, so taskType is ignored.
+func (r *openAIEmbeddingRepository) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	var res embeddingResponse
+	err := r.client.postJSON(ctx, embeddingsPath, embeddingRequest{
+		Model:      r.modelName,
+		Input:      texts,
+		Dimensions: r.dimensions,
+	}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	if len(res.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(res.Data))
+	}
+
+	// The results are not guaranteed to be in input order.
+	embeddings := make([][]float32, len(texts))
+	for _, item := range res.Data {
+		if item.Index < 0 || item.Index >= len(texts) || embeddings[item.Index] != nil {
+			return nil, fmt.Errorf("invalid embedding index %d", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,154 @@
+// OpenRAGLecture/internal/interface/repository/openai/llm_repository.go
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+const chatCompletionsPath = "/chat/completions"
+
+type openAILLMRepository struct {
+	client    *client
+	modelName string
+}
+
+// NewOpenAILLMRepository creates an LLMRepository for the chat completions API of
+// OpenAI and compatible inference servers.
+func NewOpenAILLMRepository(cfg config.OpenAIConfig) (repository.LLMRepository, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("OpenAI LLM model must be configured")
+	}
+	return &openAILLMRepository{
+		client:    newClient(cfg),
+		modelName: cfg.Model,
+	}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (r *openAILLMRepository) GenerateContent(ctx context.Context, params repository.GenerateContentParams) (string, error) {
+	var res chatCompletionResponse
+	err := r.client.postJSON(ctx, chatCompletionsPath, chatCompletionRequest{
+		Model:    r.modelName,
+		Messages: buildMessages(params),
+	}, &res)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	if len(res.Choices) == 0 {
+		return "", errors.New("no response from model")
+	}
+	return res.Choices[0].Message.Content, nil
+}
+
+// GenerateContentStream requests a streamed completion and writes every content
+// delta of the Server-Sent Events response to the writer as it arrives.
+func (r *openAILLMRepository) GenerateContentStream(ctx context.Context, params repository.GenerateContentParams, writer io.Writer) error {
+	res, err := r.client.post(ctx, chatCompletionsPath, chatCompletionRequest{
+		Model:    r.modelName,
+		Messages: buildMessages(params),
+		Stream:   true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start content stream: %w", err)
+	}
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Blank separator lines, comments and other SSE fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		if _, err := io.WriteString(writer, chunk.Choices[0].Delta.Content); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("chat stream error: %w", err)
+	}
+	return nil
+}
+
+func (r *openAILLMRepository) ModelName() string {
+	return r.modelName
+}
+
+// buildMessages maps the parameters to chat messages: the system prompt together
+// with the context chunks, the conversation history and the user prompt.
+func buildMessages(params repository.GenerateContentParams) []chatMessage {
+	messages := make([]chatMessage, 0, len(params.History)+2)
+
+	system := params.SystemPrompt
+	if contextText := buildContextString(params); contextText != "" {
+		system = strings.TrimSpace(system + "\n\n" + contextText)
+	}
+	if system != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: system})
+	}
+	for _, message := range params.History {
+		messages = append(messages, chatMessage{Role: string(message.Role), Content: message.Content})
+	}
+	if params.UserPrompt != "" {
+		messages = append(messages, chatMessage{Role: "user", Content: params.UserPrompt})
+	}
+	return messages
+}
+
+func buildContextString(params repository.GenerateContentParams) string {
+	if len(params.ContextChunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("--- Context Information ---\n")
+	for i, chunk := range params.ContextChunks {
+		sb.WriteString(fmt.Sprintf("\n[Reference %d]\n%s\n", i+1, chunk.Chunk.Text))
+	}
+	sb.WriteString("--- End of Context ---\n")
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+// OpenRAGLecture/internal/interface/repository/provider/cache.go
+package provider
+
+import (
+	"log"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/redis"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// NewCacheRepository creates the CacheRepository of the cached answers, conversations
+// and corpus versions, and returns the cache configuration in effect. Redis is only
+// connected when the answer cache is enabled. If it is disabled or Redis cannot be
+// reached, the repository stores nothing and the answer cache is turned off: corpus
+// versions could no longer be bumped, so cached answers would outlive the documents
+// they cite.
+func NewCacheRepository(cfg config.CacheConfig) (repository.CacheRepository, config.CacheConfig) {
+	if cfg.AnswerTTLMinutes <= 0 {
+		log.Printf("WARN: the answer cache is disabled; running without Redis")
+		return redis.NewNoopRepository(), disableAnswerCache(cfg)
+	}
+	cacheRepo, err := redis.NewRedisRepository(cfg.Redis)
+	if err != nil {
+		log.Printf("WARN: %v; running without Redis and the answer cache", err)
+		return redis.NewNoopRepository(), disableAnswerCache(cfg)
+	}
+	return cacheRepo, cfg
+}
+
+func disableAnswerCache(cfg config.CacheConfig) config.CacheConfig {
+	cfg.AnswerTTLMinutes = 0
+	cfg.SemanticThreshold = 0
+	return cfg
+}
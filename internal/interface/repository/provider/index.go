@@ -0,0 +1,493 @@
+// OpenRAGLecture/internal/interface/repository/provider/index.go
+package provider
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/qdrant"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// IndexRegistry hands out the repositories of the embedding indexes recorded in an
+// EmbeddingIndexRepository. Every index is a vector collection embedded by a single
+// model, so a query must be embedded by the model of the index it searches.
+type IndexRegistry struct {
+	cfg       config.Config
+	db        *gorm.DB // Database of the MySQL vector store
+	indexRepo repository.EmbeddingIndexRepository
+	refresh   time.Duration // How long the active index is cached
+
+	mu        sync.Mutex
+	active    *model.EmbeddingIndex
+	checkedAt time.Time
+
+	reposMu        sync.Mutex
+	repos          map[string]*indexRepositories                 // By index version
+	semanticCaches map[string]repository.SemanticCacheRepository // By index version
+}
+
+type indexRepositories struct {
+	vectorRepo    repository.VectorRepository
+	embeddingRepo repository.EmbeddingRepository
+}
+
+// NewIndexRegistry creates an IndexRegistry. The active index is looked up again after
+// the refresh interval, so that switching the index reaches running servers. db is only
+// used by the MySQL vector store and may be nil otherwise.
+func NewIndexRegistry(cfg config.Config, db *gorm.DB, indexRepo repository.EmbeddingIndexRepository, refresh time.Duration) *IndexRegistry {
+	return &IndexRegistry{
+		cfg:            cfg,
+		db:             db,
+		indexRepo:      indexRepo,
+		refresh:        refresh,
+		repos:          make(map[string]*indexRepositories),
+		semanticCaches: make(map[string]repository.SemanticCacheRepository),
+	}
+}
+
+// ConfiguredIndex returns the index of the configured embedding model. Its version
+// identifies the provider, model and dimension, and the Qdrant sparse vector if one is
+// configured, and names its collection.
+func ConfiguredIndex(cfg config.Config) *model.EmbeddingIndex {
+	provider := cmp.Or(cfg.Embedding.Provider, "google")
+	modelName := EmbeddingModelName(cfg)
+	dimensions := int(cfg.VectorDB.Qdrant.VectorSize)
+	var sparseVector string
+	if cfg.VectorDB.Type == "" || cfg.VectorDB.Type == "qdrant" {
+		sparseVector = cfg.VectorDB.Qdrant.SparseVector
+	}
+	version := indexVersion(provider, modelName, dimensions, sparseVector)
+	return &model.EmbeddingIndex{
+		Version:        version,
+		Provider:       provider,
+		Model:          modelName,
+		Dimensions:     dimensions,
+		SparseVector:   sparseVector,
+		CollectionName: cfg.VectorDB.Qdrant.CollectionName + "_" + version,
+		Status:         model.EmbeddingIndexBuilding,
+	}
+}
+
+// indexVersion builds a version string that is also valid as part of a collection name.
+func indexVersion(provider, modelName string, dimensions int, sparseVector string) string {
+	version := fmt.Sprintf("%s_%s_%d", provider, modelName, dimensions)
+	if sparseVector != "" {
+		version += "_" + sparseVector
+	}
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, version)
+}
+
+// Bootstrap registers the configured collection as the active index of the configured
+// model if no index has been recorded yet, so that the collection built before the
+// registry existed keeps being queried. That collection has no sparse vector; the batch
+// task "reembed" builds an index with one.
+func (r *IndexRegistry) Bootstrap(ctx context.Context) error {
+	_, err := r.indexRepo.FindActive(ctx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, appErrors.ErrEmbeddingIndexNotFound) {
+		return fmt.Errorf("failed to look up the active embedding index: %w", err)
+	}
+	indexes, err := r.indexRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list embedding indexes: %w", err)
+	}
+	if len(indexes) > 0 {
+		return errors.New("no embedding index is active")
+	}
+
+	now := time.Now()
+	index := ConfiguredIndex(r.cfg)
+	index.Version = indexVersion(index.Provider, index.Model, index.Dimensions, "")
+	index.SparseVector = ""
+	index.CollectionName = r.cfg.VectorDB.Qdrant.CollectionName
+	index.Status = model.EmbeddingIndexActive
+	index.ActivatedAt = &now
+	if err := r.indexRepo.Create(ctx, index); err != nil {
+		// Another process may have registered it concurrently.
+		if _, findErr := r.indexRepo.FindActive(ctx); findErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to register embedding index %s: %w", index.Version, err)
+	}
+	log.Printf("Registered collection '%s' as embedding index %s.", index.CollectionName, index.Version)
+	return nil
+}
+
+// Active returns the active index. If it cannot be looked up, the last known one is used.
+func (r *IndexRegistry) Active(ctx context.Context) (*model.EmbeddingIndex, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != nil && time.Since(r.checkedAt) < r.refresh {
+		return r.active, nil
+	}
+	index, err := r.indexRepo.FindActive(ctx)
+	if err != nil {
+		if r.active != nil {
+			log.Printf("WARN: failed to look up the active embedding index, keeping %s: %v", r.active.Version, err)
+			return r.active, nil
+		}
+		return nil, fmt.Errorf("failed to look up the active embedding index: %w", err)
+	}
+	if r.active != nil && r.active.Version != index.Version {
+		log.Printf("Switched from embedding index %s to %s.", r.active.Version, index.Version)
+	}
+	r.active, r.checkedAt = index, time.Now()
+	return index, nil
+}
+
+// Repositories returns the vector repository of the collection of an index and the
+// embedding repository of its model.
+func (r *IndexRegistry) Repositories(index *model.EmbeddingIndex) (repository.VectorRepository, repository.EmbeddingRepository, error) {
+	r.reposMu.Lock()
+	defer r.reposMu.Unlock()
+
+	if repos, ok := r.repos[index.Version]; ok {
+		return repos.vectorRepo, repos.embeddingRepo, nil
+	}
+	cfg := indexConfig(r.cfg, index)
+	vectorRepo, err := NewVectorRepository(cfg, r.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	embeddingRepo, err := NewEmbeddingRepository(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create embedding repo of index %s: %w", index.Version, err)
+	}
+	r.repos[index.Version] = &indexRepositories{vectorRepo: vectorRepo, embeddingRepo: embeddingRepo}
+	return vectorRepo, embeddingRepo, nil
+}
+
+// SnapshotRepository returns the snapshot repository of the collection of an index, or
+// nil if the vector store has no snapshots of its own.
+func (r *IndexRegistry) SnapshotRepository(index *model.EmbeddingIndex) (repository.VectorSnapshotRepository, error) {
+	snapshotRepo, err := NewVectorSnapshotRepository(indexConfig(r.cfg, index))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot repo of index %s: %w", index.Version, err)
+	}
+	return snapshotRepo, nil
+}
+
+// semanticCache returns the semantic answer cache of an index, creating its collection
+// when it is first used.
+func (r *IndexRegistry) semanticCache(ctx context.Context, index *model.EmbeddingIndex) (repository.SemanticCacheRepository, error) {
+	r.reposMu.Lock()
+	defer r.reposMu.Unlock()
+
+	if cache, ok := r.semanticCaches[index.Version]; ok {
+		return cache, nil
+	}
+	cache, err := qdrant.NewQdrantSemanticCacheRepository(indexConfig(r.cfg, index).VectorDB.Qdrant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create semantic answer cache of index %s: %w", index.Version, err)
+	}
+	if err := cache.EnsureCollectionExists(ctx); err != nil {
+		return nil, fmt.Errorf("failed to prepare semantic answer cache of index %s: %w", index.Version, err)
+	}
+	r.semanticCaches[index.Version] = cache
+	return cache, nil
+}
+
+// indexConfig returns the configuration serving an index: its collection, vector size,
+// sparse vector and embedding model. Fallback providers are configured for the
+// configured model, so they are dropped for indexes of other models.
+func indexConfig(cfg config.Config, index *model.EmbeddingIndex) config.Config {
+	configured := ConfiguredIndex(cfg)
+	cfg.VectorDB.Qdrant.CollectionName = index.CollectionName
+	cfg.VectorDB.Qdrant.VectorSize = uint64(index.Dimensions)
+	cfg.VectorDB.Qdrant.SparseVector = index.SparseVector
+	if configured.Provider == index.Provider && configured.Model == index.Model && configured.Dimensions == index.Dimensions {
+		return cfg
+	}
+
+	cfg.Embedding.Provider = index.Provider
+	cfg.Embedding.Fallbacks = nil
+	switch index.Provider {
+	case "openai":
+		cfg.Embedding.OpenAI.Model = index.Model
+		if cfg.Embedding.OpenAI.Dimensions > 0 {
+			cfg.Embedding.OpenAI.Dimensions = index.Dimensions
+		}
+	case "ollama":
+		cfg.Embedding.Ollama.Model = index.Model
+		cfg.Embedding.Ollama.Dimensions = index.Dimensions
+	case "fake":
+		cfg.Embedding.Fake.Dimensions = index.Dimensions
+	default:
+		cfg.Google.EmbeddingModel = index.Model
+	}
+	return cfg
+}
+
+// activeRepositories returns the active index together with its repositories.
+func (r *IndexRegistry) activeRepositories(ctx context.Context) (*model.EmbeddingIndex, repository.VectorRepository, repository.EmbeddingRepository, error) {
+	index, err := r.Active(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	vectorRepo, embeddingRepo, err := r.Repositories(index)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return index, vectorRepo, embeddingRepo, nil
+}
+
+// VectorRepository returns a VectorRepository that operates on the active index. Each
+// call resolves the index anew, so vectors whose size does not match it are rejected.
+// Questions are embedded and searched through SearchIndexes instead.
+func (r *IndexRegistry) VectorRepository() repository.VectorRepository {
+	return &activeVectorRepository{registry: r}
+}
+
+// SearchIndexes returns a SearchIndexProvider that resolves the active index once per
+// question, so that the query is searched in the index of the model that embedded it.
+// With answerCache, the indexes come with their semantic answer caches.
+func (r *IndexRegistry) SearchIndexes(answerCache bool) repository.SearchIndexProvider {
+	return &searchIndexProvider{registry: r, answerCache: answerCache}
+}
+
+// SemanticCacheRepository returns a SemanticCacheRepository that keeps the answers in
+// the Qdrant answer cache of the active index, whose vectors have the size of its model.
+// Question vectors of another size are rejected like in VectorRepository.
+func (r *IndexRegistry) SemanticCacheRepository() repository.SemanticCacheRepository {
+	return &activeSemanticCacheRepository{registry: r}
+}
+
+type searchIndexProvider struct {
+	registry    *IndexRegistry
+	answerCache bool
+}
+
+// ActiveSearchIndex returns the repositories of the active index. If its answer cache
+// cannot be prepared, the question is answered without the semantic cache.
+func (p *searchIndexProvider) ActiveSearchIndex(ctx context.Context) (*repository.SearchIndex, error) {
+	index, vectorRepo, embeddingRepo, err := p.registry.activeRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchIndex := &repository.SearchIndex{
+		EmbeddingRepo: embeddingRepo,
+		VectorRepo:    vectorRepo,
+		HybridSearch:  SupportsHybridSearch(indexConfig(p.registry.cfg, index)),
+	}
+	if p.answerCache {
+		cache, err := p.registry.semanticCache(ctx, index)
+		if err != nil {
+			log.Printf("WARN: %v; skipping the semantic answer cache", err)
+		} else {
+			searchIndex.AnswerCache = cache
+		}
+	}
+	return searchIndex, nil
+}
+
+type activeVectorRepository struct {
+	registry *IndexRegistry
+}
+
+func (r *activeVectorRepository) Upsert(ctx context.Context, chunks []*model.Chunk, vectors [][]float32) error {
+	index, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	for _, vector := range vectors {
+		if err := checkDimensions(index, vector); err != nil {
+			return err
+		}
+	}
+	return vectorRepo.Upsert(ctx, chunks, vectors)
+}
+
+func (r *activeVectorRepository) Search(ctx context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
+	index, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDimensions(index, queryVector); err != nil {
+		return nil, err
+	}
+	return vectorRepo.Search(ctx, queryVector, filter, limit)
+}
+
+func (r *activeVectorRepository) GetVectors(ctx context.Context, embeddingIDs []string) ([]model.ChunkVector, error) {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vectorRepo.GetVectors(ctx, embeddingIDs)
+}
+
+func (r *activeVectorRepository) DeleteByIDs(ctx context.Context, embeddingIDs []string) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.DeleteByIDs(ctx, embeddingIDs)
+}
+
+func (r *activeVectorRepository) HybridSearch(ctx context.Context, query model.HybridQuery, filter model.SearchFilter) ([]model.RetrievedChunk, error) {
+	index, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if query.DenseLimit > 0 {
+		if err := checkDimensions(index, query.Vector); err != nil {
+			return nil, err
+		}
+	}
+	return vectorRepo.HybridSearch(ctx, query, filter)
+}
+
+func (r *activeVectorRepository) DeleteByDocument(ctx context.Context, documentID uint64) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.DeleteByDocument(ctx, documentID)
+}
+
+func (r *activeVectorRepository) ReplaceDocuments(ctx context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error {
+	index, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	for _, vector := range vectors {
+		if err := checkDimensions(index, vector); err != nil {
+			return err
+		}
+	}
+	return vectorRepo.ReplaceDocuments(ctx, oldDocumentIDs, chunks, vectors)
+}
+
+func (r *activeVectorRepository) CreateCourseSpace(ctx context.Context, courseID uint64) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.CreateCourseSpace(ctx, courseID)
+}
+
+func (r *activeVectorRepository) DropCourseSpace(ctx context.Context, courseID uint64) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.DropCourseSpace(ctx, courseID)
+}
+
+func (r *activeVectorRepository) CourseSpaceStats(ctx context.Context, courseID uint64) (*model.VectorSpaceStats, error) {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vectorRepo.CourseSpaceStats(ctx, courseID)
+}
+
+func (r *activeVectorRepository) Scan(ctx context.Context, batchSize int, fn func(points []model.ChunkVector) error) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.Scan(ctx, batchSize, fn)
+}
+
+func (r *activeVectorRepository) Count(ctx context.Context) (int, error) {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return vectorRepo.Count(ctx)
+}
+
+func (r *activeVectorRepository) RecreateCollection(ctx context.Context) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.RecreateCollection(ctx)
+}
+
+func (r *activeVectorRepository) EnsureCollectionExists(ctx context.Context) error {
+	_, vectorRepo, _, err := r.registry.activeRepositories(ctx)
+	if err != nil {
+		return err
+	}
+	return vectorRepo.EnsureCollectionExists(ctx)
+}
+
+type activeSemanticCacheRepository struct {
+	registry *IndexRegistry
+}
+
+// activeCache returns the answer cache of the active index. A non-nil vector must have
+// been embedded for the index.
+func (r *activeSemanticCacheRepository) activeCache(ctx context.Context, vector []float32) (repository.SemanticCacheRepository, error) {
+	index, err := r.registry.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if vector != nil {
+		if err := checkDimensions(index, vector); err != nil {
+			return nil, err
+		}
+	}
+	return r.registry.semanticCache(ctx, index)
+}
+
+func (r *activeSemanticCacheRepository) FindNearest(ctx context.Context, courseID uint64, corpusVersion string, vector []float32, notBefore time.Time) (*model.SemanticCacheEntry, float32, error) {
+	cache, err := r.activeCache(ctx, vector)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cache.FindNearest(ctx, courseID, corpusVersion, vector, notBefore)
+}
+
+func (r *activeSemanticCacheRepository) Save(ctx context.Context, entry *model.SemanticCacheEntry) error {
+	cache, err := r.activeCache(ctx, entry.Vector)
+	if err != nil {
+		return err
+	}
+	return cache.Save(ctx, entry)
+}
+
+func (r *activeSemanticCacheRepository) DeleteByCourse(ctx context.Context, courseID uint64) error {
+	cache, err := r.activeCache(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return cache.DeleteByCourse(ctx, courseID)
+}
+
+// EnsureCollectionExists creates the answer cache of the active index. The caches of
+// indexes activated later are created when they are first used.
+func (r *activeSemanticCacheRepository) EnsureCollectionExists(ctx context.Context) error {
+	_, err := r.activeCache(ctx, nil)
+	return err
+}
+
+// checkDimensions verifies that a vector was embedded for the index.
+func checkDimensions(index *model.EmbeddingIndex, vector []float32) error {
+	if len(vector) != index.Dimensions {
+		return fmt.Errorf("vector of size %d does not match embedding index %s of size %d", len(vector), index.Version, index.Dimensions)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// OpenRAGLecture/internal/interface/repository/provider/provider.go
+package provider
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/batching"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/fake"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/google"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/ollama"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/openai"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/resilient"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// NewLLMRepository creates the LLMRepository selected by cfg.LLM.Provider. If fallback
+// providers are configured, the providers are wrapped into a chain that falls over to the
+// next one when a provider fails.
+func NewLLMRepository(cfg config.Config) (repository.LLMRepository, error) {
+	names := append([]string{cmp.Or(cfg.LLM.Provider, "google")}, cfg.LLM.Fallbacks...)
+	providers := make([]repository.LLMRepository, len(names))
+	for i, name := range names {
+		llmRepo, err := newLLMRepository(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = llmRepo
+	}
+	return resilient.NewLLMRepository(providers, cfg.LLM.Resilience), nil
+}
+
+func newLLMRepository(cfg config.Config, name string) (repository.LLMRepository, error) {
+	switch name {
+	case "", "google":
+		return google.NewGoogleLLMRepository(cfg.Google)
+	case "openai":
+		return openai.NewOpenAILLMRepository(cfg.LLM.OpenAI)
+	case "ollama":
+		return ollama.NewOllamaLLMRepository(cfg.LLM.Ollama)
+	case "fake":
+		return fake.NewFakeLLMRepository(cfg.LLM.Fake), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", name)
+	}
+}
+
+// NewEmbeddingRepository creates the EmbeddingRepository selected by cfg.Embedding.Provider,
+// wrapped into a chain with the configured fallback providers. Requests are split into
+// batches within the API limits before they enter the chain.
+func NewEmbeddingRepository(cfg config.Config) (repository.EmbeddingRepository, error) {
+	names := append([]string{cmp.Or(cfg.Embedding.Provider, "google")}, cfg.Embedding.Fallbacks...)
+	providers := make([]repository.EmbeddingRepository, len(names))
+	for i, name := range names {
+		embeddingRepo, err := newEmbeddingRepository(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = embeddingRepo
+	}
+	chain := resilient.NewEmbeddingRepository(providers, names, cfg.Embedding.Resilience)
+	return batching.NewEmbeddingRepository(chain, cfg.Embedding.Batch), nil
+}
+
+func newEmbeddingRepository(cfg config.Config, name string) (repository.EmbeddingRepository, error) {
+	switch name {
+	case "", "google":
+		return google.NewGoogleEmbeddingRepository(cfg.Google)
+	case "openai":
+		return openai.NewOpenAIEmbeddingRepository(cfg.Embedding.OpenAI)
+	case "ollama":
+		return ollama.NewOllamaEmbeddingRepository(cfg.Embedding.Ollama)
+	case "fake":
+		return fake.NewFakeEmbeddingRepository(fakeEmbeddingDimensions(cfg))
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", name)
+	}
+}
+
+// EmbeddingModelName returns the name of the embedding model of the selected provider.
+// It is recorded with every chunk so that chunks embedded by another model can be told apart.
+func EmbeddingModelName(cfg config.Config) string {
+	switch cfg.Embedding.Provider {
+	case "openai":
+		return cfg.Embedding.OpenAI.Model
+	case "ollama":
+		return cfg.Embedding.Ollama.Model
+	case "fake":
+		return fmt.Sprintf("fake-bow-%d", fakeEmbeddingDimensions(cfg))
+	default:
+		return cfg.Google.EmbeddingModel
+	}
+}
+
+// CheckEmbeddingDimension verifies that the vectors of the selected embedding model fit
+// the vector collection. A configured embedding dimension must equal the vector size.
+// Local models, whose dimension cannot be chosen, are also asked for a probe embedding;
+// hosted models are not, to keep startup free of billable requests.
+func CheckEmbeddingDimension(ctx context.Context, cfg config.Config, embeddingRepo repository.EmbeddingRepository) error {
+	vectorSize := int(cfg.VectorDB.Qdrant.VectorSize)
+
+	var dimensions int
+	switch cfg.Embedding.Provider {
+	case "openai":
+		dimensions = cfg.Embedding.OpenAI.Dimensions
+	case "ollama":
+		dimensions = cfg.Embedding.Ollama.Dimensions
+	case "fake":
+		dimensions = fakeEmbeddingDimensions(cfg)
+	}
+	if dimensions > 0 && dimensions != vectorSize {
+		return fmt.Errorf("embedding dimension %d does not match the vector size %d", dimensions, vectorSize)
+	}
+
+	if cfg.Embedding.Provider != "ollama" {
+		return nil
+	}
+	embeddings, err := embeddingRepo.CreateEmbeddings(ctx, []string{"dimension check"}, "RETRIEVAL_QUERY")
+	if err != nil {
+		return fmt.Errorf("failed to create probe embedding: %w", err)
+	}
+	if len(embeddings) != 1 || len(embeddings[0]) != vectorSize {
+		return fmt.Errorf("embedding model %s does not produce vectors of size %d", cfg.Embedding.Ollama.Model, vectorSize)
+	}
+	return nil
+}
+
+// fakeEmbeddingDimensions returns the dimension of the fake embeddings, which defaults
+// to the vector size of the collection.
+func fakeEmbeddingDimensions(cfg config.Config) int {
+	if cfg.Embedding.Fake.Dimensions > 0 {
+		return cfg.Embedding.Fake.Dimensions
+	}
+	return int(cfg.VectorDB.Qdrant.VectorSize)
+}
@@ -0,0 +1,57 @@
+// OpenRAGLecture/internal/interface/repository/provider/vector.go
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/memory"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/mysql"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/qdrant"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	"gorm.io/gorm"
+)
+
+// SupportsHybridSearch reports whether the vector store selected by cfg keeps the sparse
+// vectors HybridSearch needs.
+func SupportsHybridSearch(cfg config.Config) bool {
+	switch cfg.VectorDB.Type {
+	case "", "qdrant":
+		return cfg.VectorDB.Qdrant.SparseVector != ""
+	case "memory":
+		return true
+	default:
+		return false
+	}
+}
+
+// NewVectorSnapshotRepository creates the VectorSnapshotRepository of the vector store
+// selected by cfg.VectorDB.Type, or returns nil if the store has no snapshots of its own.
+func NewVectorSnapshotRepository(cfg config.Config) (repository.VectorSnapshotRepository, error) {
+	switch cfg.VectorDB.Type {
+	case "", "qdrant":
+		return qdrant.NewSnapshotRepository(cfg.VectorDB.Qdrant)
+	default:
+		return nil, nil
+	}
+}
+
+// NewVectorRepository creates the VectorRepository selected by cfg.VectorDB.Type for the
+// collection configured in cfg.VectorDB.Qdrant. The MySQL vector store keeps the vectors
+// in db.
+func NewVectorRepository(cfg config.Config, db *gorm.DB) (repository.VectorRepository, error) {
+	switch cfg.VectorDB.Type {
+	case "", "qdrant":
+		return qdrant.NewQdrantRepository(cfg.VectorDB.Qdrant)
+	case "memory":
+		return memory.NewMemoryVectorRepository(cfg.VectorDB.Memory, cfg.VectorDB.Qdrant.CollectionName, cfg.VectorDB.Qdrant.VectorSize)
+	case "mysql":
+		if db == nil {
+			return nil, errors.New("the mysql vector store needs a database connection")
+		}
+		return mysql.NewVectorRepository(db, cfg.VectorDB.Qdrant.CollectionName, cfg.VectorDB.Qdrant.VectorSize), nil
+	default:
+		return nil, fmt.Errorf("unknown vector database type: %s", cfg.VectorDB.Type)
+	}
+}
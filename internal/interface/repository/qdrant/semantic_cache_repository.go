@@ -0,0 +1,190 @@
+// open-rag-lecture/internal/interface/repository/qdrant/semantic_cache_repository.go
+
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// semanticCacheCollectionSuffix is appended to the chunk collection name to name the
+// collection of cached answers.
+const semanticCacheCollectionSuffix = "_answer_cache"
+
+// semanticCacheIndexes are the payload fields answer lookups filter on.
+var semanticCacheIndexes = []payloadIndex{
+	{"course_id", pb.FieldType_FieldTypeInteger},
+	{"corpus_version", pb.FieldType_FieldTypeKeyword},
+	{"created_at", pb.FieldType_FieldTypeInteger},
+}
+
+type qdrantSemanticCacheRepository struct {
+	pointsClient      pb.PointsClient
+	collectionsClient pb.CollectionsClient
+	collectionName    string
+	vectorSize        uint64
+}
+
+// NewQdrantSemanticCacheRepository creates a new SemanticCacheRepository implementation
+// that keeps the cached answers in a dedicated Qdrant collection. The collection belongs
+// to the chunk collection of cfg, and its vectors have the size of the chunk vectors, as
+// questions are embedded by the model of the chunks they are searched in.
+func NewQdrantSemanticCacheRepository(cfg config.QdrantConfig) (repository.SemanticCacheRepository, error) {
+	conn, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &qdrantSemanticCacheRepository{
+		pointsClient:      pb.NewPointsClient(conn),
+		collectionsClient: pb.NewCollectionsClient(conn),
+		collectionName:    cfg.CollectionName + semanticCacheCollectionSuffix,
+		vectorSize:        cfg.VectorSize,
+	}, nil
+}
+
+func (r *qdrantSemanticCacheRepository) FindNearest(ctx context.Context, courseID uint64, corpusVersion string, vector []float32, notBefore time.Time) (*model.SemanticCacheEntry, float32, error) {
+	createdAfter := float64(notBefore.Unix())
+	res, err := r.pointsClient.Search(ctx, &pb.SearchPoints{
+		CollectionName: r.collectionName,
+		Vector:         vector,
+		Limit:          1,
+		WithPayload:    &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}},
+		Filter: &pb.Filter{
+			Must: []*pb.Condition{
+				courseCondition(courseID),
+				{
+					ConditionOneOf: &pb.Condition_Field{
+						Field: &pb.FieldCondition{
+							Key:   "corpus_version",
+							Match: &pb.Match{MatchValue: &pb.Match_Keyword{Keyword: corpusVersion}},
+						},
+					},
+				},
+				{
+					ConditionOneOf: &pb.Condition_Field{
+						Field: &pb.FieldCondition{
+							Key:   "created_at",
+							Range: &pb.Range{Gte: &createdAfter},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search answer cache: %w", err)
+	}
+	if len(res.GetResult()) == 0 {
+		return nil, 0, nil
+	}
+
+	point := res.GetResult()[0]
+	payload := point.GetPayload()
+	return &model.SemanticCacheEntry{
+		CourseID:      uint64(payload["course_id"].GetIntegerValue()),
+		CorpusVersion: payload["corpus_version"].GetStringValue(),
+		Query:         payload["query"].GetStringValue(),
+		Answer:        payload["answer"].GetStringValue(),
+		CreatedAt:     time.Unix(payload["created_at"].GetIntegerValue(), 0),
+	}, point.GetScore(), nil
+}
+
+func (r *qdrantSemanticCacheRepository) Save(ctx context.Context, entry *model.SemanticCacheEntry) error {
+	createdAt := entry.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	wait := true
+	_, err := r.pointsClient.Upsert(ctx, &pb.UpsertPoints{
+		CollectionName: r.collectionName,
+		Wait:           &wait,
+		Points: []*pb.PointStruct{{
+			Id:      &pb.PointId{PointIdOptions: &pb.PointId_Uuid{Uuid: uuid.New().String()}},
+			Vectors: &pb.Vectors{VectorsOptions: &pb.Vectors_Vector{Vector: &pb.Vector{Data: entry.Vector}}},
+			Payload: map[string]*pb.Value{
+				"course_id":      {Kind: &pb.Value_IntegerValue{IntegerValue: int64(entry.CourseID)}},
+				"corpus_version": {Kind: &pb.Value_StringValue{StringValue: entry.CorpusVersion}},
+				"query":          {Kind: &pb.Value_StringValue{StringValue: entry.Query}},
+				"answer":         {Kind: &pb.Value_StringValue{StringValue: entry.Answer}},
+				"created_at":     {Kind: &pb.Value_IntegerValue{IntegerValue: createdAt.Unix()}},
+			},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store answer cache entry: %w", err)
+	}
+	return nil
+}
+
+func (r *qdrantSemanticCacheRepository) DeleteByCourse(ctx context.Context, courseID uint64) error {
+	wait := true
+	_, err := r.pointsClient.Delete(ctx, &pb.DeletePoints{
+		CollectionName: r.collectionName,
+		Wait:           &wait,
+		Points: &pb.PointsSelector{
+			PointsSelectorOneOf: &pb.PointsSelector_Filter{
+				Filter: &pb.Filter{Must: []*pb.Condition{courseCondition(courseID)}},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete answer cache of course %d: %w", courseID, err)
+	}
+	return nil
+}
+
+// EnsureCollectionExists creates the answer cache collection only if it does not exist,
+// and indexes the payload fields lookups filter on.
+func (r *qdrantSemanticCacheRepository) EnsureCollectionExists(ctx context.Context) error {
+	_, err := r.collectionsClient.Get(ctx, &pb.GetCollectionInfoRequest{
+		CollectionName: r.collectionName,
+	})
+	if err == nil {
+		return createFieldIndexes(ctx, r.pointsClient, r.collectionName, semanticCacheIndexes)
+	}
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to check qdrant collection existence: %w", err)
+	}
+
+	log.Printf("Qdrant collection '%s' not found. Creating...", r.collectionName)
+	_, err = r.collectionsClient.Create(ctx, &pb.CreateCollection{
+		CollectionName: r.collectionName,
+		VectorsConfig: &pb.VectorsConfig{
+			Config: &pb.VectorsConfig_Params{
+				Params: &pb.VectorParams{
+					Size:     r.vectorSize,
+					Distance: pb.Distance_Cosine,
+				},
+			},
+		},
+	})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("failed to create qdrant collection: %w", err)
+	}
+	return createFieldIndexes(ctx, r.pointsClient, r.collectionName, semanticCacheIndexes)
+}
+
+// courseCondition matches the points of a course.
+func courseCondition(courseID uint64) *pb.Condition {
+	return &pb.Condition{
+		ConditionOneOf: &pb.Condition_Field{
+			Field: &pb.FieldCondition{
+				Key:   "course_id",
+				Match: &pb.Match{MatchValue: &pb.Match_Integer{Integer: int64(courseID)}},
+			},
+		},
+	}
+}
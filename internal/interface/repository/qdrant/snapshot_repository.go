@@ -0,0 +1,202 @@
+// open-rag-lecture/internal/interface/repository/qdrant/snapshot_repository.go
+
+package qdrant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	pb "github.com/qdrant/go-client/qdrant"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// snapshotRepository takes collection snapshots through the gRPC API and moves the
+// snapshot files through the REST API, which is the only one serving them.
+type snapshotRepository struct {
+	vectors         *qdrantRepository
+	snapshotsClient pb.SnapshotsClient
+	httpClient      *http.Client
+	baseURL         string // Base URL of the REST API
+	apiKey          string
+}
+
+// NewSnapshotRepository creates a VectorSnapshotRepository for the collections of the
+// configured Qdrant collection, including the collections of courses with collection
+// tenancy.
+func NewSnapshotRepository(cfg config.QdrantConfig) (repository.VectorSnapshotRepository, error) {
+	conn, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := newQdrantRepository(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if cfg.UseTLS {
+		scheme = "https"
+	}
+	return &snapshotRepository{
+		vectors:         vectors,
+		snapshotsClient: pb.NewSnapshotsClient(conn),
+		httpClient:      &http.Client{},
+		baseURL:         fmt.Sprintf("%s://%s:%d", scheme, cfg.Host, cfg.Port),
+		apiKey:          cfg.APIKey,
+	}, nil
+}
+
+// CreateSnapshots snapshots each collection, downloads the snapshot and deletes it from
+// the server again.
+func (r *snapshotRepository) CreateSnapshots(ctx context.Context, dir string) ([]model.VectorSnapshot, error) {
+	collections, err := r.vectors.pointCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]model.VectorSnapshot, 0, len(collections))
+	for _, collection := range collections {
+		res, err := r.snapshotsClient.Create(ctx, &pb.CreateSnapshotRequest{CollectionName: collection})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create snapshot of qdrant collection '%s': %w", collection, err)
+		}
+		description := res.GetSnapshotDescription()
+
+		snapshot, err := r.download(ctx, collection, description.GetName(), dir)
+		if err != nil {
+			return nil, err
+		}
+		if description.Checksum != nil && description.GetChecksum() != snapshot.Checksum {
+			return nil, fmt.Errorf("snapshot %s of qdrant collection '%s' was corrupted in transfer", description.GetName(), collection)
+		}
+		snapshot.CourseID, _ = r.vectors.collectionCourse(collection)
+		snapshots = append(snapshots, *snapshot)
+
+		_, err = r.snapshotsClient.Delete(ctx, &pb.DeleteSnapshotRequest{CollectionName: collection, SnapshotName: description.GetName()})
+		if err != nil {
+			log.Printf("WARN: failed to delete snapshot %s of qdrant collection '%s' from the server: %v", description.GetName(), collection, err)
+		}
+		log.Printf("Saved snapshot of Qdrant collection '%s' (%d bytes).", collection, snapshot.Size)
+	}
+	return snapshots, nil
+}
+
+// download saves a snapshot file of a collection into dir.
+func (r *snapshotRepository) download(ctx context.Context, collection, name, dir string) (*model.VectorSnapshot, error) {
+	req, err := r.newRequest(ctx, http.MethodGet, fmt.Sprintf("/collections/%s/snapshots/%s", url.PathEscape(collection), url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download snapshot %s of qdrant collection '%s': %w", name, collection, err)
+	}
+	defer res.Body.Close()
+
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download snapshot %s of qdrant collection '%s': %w", name, collection, err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	return &model.VectorSnapshot{File: name, Size: size, Checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// RestoreSnapshots uploads each snapshot into the collection it was taken of under the
+// current configuration, so a snapshot of another index's collection can be restored as
+// long as the tenancy is the same.
+func (r *snapshotRepository) RestoreSnapshots(ctx context.Context, dir string, snapshots []model.VectorSnapshot) error {
+	for _, snapshot := range snapshots {
+		if (snapshot.CourseID != 0) != (r.vectors.tenancy == model.TenancyCollection) {
+			return fmt.Errorf("snapshot %s was taken with another qdrant tenancy than %s", snapshot.File, r.vectors.tenancy)
+		}
+		collection := r.vectors.courseCollection(snapshot.CourseID)
+		if err := r.upload(ctx, collection, filepath.Join(dir, snapshot.File)); err != nil {
+			return fmt.Errorf("failed to restore qdrant collection '%s' from %s: %w", collection, snapshot.File, err)
+		}
+		log.Printf("Restored Qdrant collection '%s' from %s.", collection, snapshot.File)
+	}
+	r.vectors.spaces.Clear()
+	return nil
+}
+
+// upload recovers a collection from a snapshot file, streaming the file as a multipart
+// form. The data of the snapshot takes priority over the existing collection.
+func (r *snapshotRepository) upload(ctx context.Context, collection, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	body, pipe := io.Pipe()
+	form := multipart.NewWriter(pipe)
+	go func() {
+		part, err := form.CreateFormFile("snapshot", filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pipe.CloseWithError(err)
+	}()
+
+	req, err := r.newRequest(ctx, http.MethodPost, fmt.Sprintf("/collections/%s/snapshots/upload?priority=snapshot&wait=true", url.PathEscape(collection)), body)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	res, err := r.do(req)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (r *snapshotRepository) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if r.apiKey != "" {
+		req.Header.Set("api-key", r.apiKey)
+	}
+	return req, nil
+}
+
+// do sends a request to the REST API and turns an error status into an error.
+func (r *snapshotRepository) do(req *http.Request) (*http.Response, error) {
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("qdrant returned %s: %s", res.Status, message)
+	}
+	return res, nil
+}
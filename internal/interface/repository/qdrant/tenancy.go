@@ -0,0 +1,240 @@
+// open-rag-lecture/internal/interface/repository/qdrant/tenancy.go
+
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// courseGroup is the chunks of one course and their vectors.
+type courseGroup struct {
+	courseID uint64
+	chunks   []*model.Chunk
+	vectors  [][]float32
+}
+
+// groupByCourse splits chunks and their vectors by course, keeping the order in which
+// the courses first appear.
+func groupByCourse(chunks []*model.Chunk, vectors [][]float32) []*courseGroup {
+	var groups []*courseGroup
+	byCourse := make(map[uint64]*courseGroup)
+	for i, chunk := range chunks {
+		group, ok := byCourse[chunk.CourseID]
+		if !ok {
+			group = &courseGroup{courseID: chunk.CourseID}
+			byCourse[chunk.CourseID] = group
+			groups = append(groups, group)
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.vectors = append(group.vectors, vectors[i])
+	}
+	return groups
+}
+
+// courseCollection returns the collection holding the points of a course.
+func (r *qdrantRepository) courseCollection(courseID uint64) string {
+	if r.tenancy == model.TenancyCollection {
+		return fmt.Sprintf("%s_course_%d", r.collectionName, courseID)
+	}
+	return r.collectionName
+}
+
+// shardKeySelector returns the selector routing a request to the shard of a course, or
+// nil unless the points are sharded by course.
+func (r *qdrantRepository) shardKeySelector(courseID uint64) *pb.ShardKeySelector {
+	if r.tenancy != model.TenancyShardKey {
+		return nil
+	}
+	return &pb.ShardKeySelector{ShardKeys: []*pb.ShardKey{courseShardKey(courseID)}}
+}
+
+func courseShardKey(courseID uint64) *pb.ShardKey {
+	return &pb.ShardKey{Key: &pb.ShardKey_Number{Number: courseID}}
+}
+
+// isMissingSpace reports whether err is Qdrant rejecting a request for a course whose
+// collection or shard key does not exist. A course without a vector space has no points.
+// With a shared collection, a missing collection is an error.
+func (r *qdrantRepository) isMissingSpace(err error) bool {
+	switch r.tenancy {
+	case model.TenancyCollection:
+		return status.Code(err) == codes.NotFound
+	case model.TenancyShardKey:
+		return status.Code(err) == codes.InvalidArgument && strings.Contains(strings.ToLower(err.Error()), "not found")
+	default:
+		return false
+	}
+}
+
+// pointCollections returns the collections holding points: the collections of all
+// courses with collection tenancy, or else the one shared collection.
+func (r *qdrantRepository) pointCollections(ctx context.Context) ([]string, error) {
+	if r.tenancy != model.TenancyCollection {
+		return []string{r.collectionName}, nil
+	}
+	res, err := r.collectionsClient.List(ctx, &pb.ListCollectionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qdrant collections: %w", err)
+	}
+	var collections []string
+	for _, collection := range res.GetCollections() {
+		if _, ok := r.collectionCourse(collection.GetName()); ok {
+			collections = append(collections, collection.GetName())
+		}
+	}
+	return collections, nil
+}
+
+// collectionCourse returns the course whose points a collection holds with collection
+// tenancy, and false if it is not the collection of a course.
+func (r *qdrantRepository) collectionCourse(collection string) (uint64, bool) {
+	if r.tenancy != model.TenancyCollection {
+		return 0, false
+	}
+	suffix, ok := strings.CutPrefix(collection, r.collectionName+"_course_")
+	if !ok {
+		return 0, false
+	}
+	courseID, err := strconv.ParseUint(suffix, 10, 64)
+	return courseID, err == nil
+}
+
+// deletePoints deletes the selected points from every collection holding points.
+func (r *qdrantRepository) deletePoints(ctx context.Context, points *pb.PointsSelector) error {
+	collections, err := r.pointCollections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		wait := true
+		_, err := r.pointsClient.Delete(ctx, &pb.DeletePoints{
+			CollectionName: collection,
+			Wait:           &wait,
+			Points:         points,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateCourseSpace creates the collection or the shard key of a course. Courses
+// known to have one are skipped, so that it is cheap to call before every write.
+func (r *qdrantRepository) CreateCourseSpace(ctx context.Context, courseID uint64) error {
+	if _, ok := r.spaces.Load(courseID); ok {
+		return nil
+	}
+	switch r.tenancy {
+	case model.TenancyCollection:
+		if err := r.createCollection(ctx, r.courseCollection(courseID)); err != nil {
+			return err
+		}
+	case model.TenancyShardKey:
+		_, err := r.collectionsClient.CreateShardKey(ctx, &pb.CreateShardKeyRequest{
+			CollectionName: r.collectionName,
+			Request:        &pb.CreateShardKey{ShardKey: courseShardKey(courseID)},
+		})
+		if err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("failed to create qdrant shard key for course %d: %w", courseID, err)
+		}
+		if err == nil {
+			log.Printf("Qdrant shard key for course %d created in collection '%s'.", courseID, r.collectionName)
+		}
+	}
+	r.spaces.Store(courseID, struct{}{})
+	return nil
+}
+
+// isAlreadyExists reports whether err is Qdrant rejecting the creation of a shard key
+// that exists, which older servers report as an invalid argument.
+func isAlreadyExists(err error) bool {
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return true
+	case codes.InvalidArgument:
+		return strings.Contains(strings.ToLower(err.Error()), "already exists")
+	default:
+		return false
+	}
+}
+
+// DropCourseSpace deletes the collection or the shard key of a course along with its
+// points. With a shared collection only the points of the course are deleted.
+func (r *qdrantRepository) DropCourseSpace(ctx context.Context, courseID uint64) error {
+	defer r.spaces.Delete(courseID)
+
+	switch r.tenancy {
+	case model.TenancyCollection:
+		_, err := r.collectionsClient.Delete(ctx, &pb.DeleteCollection{
+			CollectionName: r.courseCollection(courseID),
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("failed to delete qdrant collection for course %d: %w", courseID, err)
+		}
+	case model.TenancyShardKey:
+		_, err := r.collectionsClient.DeleteShardKey(ctx, &pb.DeleteShardKeyRequest{
+			CollectionName: r.collectionName,
+			Request:        &pb.DeleteShardKey{ShardKey: courseShardKey(courseID)},
+		})
+		if err != nil && !r.isMissingSpace(err) {
+			return fmt.Errorf("failed to delete qdrant shard key for course %d: %w", courseID, err)
+		}
+	default:
+		wait := true
+		_, err := r.pointsClient.Delete(ctx, &pb.DeletePoints{
+			CollectionName: r.collectionName,
+			Wait:           &wait,
+			Points: &pb.PointsSelector{
+				PointsSelectorOneOf: &pb.PointsSelector_Filter{Filter: searchFilter(model.SearchFilter{CourseID: courseID})},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete qdrant points of course %d: %w", courseID, err)
+		}
+	}
+	log.Printf("Dropped the vector space of course %d (%s tenancy).", courseID, r.tenancy)
+	return nil
+}
+
+// CourseSpaceStats reports where the points of a course live and how many there are.
+func (r *qdrantRepository) CourseSpaceStats(ctx context.Context, courseID uint64) (*model.VectorSpaceStats, error) {
+	stats := &model.VectorSpaceStats{
+		CourseID:   courseID,
+		Tenancy:    r.tenancy,
+		Collection: r.courseCollection(courseID),
+	}
+	if r.tenancy == model.TenancyShardKey {
+		stats.ShardKey = strconv.FormatUint(courseID, 10)
+	}
+
+	exact := true
+	req := &pb.CountPoints{
+		CollectionName:   stats.Collection,
+		Exact:            &exact,
+		ShardKeySelector: r.shardKeySelector(courseID),
+	}
+	if r.tenancy == model.TenancyShared {
+		req.Filter = searchFilter(model.SearchFilter{CourseID: courseID})
+	}
+	res, err := r.pointsClient.Count(ctx, req)
+	if err != nil {
+		if r.isMissingSpace(err) || status.Code(err) == codes.NotFound {
+			return stats, nil
+		}
+		return nil, fmt.Errorf("failed to count qdrant points of course %d: %w", courseID, err)
+	}
+	stats.Exists = true
+	stats.Points = int(res.GetResult().GetCount())
+	return stats, nil
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 
 	pb "github.com/qdrant/go-client/qdrant"
 	"google.golang.org/grpc"
@@ -17,17 +18,61 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
 )
 
+// defaultSparseAvgTerms is the average number of terms in a chunk assumed if none is
+// configured.
+const defaultSparseAvgTerms = 256
+
 type qdrantRepository struct {
 	pointsClient      pb.PointsClient
 	collectionsClient pb.CollectionsClient
 	collectionName    string
 	vectorSize        uint64
+	sparseVector      string  // Name of the sparse vector; empty if the points have none
+	sparseAvgTerms    float64 // Average number of terms in a chunk, for the BM25 weights
+	tenancy           string
+	spaces            sync.Map // IDs of the courses whose vector space is known to exist
 }
 
 // NewQdrantRepository creates a new VectorRepository implementation for Qdrant.
 func NewQdrantRepository(cfg config.QdrantConfig) (repository.VectorRepository, error) {
+	conn, err := dial(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newQdrantRepository(cfg, conn)
+}
+
+func newQdrantRepository(cfg config.QdrantConfig, conn *grpc.ClientConn) (*qdrantRepository, error) {
+	tenancy := cfg.Tenancy
+	switch tenancy {
+	case "":
+		tenancy = model.TenancyShared
+	case model.TenancyShared, model.TenancyCollection, model.TenancyShardKey:
+	default:
+		return nil, fmt.Errorf("unknown qdrant tenancy: %s", cfg.Tenancy)
+	}
+	sparseAvgTerms := cfg.SparseAvgTerms
+	if sparseAvgTerms <= 0 {
+		sparseAvgTerms = defaultSparseAvgTerms
+	}
+
+	return &qdrantRepository{
+		pointsClient:      pb.NewPointsClient(conn),
+		collectionsClient: pb.NewCollectionsClient(conn),
+		collectionName:    cfg.CollectionName,
+		vectorSize:        cfg.VectorSize,
+		sparseVector:      cfg.SparseVector,
+		sparseAvgTerms:    sparseAvgTerms,
+		tenancy:           tenancy,
+	}, nil
+}
+
+// dial opens the gRPC connection to Qdrant.
+func dial(cfg config.QdrantConfig) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 	if cfg.UseTLS {
 		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(nil)))
@@ -39,135 +84,454 @@ func NewQdrantRepository(cfg config.QdrantConfig) (repository.VectorRepository,
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to qdrant: %w", err)
 	}
-
-	return &qdrantRepository{
-		pointsClient:      pb.NewPointsClient(conn),
-		collectionsClient: pb.NewCollectionsClient(conn),
-		collectionName:    cfg.CollectionName,
-		vectorSize:        cfg.VectorSize,
-	}, nil
+	return conn, nil
 }
 
+// Upsert stores the points of each course in the vector space of the course, which is
+// created if needed.
 func (r *qdrantRepository) Upsert(ctx context.Context, chunks []*model.Chunk, vectors [][]float32) error {
+	for _, group := range groupByCourse(chunks, vectors) {
+		if err := r.CreateCourseSpace(ctx, group.courseID); err != nil {
+			return err
+		}
+		wait := true
+		_, err := r.pointsClient.Upsert(ctx, &pb.UpsertPoints{
+			CollectionName:   r.courseCollection(group.courseID),
+			Wait:             &wait,
+			Points:           r.chunkPoints(group.chunks, group.vectors),
+			ShardKeySelector: r.shardKeySelector(group.courseID),
+		})
+		if err != nil {
+			// The space may have been dropped by another process.
+			r.spaces.Delete(group.courseID)
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkPoints builds the points of chunks and their vectors. With a sparse vector
+// configured, the BM25 term weights of the chunk text are stored under its name next to
+// the dense vector, which keeps the default (empty) name. Qdrant applies the inverse
+// document frequency at search time.
+func (r *qdrantRepository) chunkPoints(chunks []*model.Chunk, vectors [][]float32) []*pb.PointStruct {
 	points := make([]*pb.PointStruct, len(chunks))
 	for i, chunk := range chunks {
+		pointVectors := &pb.Vectors{VectorsOptions: &pb.Vectors_Vector{Vector: &pb.Vector{Data: vectors[i]}}}
+		if r.sparseVector != "" {
+			terms := tokenizer.BM25Document(chunk.Text, r.sparseAvgTerms)
+			pointVectors = &pb.Vectors{VectorsOptions: &pb.Vectors_Vectors{Vectors: &pb.NamedVectors{
+				Vectors: map[string]*pb.Vector{
+					"":             {Data: vectors[i]},
+					r.sparseVector: {Data: terms.Values, Indices: &pb.SparseIndices{Data: terms.Indices}},
+				},
+			}}}
+		}
 		points[i] = &pb.PointStruct{
 			Id:      &pb.PointId{PointIdOptions: &pb.PointId_Uuid{Uuid: chunk.EmbeddingID}},
-			Vectors: &pb.Vectors{VectorsOptions: &pb.Vectors_Vector{Vector: &pb.Vector{Data: vectors[i]}}},
-			Payload: map[string]*pb.Value{
-				"text":      {Kind: &pb.Value_StringValue{StringValue: chunk.Text}},
-				"chunk_id":  {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.ID)}},
-				"doc_id":    {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.DocumentID)}},
-				"course_id": {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.CourseID)}},
-			},
+			Vectors: pointVectors,
+			Payload: chunkPayload(chunk),
 		}
 	}
-
-	wait := true
-	_, err := r.pointsClient.Upsert(ctx, &pb.UpsertPoints{
-		CollectionName: r.collectionName,
-		Wait:           &wait,
-		Points:         points,
-	})
-	return err
+	return points
 }
 
-func (r *qdrantRepository) Search(ctx context.Context, queryVector []float32, courseID uint64, limit int) ([]model.RetrievedChunk, error) {
+func (r *qdrantRepository) Search(ctx context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
 	searchRequest := &pb.SearchPoints{
-		CollectionName: r.collectionName,
-		Vector:         queryVector,
-		Limit:          uint64(limit),
-		WithPayload:    &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}},
-		Filter: &pb.Filter{
-			Must: []*pb.Condition{
-				{
-					ConditionOneOf: &pb.Condition_Field{
-						Field: &pb.FieldCondition{
-							Key: "course_id",
-							Match: &pb.Match{
-								MatchValue: &pb.Match_Integer{Integer: int64(courseID)},
-							},
-						},
-					},
-				},
-			},
-		},
+		CollectionName:   r.courseCollection(filter.CourseID),
+		Vector:           queryVector,
+		Limit:            uint64(limit),
+		WithPayload:      &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}},
+		Filter:           searchFilter(filter),
+		ShardKeySelector: r.shardKeySelector(filter.CourseID),
 	}
 
 	res, err := r.pointsClient.Search(ctx, searchRequest)
 	if err != nil {
+		if r.isMissingSpace(err) {
+			return nil, nil
+		}
 		return nil, err
 	}
 
 	retrievedChunks := make([]model.RetrievedChunk, len(res.GetResult()))
 	for i, point := range res.GetResult() {
-		payload := point.GetPayload()
 		retrievedChunks[i] = model.RetrievedChunk{
-			Chunk: model.Chunk{
-				Base:       model.Base{ID: uint64(payload["chunk_id"].GetIntegerValue())},
-				DocumentID: uint64(payload["doc_id"].GetIntegerValue()),
-				CourseID:   uint64(payload["course_id"].GetIntegerValue()),
-				Text:       payload["text"].GetStringValue(),
-			},
+			Chunk: chunkFromPayload(point.GetId(), point.GetPayload()),
 			Score: point.GetScore(),
 		}
 	}
 	return retrievedChunks, nil
 }
 
-// RecreateCollection deletes and then creates the collection.
-func (r *qdrantRepository) RecreateCollection(ctx context.Context) error {
-	// 1. Delete the collection if it exists.
-	_, err := r.collectionsClient.Delete(ctx, &pb.DeleteCollection{
-		CollectionName: r.collectionName,
+// HybridSearch sends the dense and the sparse search as one batch request and fuses
+// their hits.
+func (r *qdrantRepository) HybridSearch(ctx context.Context, query model.HybridQuery, filter model.SearchFilter) ([]model.RetrievedChunk, error) {
+	if r.sparseVector == "" {
+		return nil, appErrors.ErrHybridSearchUnsupported
+	}
+	collection := r.courseCollection(filter.CourseID)
+	withPayload := &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}}
+	var searches []*pb.SearchPoints
+	var weights []float64
+	if query.DenseLimit > 0 {
+		dense := &pb.SearchPoints{
+			CollectionName:   collection,
+			Vector:           query.Vector,
+			Limit:            uint64(query.DenseLimit),
+			WithPayload:      withPayload,
+			Filter:           searchFilter(filter),
+			ShardKeySelector: r.shardKeySelector(filter.CourseID),
+		}
+		if query.MinScore > 0 {
+			dense.ScoreThreshold = &query.MinScore
+		}
+		searches = append(searches, dense)
+		weights = append(weights, query.DenseWeight)
+	}
+	if terms := tokenizer.BM25Query(query.Text); query.SparseLimit > 0 && len(terms.Indices) > 0 {
+		searches = append(searches, &pb.SearchPoints{
+			CollectionName:   collection,
+			Vector:           terms.Values,
+			SparseIndices:    &pb.SparseIndices{Data: terms.Indices},
+			VectorName:       &r.sparseVector,
+			Limit:            uint64(query.SparseLimit),
+			WithPayload:      withPayload,
+			Filter:           searchFilter(filter),
+			ShardKeySelector: r.shardKeySelector(filter.CourseID),
+		})
+		weights = append(weights, query.SparseWeight)
+	}
+	if len(searches) == 0 {
+		return nil, nil
+	}
+
+	res, err := r.pointsClient.SearchBatch(ctx, &pb.SearchBatchPoints{
+		CollectionName: collection,
+		SearchPoints:   searches,
+	})
+	if err != nil {
+		if r.isMissingSpace(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("hybrid search on qdrant failed: %w", err)
+	}
+	lists := make([][]model.RetrievedChunk, len(res.GetResult()))
+	for i, batch := range res.GetResult() {
+		lists[i] = make([]model.RetrievedChunk, len(batch.GetResult()))
+		for j, point := range batch.GetResult() {
+			lists[i][j] = model.RetrievedChunk{
+				Chunk: chunkFromPayload(point.GetId(), point.GetPayload()),
+				Score: point.GetScore(),
+			}
+		}
+	}
+	return model.FuseRankings(query.RRFK, weights, lists...), nil
+}
+
+func (r *qdrantRepository) GetVectors(ctx context.Context, embeddingIDs []string) ([]model.ChunkVector, error) {
+	if len(embeddingIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]*pb.PointId, len(embeddingIDs))
+	for i, id := range embeddingIDs {
+		ids[i] = &pb.PointId{PointIdOptions: &pb.PointId_Uuid{Uuid: id}}
+	}
+
+	collections, err := r.pointCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []model.ChunkVector
+	for _, collection := range collections {
+		res, err := r.pointsClient.Get(ctx, &pb.GetPoints{
+			CollectionName: collection,
+			Ids:            ids,
+			WithPayload:    &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}},
+			WithVectors:    &pb.WithVectorsSelector{SelectorOptions: &pb.WithVectorsSelector_Enable{Enable: true}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get qdrant points: %w", err)
+		}
+		for _, point := range res.GetResult() {
+			vectors = append(vectors, model.ChunkVector{
+				Chunk:  chunkFromPayload(point.GetId(), point.GetPayload()),
+				Vector: denseVector(point.GetVectors()),
+			})
+		}
+	}
+	return vectors, nil
+}
+
+// denseVector returns the dense vector of a point, which is the default vector of points
+// that also have a sparse vector.
+func denseVector(vectors *pb.Vectors) []float32 {
+	if vector := vectors.GetVector(); vector != nil {
+		return vector.GetData()
+	}
+	return vectors.GetVectors().GetVectors()[""].GetData()
+}
+
+func (r *qdrantRepository) DeleteByIDs(ctx context.Context, embeddingIDs []string) error {
+	if len(embeddingIDs) == 0 {
+		return nil
+	}
+	ids := make([]*pb.PointId, len(embeddingIDs))
+	for i, id := range embeddingIDs {
+		ids[i] = &pb.PointId{PointIdOptions: &pb.PointId_Uuid{Uuid: id}}
+	}
+
+	err := r.deletePoints(ctx, &pb.PointsSelector{
+		PointsSelectorOneOf: &pb.PointsSelector_Points{Points: &pb.PointsIdsList{Ids: ids}},
 	})
-	if err != nil && status.Code(err) != codes.NotFound {
-		return fmt.Errorf("failed to delete qdrant collection: %w", err)
+	if err != nil {
+		return fmt.Errorf("failed to delete qdrant points: %w", err)
 	}
-	log.Printf("Qdrant collection '%s' deleted (if it existed).", r.collectionName)
+	return nil
+}
 
-	// 2. Create a new collection.
-	_, err = r.collectionsClient.Create(ctx, &pb.CreateCollection{
-		CollectionName: r.collectionName,
-		VectorsConfig: &pb.VectorsConfig{
-			Config: &pb.VectorsConfig_Params{
-				Params: &pb.VectorParams{
-					Size:     r.vectorSize,
-					Distance: pb.Distance_Cosine,
-				},
+func (r *qdrantRepository) DeleteByDocument(ctx context.Context, documentID uint64) error {
+	if err := r.deletePoints(ctx, documentsSelector([]uint64{documentID})); err != nil {
+		return fmt.Errorf("failed to delete qdrant points of document %d: %w", documentID, err)
+	}
+	return nil
+}
+
+// ReplaceDocuments sends the upsert and the deletion as one batch update, which Qdrant
+// applies in order. The new chunks and the replaced documents belong to one course.
+func (r *qdrantRepository) ReplaceDocuments(ctx context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error {
+	if len(chunks) == 0 {
+		if len(oldDocumentIDs) == 0 {
+			return nil
+		}
+		return r.deletePoints(ctx, documentsSelector(oldDocumentIDs))
+	}
+	groups := groupByCourse(chunks, vectors)
+	if len(groups) > 1 {
+		return fmt.Errorf("cannot replace documents with chunks of %d courses", len(groups))
+	}
+	courseID := groups[0].courseID
+	if err := r.CreateCourseSpace(ctx, courseID); err != nil {
+		return err
+	}
+
+	operations := []*pb.PointsUpdateOperation{{
+		Operation: &pb.PointsUpdateOperation_Upsert{
+			Upsert: &pb.PointsUpdateOperation_PointStructList{
+				Points:           r.chunkPoints(chunks, vectors),
+				ShardKeySelector: r.shardKeySelector(courseID),
 			},
 		},
+	}}
+	if len(oldDocumentIDs) > 0 {
+		operations = append(operations, &pb.PointsUpdateOperation{
+			Operation: &pb.PointsUpdateOperation_DeletePoints_{
+				DeletePoints: &pb.PointsUpdateOperation_DeletePoints{
+					Points:           documentsSelector(oldDocumentIDs),
+					ShardKeySelector: r.shardKeySelector(courseID),
+				},
+			},
+		})
+	}
+
+	wait := true
+	_, err := r.pointsClient.UpdateBatch(ctx, &pb.UpdateBatchPoints{
+		CollectionName: r.courseCollection(courseID),
+		Wait:           &wait,
+		Operations:     operations,
 	})
 	if err != nil {
-		if status.Code(err) == codes.AlreadyExists {
-			log.Printf("Qdrant collection '%s' already exists (race condition handled).", r.collectionName)
-			return nil
+		return fmt.Errorf("failed to replace qdrant points of documents %v: %w", oldDocumentIDs, err)
+	}
+	return nil
+}
+
+// documentsSelector selects the points of the chunks of the given documents.
+func documentsSelector(documentIDs []uint64) *pb.PointsSelector {
+	ids := make([]int64, len(documentIDs))
+	for i, id := range documentIDs {
+		ids[i] = int64(id)
+	}
+	return &pb.PointsSelector{
+		PointsSelectorOneOf: &pb.PointsSelector_Filter{
+			Filter: &pb.Filter{Must: []*pb.Condition{
+				fieldCondition("doc_id", &pb.Match{MatchValue: &pb.Match_Integers{Integers: &pb.RepeatedIntegers{Integers: ids}}}),
+			}},
+		},
+	}
+}
+
+// Scan scrolls through the points of every collection holding points.
+func (r *qdrantRepository) Scan(ctx context.Context, batchSize int, fn func(points []model.ChunkVector) error) error {
+	collections, err := r.pointCollections(ctx)
+	if err != nil {
+		return err
+	}
+	limit := uint32(max(batchSize, 1))
+	for _, collection := range collections {
+		var offset *pb.PointId
+		for {
+			res, err := r.pointsClient.Scroll(ctx, &pb.ScrollPoints{
+				CollectionName: collection,
+				Offset:         offset,
+				Limit:          &limit,
+				WithPayload:    &pb.WithPayloadSelector{SelectorOptions: &pb.WithPayloadSelector_Enable{Enable: true}},
+				WithVectors:    &pb.WithVectorsSelector{SelectorOptions: &pb.WithVectorsSelector_Enable{Enable: true}},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to scroll qdrant points: %w", err)
+			}
+			if len(res.GetResult()) > 0 {
+				points := make([]model.ChunkVector, len(res.GetResult()))
+				for i, point := range res.GetResult() {
+					points[i] = model.ChunkVector{
+						Chunk:  chunkFromPayload(point.GetId(), point.GetPayload()),
+						Vector: denseVector(point.GetVectors()),
+					}
+				}
+				if err := fn(points); err != nil {
+					return err
+				}
+			}
+			if offset = res.GetNextPageOffset(); offset == nil {
+				break
+			}
 		}
-		return fmt.Errorf("failed to create qdrant collection: %w", err)
 	}
-	log.Printf("Qdrant collection '%s' created successfully.", r.collectionName)
 	return nil
 }
 
-// EnsureCollectionExists creates the collection only if it does not exist.
-func (r *qdrantRepository) EnsureCollectionExists(ctx context.Context) error {
-	// 1. Check if the collection exists.
-	res, err := r.collectionsClient.Get(ctx, &pb.GetCollectionInfoRequest{
-		CollectionName: r.collectionName,
-	})
-	if err == nil && res != nil {
-		log.Printf("Qdrant collection '%s' already exists. Skipping creation.", r.collectionName)
-		return nil // Collection already exists
+func (r *qdrantRepository) Count(ctx context.Context) (int, error) {
+	collections, err := r.pointCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for _, collection := range collections {
+		exact := true
+		res, err := r.pointsClient.Count(ctx, &pb.CountPoints{
+			CollectionName: collection,
+			Exact:          &exact,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to count qdrant points: %w", err)
+		}
+		total += int(res.GetResult().GetCount())
 	}
+	return total, nil
+}
 
-	if status.Code(err) != codes.NotFound {
-		return fmt.Errorf("failed to check qdrant collection existence: %w", err)
+// payloadIndex is a payload field that searches filter on, with its index type.
+type payloadIndex struct {
+	field     string
+	fieldType pb.FieldType
+}
+
+// payloadIndexes are the payload fields chunk searches filter on.
+var payloadIndexes = []payloadIndex{
+	{"course_id", pb.FieldType_FieldTypeInteger},
+	{"doc_id", pb.FieldType_FieldTypeInteger},
+	{"semester_id", pb.FieldType_FieldTypeInteger},
+	{"doc_type", pb.FieldType_FieldTypeKeyword},
+	{"language", pb.FieldType_FieldTypeKeyword},
+}
+
+// chunkPayload returns the payload of the point of a chunk. The page and document
+// fields are taken from chunk.Page and chunk.Document.
+func chunkPayload(chunk *model.Chunk) map[string]*pb.Value {
+	return map[string]*pb.Value{
+		"text":        {Kind: &pb.Value_StringValue{StringValue: chunk.Text}},
+		"chunk_id":    {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.ID)}},
+		"doc_id":      {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.DocumentID)}},
+		"course_id":   {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.CourseID)}},
+		"semester_id": {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.SemesterID)}},
+		"page_number": {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.Page.PageNumber)}},
+		"doc_type":    {Kind: &pb.Value_StringValue{StringValue: string(chunk.Document.DocType)}},
+		"doc_version": {Kind: &pb.Value_IntegerValue{IntegerValue: int64(chunk.Document.Version)}},
+		"language":    {Kind: &pb.Value_StringValue{StringValue: chunk.Page.Language}},
+		"vector_hash": {Kind: &pb.Value_StringValue{StringValue: chunk.VectorHash}},
 	}
+}
 
-	// 2. If not found, create it.
-	log.Printf("Qdrant collection '%s' not found. Creating...", r.collectionName)
-	_, err = r.collectionsClient.Create(ctx, &pb.CreateCollection{
-		CollectionName: r.collectionName,
+// chunkFromPayload rebuilds the chunk fields stored in a point payload.
+func chunkFromPayload(id *pb.PointId, payload map[string]*pb.Value) model.Chunk {
+	return model.Chunk{
+		Base:        model.Base{ID: uint64(payload["chunk_id"].GetIntegerValue())},
+		DocumentID:  uint64(payload["doc_id"].GetIntegerValue()),
+		CourseID:    uint64(payload["course_id"].GetIntegerValue()),
+		SemesterID:  uint64(payload["semester_id"].GetIntegerValue()),
+		Text:        payload["text"].GetStringValue(),
+		EmbeddingID: id.GetUuid(),
+		VectorHash:  payload["vector_hash"].GetStringValue(),
+		Page: model.Page{
+			PageNumber: int(payload["page_number"].GetIntegerValue()),
+			Language:   payload["language"].GetStringValue(),
+		},
+		Document: model.Document{
+			DocType: model.DocType(payload["doc_type"].GetStringValue()),
+			Version: int(payload["doc_version"].GetIntegerValue()),
+		},
+	}
+}
+
+// searchFilter translates a SearchFilter into the conditions on the point payload.
+func searchFilter(filter model.SearchFilter) *pb.Filter {
+	must := []*pb.Condition{
+		fieldCondition("course_id", &pb.Match{MatchValue: &pb.Match_Integer{Integer: int64(filter.CourseID)}}),
+	}
+	if len(filter.DocumentIDs) > 0 {
+		ids := make([]int64, len(filter.DocumentIDs))
+		for i, id := range filter.DocumentIDs {
+			ids[i] = int64(id)
+		}
+		must = append(must, fieldCondition("doc_id", &pb.Match{MatchValue: &pb.Match_Integers{Integers: &pb.RepeatedIntegers{Integers: ids}}}))
+	}
+	if filter.SemesterID != 0 {
+		must = append(must, fieldCondition("semester_id", &pb.Match{MatchValue: &pb.Match_Integer{Integer: int64(filter.SemesterID)}}))
+	}
+	if filter.DocType != "" {
+		must = append(must, fieldCondition("doc_type", &pb.Match{MatchValue: &pb.Match_Keyword{Keyword: string(filter.DocType)}}))
+	}
+	return &pb.Filter{Must: must}
+}
+
+func fieldCondition(key string, match *pb.Match) *pb.Condition {
+	return &pb.Condition{
+		ConditionOneOf: &pb.Condition_Field{
+			Field: &pb.FieldCondition{Key: key, Match: match},
+		},
+	}
+}
+
+// createPayloadIndexes indexes the payload fields chunk searches filter on.
+func (r *qdrantRepository) createPayloadIndexes(ctx context.Context, collection string) error {
+	return createFieldIndexes(ctx, r.pointsClient, collection, payloadIndexes)
+}
+
+// createFieldIndexes indexes payload fields of a collection. Indexing an already indexed
+// field is a no-op.
+func createFieldIndexes(ctx context.Context, pointsClient pb.PointsClient, collection string, indexes []payloadIndex) error {
+	wait := true
+	for _, index := range indexes {
+		_, err := pointsClient.CreateFieldIndex(ctx, &pb.CreateFieldIndexCollection{
+			CollectionName: collection,
+			Wait:           &wait,
+			FieldName:      index.field,
+			FieldType:      index.fieldType.Enum(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create payload index on '%s': %w", index.field, err)
+		}
+	}
+	return nil
+}
+
+// createCollectionRequest returns the request creating a collection with its dense
+// vector and, if configured, its sparse vector. With shard key tenancy the collection
+// is sharded by the custom shard keys of the courses.
+func (r *qdrantRepository) createCollectionRequest(collection string) *pb.CreateCollection {
+	req := &pb.CreateCollection{
+		CollectionName: collection,
 		VectorsConfig: &pb.VectorsConfig{
 			Config: &pb.VectorsConfig_Params{
 				Params: &pb.VectorParams{
@@ -176,14 +540,127 @@ func (r *qdrantRepository) EnsureCollectionExists(ctx context.Context) error {
 				},
 			},
 		},
-	})
+	}
+	if r.sparseVector != "" {
+		req.SparseVectorsConfig = r.sparseVectorsConfig()
+	}
+	if r.tenancy == model.TenancyShardKey {
+		req.ShardingMethod = pb.ShardingMethod_Custom.Enum()
+	}
+	return req
+}
+
+// sparseVectorsConfig configures the sparse vector with the IDF modifier, which makes
+// Qdrant weight the query terms by their inverse document frequency in the collection.
+func (r *qdrantRepository) sparseVectorsConfig() *pb.SparseVectorConfig {
+	return &pb.SparseVectorConfig{Map: map[string]*pb.SparseVectorParams{
+		r.sparseVector: {Modifier: pb.Modifier_Idf.Enum()},
+	}}
+}
+
+// checkSparseVector checks that an existing collection has the configured sparse vector
+// with the IDF modifier. Qdrant cannot add a vector to an existing collection, so a
+// collection without it is rebuilt as a new embedding index by the batch task "reembed".
+func (r *qdrantRepository) checkSparseVector(collection string, info *pb.CollectionInfo) error {
+	if r.sparseVector == "" {
+		return nil
+	}
+	params, ok := info.GetConfig().GetParams().GetSparseVectorsConfig().GetMap()[r.sparseVector]
+	if !ok || params.GetModifier() != pb.Modifier_Idf {
+		return fmt.Errorf("qdrant collection '%s' has no sparse vector '%s' with the IDF modifier; build a new index with the batch task \"reembed\"", collection, r.sparseVector)
+	}
+	return nil
+}
+
+// createCollection creates a collection and its payload indexes. A collection created
+// concurrently by another process is not an error.
+func (r *qdrantRepository) createCollection(ctx context.Context, collection string) error {
+	_, err := r.collectionsClient.Create(ctx, r.createCollectionRequest(collection))
 	if err != nil {
 		if status.Code(err) == codes.AlreadyExists {
-			return nil
+			log.Printf("Qdrant collection '%s' already exists (race condition handled).", collection)
+			return r.createPayloadIndexes(ctx, collection)
 		}
 		return fmt.Errorf("failed to create qdrant collection: %w", err)
 	}
+	log.Printf("Qdrant collection '%s' created successfully.", collection)
+	return r.createPayloadIndexes(ctx, collection)
+}
 
-	log.Printf("Qdrant collection '%s' created successfully.", r.collectionName)
-	return nil
+// RecreateCollection deletes and then creates the collection. With collection tenancy
+// the collections of all courses are deleted; they are created again by the next upsert.
+func (r *qdrantRepository) RecreateCollection(ctx context.Context) error {
+	defer r.spaces.Clear()
+
+	// 1. Delete the collections if they exist.
+	collections, err := r.pointCollections(ctx)
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		_, err := r.collectionsClient.Delete(ctx, &pb.DeleteCollection{
+			CollectionName: collection,
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("failed to delete qdrant collection: %w", err)
+		}
+		log.Printf("Qdrant collection '%s' deleted (if it existed).", collection)
+	}
+	if r.tenancy == model.TenancyCollection {
+		return nil
+	}
+
+	// 2. Create a new collection.
+	return r.createCollection(ctx, r.collectionName)
+}
+
+// EnsureCollectionExists creates the collection only if it does not exist. With
+// collection tenancy it only brings the existing course collections up to date.
+func (r *qdrantRepository) EnsureCollectionExists(ctx context.Context) error {
+	if r.tenancy == model.TenancyCollection {
+		collections, err := r.pointCollections(ctx)
+		if err != nil {
+			return err
+		}
+		for _, collection := range collections {
+			if err := r.ensureCollectionUpToDate(ctx, collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 1. Check if the collection exists.
+	err := r.ensureCollectionUpToDate(ctx, r.collectionName)
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+
+	// 2. If not found, create it.
+	log.Printf("Qdrant collection '%s' not found. Creating...", r.collectionName)
+	return r.createCollection(ctx, r.collectionName)
+}
+
+// ensureCollectionUpToDate adds the payload indexes to an existing collection created
+// before they existed, and checks its sharding and sparse vector. It returns the status
+// error of Qdrant if the collection does not exist.
+func (r *qdrantRepository) ensureCollectionUpToDate(ctx context.Context, collection string) error {
+	res, err := r.collectionsClient.Get(ctx, &pb.GetCollectionInfoRequest{
+		CollectionName: collection,
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return err
+		}
+		return fmt.Errorf("failed to check qdrant collection existence: %w", err)
+	}
+	log.Printf("Qdrant collection '%s' already exists. Skipping creation.", collection)
+	info := res.GetResult()
+	if r.tenancy == model.TenancyShardKey && info.GetConfig().GetParams().GetShardingMethod() != pb.ShardingMethod_Custom {
+		return fmt.Errorf("qdrant collection '%s' is not sharded by custom shard keys; recreate it to use shard_key tenancy", collection)
+	}
+	if err := r.checkSparseVector(collection, info); err != nil {
+		return err
+	}
+	return r.createPayloadIndexes(ctx, collection)
 }
@@ -46,3 +46,7 @@ func (r *redisRepository) Set(ctx context.Context, key string, value interface{}
 func (r *redisRepository) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
+
+func (r *redisRepository) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
@@ -0,0 +1,34 @@
+// OpenRAGLecture/internal/interface/repository/redis/noop_repository.go
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+type noopRepository struct{}
+
+// NewNoopRepository creates a CacheRepository that stores nothing. It stands in for
+// Redis when the answer cache is disabled or Redis is unreachable: every lookup is a
+// miss, so callers fall back to their uncached paths.
+func NewNoopRepository() repository.CacheRepository {
+	return noopRepository{}
+}
+
+func (noopRepository) Get(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func (noopRepository) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (noopRepository) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func (noopRepository) Incr(ctx context.Context, key string) (int64, error) {
+	return 0, nil
+}
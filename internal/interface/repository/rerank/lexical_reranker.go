@@ -0,0 +1,77 @@
+// OpenRAGLecture/internal/interface/repository/rerank/lexical_reranker.go
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+type lexicalReranker struct{}
+
+// NewLexicalReranker creates a Reranker that scores candidates by the share of query
+// terms they contain. It needs no external service and is meant for offline use.
+func NewLexicalReranker() repository.Reranker {
+	return &lexicalReranker{}
+}
+
+func (r *lexicalReranker) Rerank(ctx context.Context, query string, candidates []model.RetrievedChunk, topN int) ([]model.RetrievedChunk, error) {
+	queryTerms := terms(query)
+	scores := make([]float32, len(candidates))
+	if len(queryTerms) > 0 {
+		for idx, candidate := range candidates {
+			chunkTerms := terms(candidate.Chunk.Text)
+			matched := 0
+			for term := range queryTerms {
+				if _, ok := chunkTerms[term]; ok {
+					matched++
+				}
+			}
+			scores[idx] = float32(matched) / float32(len(queryTerms))
+		}
+	}
+	return sortByScores(candidates, scores, topN), nil
+}
+
+// terms splits text into lower-cased words. Runs of CJK characters, which are not
+// separated by spaces, are split into character bigrams instead.
+func terms(text string) map[string]struct{} {
+	result := make(map[string]struct{})
+	var word, cjk []rune
+
+	flushWord := func() {
+		if len(word) > 1 {
+			result[strings.ToLower(string(word))] = struct{}{}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			result[string(cjk)] = struct{}{}
+		}
+		for idx := 0; idx+1 < len(cjk); idx++ {
+			result[string(cjk[idx:idx+2])] = struct{}{}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return result
+}
@@ -0,0 +1,72 @@
+// OpenRAGLecture/internal/interface/repository/rerank/llm_reranker.go
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	llmRerankConcurrency = 4
+	llmRerankMaxScore    = 10
+	llmRerankPrompt      = `You judge search results for a university lecture assistant.
+Rate how relevant the passage is to the question on a scale from 0 (irrelevant) to 10 (answers the question completely).
+Respond with the number only.`
+)
+
+var llmScorePattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+type llmReranker struct {
+	llmRepo repository.LLMRepository
+}
+
+// NewLLMReranker creates a pointwise Reranker that asks the LLM to score each
+// candidate against the query. Scores are normalized to the range 0 to 1.
+func NewLLMReranker(llmRepo repository.LLMRepository) repository.Reranker {
+	return &llmReranker{llmRepo: llmRepo}
+}
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, candidates []model.RetrievedChunk, topN int) ([]model.RetrievedChunk, error) {
+	scores := make([]float32, len(candidates))
+	eg, gCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(llmRerankConcurrency)
+
+	for idx, candidate := range candidates {
+		eg.Go(func() error {
+			score, err := r.score(gCtx, query, candidate.Chunk.Text)
+			if err != nil {
+				return err
+			}
+			scores[idx] = score
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return sortByScores(candidates, scores, topN), nil
+}
+
+func (r *llmReranker) score(ctx context.Context, query, passage string) (float32, error) {
+	response, err := r.llmRepo.GenerateContent(ctx, repository.GenerateContentParams{
+		SystemPrompt: llmRerankPrompt,
+		UserPrompt:   fmt.Sprintf("Question: %s\n\nPassage:\n%s", query, passage),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to score candidate: %w", err)
+	}
+
+	// An unparsable rating counts as irrelevant rather than failing the whole request.
+	value, err := strconv.ParseFloat(llmScorePattern.FindString(response), 32)
+	if err != nil {
+		return 0, nil
+	}
+	return float32(min(value, llmRerankMaxScore) / llmRerankMaxScore), nil
+}
@@ -0,0 +1,47 @@
+// OpenRAGLecture/internal/interface/repository/rerank/reranker.go
+package rerank
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// NewReranker creates the Reranker selected by cfg.Type ("rrf", "llm" or "lexical").
+// The LLM repository is only used by the "llm" reranker.
+func NewReranker(cfg config.RerankConfig, llmRepo repository.LLMRepository) (repository.Reranker, error) {
+	switch cfg.Type {
+	case "", "rrf":
+		return NewRRFReranker(), nil
+	case "llm":
+		return NewLLMReranker(llmRepo), nil
+	case "lexical":
+		return NewLexicalReranker(), nil
+	default:
+		return nil, fmt.Errorf("unknown reranker type: %s", cfg.Type)
+	}
+}
+
+// sortByScores orders the candidates by descending score, keeping the fusion
+// order for equal scores, replaces their scores and truncates them to topN.
+func sortByScores(candidates []model.RetrievedChunk, scores []float32, topN int) []model.RetrievedChunk {
+	ranked := make([]model.RetrievedChunk, len(candidates))
+	copy(ranked, candidates)
+	for idx := range ranked {
+		ranked[idx].Score = scores[idx]
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return ranked[a].Score > ranked[b].Score
+	})
+	return truncate(ranked, topN)
+}
+
+func truncate(chunks []model.RetrievedChunk, topN int) []model.RetrievedChunk {
+	if topN >= 0 && len(chunks) > topN {
+		return chunks[:topN]
+	}
+	return chunks
+}
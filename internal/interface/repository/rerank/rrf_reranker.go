@@ -0,0 +1,21 @@
+// OpenRAGLecture/internal/interface/repository/rerank/rrf_reranker.go
+package rerank
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+type rrfReranker struct{}
+
+// NewRRFReranker creates a Reranker that keeps the reciprocal rank fusion order
+// of the candidates and only truncates them.
+func NewRRFReranker() repository.Reranker {
+	return &rrfReranker{}
+}
+
+func (r *rrfReranker) Rerank(ctx context.Context, query string, candidates []model.RetrievedChunk, topN int) ([]model.RetrievedChunk, error) {
+	return truncate(candidates, topN), nil
+}
@@ -0,0 +1,66 @@
+// OpenRAGLecture/internal/interface/repository/resilient/breaker.go
+package resilient
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops calls to a provider after a run of consecutive failures. Once
+// the cooldown has passed a single trial call is let through: if it succeeds the
+// breaker closes again, otherwise it stays open for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int // Consecutive failures that open the breaker; 0 disables the breaker
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures  int
+	openUntil time.Time // Zero while the breaker is closed
+	trial     bool      // Whether the trial call of a half-open breaker is in flight
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may be made.
+func (b *circuitBreaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if b.trial || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// success records a successful call and closes the breaker.
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trial = false
+}
+
+// failure records a failed call and opens the breaker once the threshold is reached.
+func (b *circuitBreaker) failure() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.trial || b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+	b.trial = false
+}
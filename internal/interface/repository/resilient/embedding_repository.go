@@ -0,0 +1,44 @@
+// OpenRAGLecture/internal/interface/repository/resilient/embedding_repository.go
+package resilient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+type resilientEmbeddingRepository struct {
+	providers []repository.EmbeddingRepository
+	members   []*member
+	policy    policy
+}
+
+// NewEmbeddingRepository creates an EmbeddingRepository that retries transient failures
+// and falls over to the next of the given providers when one fails. All providers must
+// serve the same embedding model: vectors of different models are not comparable.
+func NewEmbeddingRepository(providers []repository.EmbeddingRepository, names []string, cfg config.ResilienceConfig) repository.EmbeddingRepository {
+	p := newPolicy(cfg)
+	members := make([]*member, len(providers))
+	for i := range providers {
+		members[i] = &member{
+			name:    names[i],
+			breaker: newCircuitBreaker(p.breakerThreshold, p.breakerCooldown),
+		}
+	}
+	return &resilientEmbeddingRepository{providers: providers, members: members, policy: p}
+}
+
+func (r *resilientEmbeddingRepository) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	var embeddings [][]float32
+	err := fallover(ctx, r.policy, r.members, func(idx int) error {
+		var err error
+		embeddings, err = r.providers[idx].CreateEmbeddings(ctx, texts, taskType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("all embedding providers failed: %w", err)
+	}
+	return embeddings, nil
+}
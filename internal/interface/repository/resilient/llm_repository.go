@@ -0,0 +1,99 @@
+// OpenRAGLecture/internal/interface/repository/resilient/llm_repository.go
+package resilient
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+type resilientLLMRepository struct {
+	providers []repository.LLMRepository
+	members   []*member
+	policy    policy
+}
+
+// NewLLMRepository creates an LLMRepository that sends every request to the first of the
+// given providers whose circuit breaker is closed, retries transient failures and falls
+// over to the next provider when one fails. The model that answered is reported in the
+// GenerationInfo of the request context.
+func NewLLMRepository(providers []repository.LLMRepository, cfg config.ResilienceConfig) repository.LLMRepository {
+	p := newPolicy(cfg)
+	members := make([]*member, len(providers))
+	for i, provider := range providers {
+		members[i] = &member{
+			name:    provider.ModelName(),
+			breaker: newCircuitBreaker(p.breakerThreshold, p.breakerCooldown),
+		}
+	}
+	return &resilientLLMRepository{providers: providers, members: members, policy: p}
+}
+
+func (r *resilientLLMRepository) GenerateContent(ctx context.Context, params repository.GenerateContentParams) (string, error) {
+	var response string
+	err := fallover(ctx, r.policy, r.members, func(idx int) error {
+		var err error
+		response, err = r.providers[idx].GenerateContent(ctx, params)
+		if err == nil {
+			r.report(ctx, idx)
+		}
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("all LLM providers failed: %w", err)
+	}
+	return response, nil
+}
+
+// GenerateContentStream falls over like GenerateContent, but only as long as nothing
+// has been written: once a provider has started streaming, its errors are final.
+func (r *resilientLLMRepository) GenerateContentStream(ctx context.Context, params repository.GenerateContentParams, writer io.Writer) error {
+	tracked := &trackingWriter{w: writer}
+	var streamErr error
+	err := fallover(ctx, r.policy, r.members, func(idx int) error {
+		if err := r.providers[idx].GenerateContentStream(ctx, params, tracked); err != nil {
+			if tracked.written {
+				streamErr = err
+				return nil // Stop the chain; the error is returned below.
+			}
+			return err
+		}
+		r.report(ctx, idx)
+		return nil
+	})
+	if streamErr != nil {
+		return streamErr
+	}
+	if err != nil {
+		return fmt.Errorf("all LLM providers failed: %w", err)
+	}
+	return nil
+}
+
+// ModelName returns the model of the primary provider.
+func (r *resilientLLMRepository) ModelName() string {
+	return r.providers[0].ModelName()
+}
+
+// report records the provider that answered in the GenerationInfo of the context.
+func (r *resilientLLMRepository) report(ctx context.Context, idx int) {
+	if info := repository.GenerationInfoFromContext(ctx); info != nil {
+		info.Model = r.providers[idx].ModelName()
+	}
+}
+
+// trackingWriter remembers whether anything was written through it.
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.written = true
+	}
+	return t.w.Write(p)
+}
@@ -0,0 +1,163 @@
+// OpenRAGLecture/internal/interface/repository/resilient/retry.go
+package resilient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"google.golang.org/genai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+// ErrCircuitOpen is returned for a provider whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// policy is the retry and circuit breaker configuration shared by the providers of a chain.
+type policy struct {
+	maxRetries       int
+	initialBackoff   time.Duration
+	maxBackoff       time.Duration
+	breakerThreshold int
+	breakerCooldown  time.Duration
+}
+
+func newPolicy(cfg config.ResilienceConfig) policy {
+	return policy{
+		maxRetries:       max(cfg.MaxRetries, 0),
+		initialBackoff:   time.Duration(cfg.InitialBackoffMillis) * time.Millisecond,
+		maxBackoff:       time.Duration(cfg.MaxBackoffMillis) * time.Millisecond,
+		breakerThreshold: cfg.BreakerFailureThreshold,
+		breakerCooldown:  time.Duration(cfg.BreakerCooldownSeconds) * time.Second,
+	}
+}
+
+// backoff returns the delay before the given retry (0-based): exponential growth capped
+// at maxBackoff, with full jitter so that concurrent callers do not retry in lockstep.
+func (p policy) backoff(retry int) time.Duration {
+	if p.initialBackoff <= 0 {
+		return 0
+	}
+	limit := p.initialBackoff << min(retry, 30)
+	if p.maxBackoff > 0 && (limit > p.maxBackoff || limit <= 0) {
+		limit = p.maxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(limit) + 1))
+}
+
+// member is a provider of a chain together with its circuit breaker.
+type member struct {
+	name    string
+	breaker *circuitBreaker
+}
+
+// call runs fn against a single provider, retrying transient failures with backoff.
+// It records the outcome in the provider's circuit breaker.
+func (p policy) call(ctx context.Context, m *member, fn func() error) error {
+	if !m.breaker.allow() {
+		return ErrCircuitOpen
+	}
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil {
+			m.breaker.success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err // The caller gave up; this says nothing about the provider.
+		}
+		if !isRetryable(err) {
+			// The request itself was rejected; the provider is healthy.
+			m.breaker.success()
+			return err
+		}
+		if attempt == p.maxRetries {
+			break
+		}
+		log.Printf("WARN: %s failed, retrying: %v", m.name, err)
+		if sleepErr := sleep(ctx, p.backoff(attempt)); sleepErr != nil {
+			return err
+		}
+	}
+	m.breaker.failure()
+	return err
+}
+
+// fallover tries the providers in order until one succeeds. Canceled requests are
+// not passed on to the next provider.
+func fallover(ctx context.Context, p policy, members []*member, fn func(idx int) error) error {
+	var errs []error
+	for idx, m := range members {
+		err := p.call(ctx, m, func() error { return fn(idx) })
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.name, err))
+		if ctx.Err() != nil {
+			break
+		}
+		if idx < len(members)-1 {
+			log.Printf("WARN: %s failed, falling over to %s: %v", m.name, members[idx+1].name, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isRetryable reports whether an error is a transient failure worth retrying: a gRPC
+// or HTTP status signalling overload, rate limiting or an unavailable server, or a
+// network timeout.
+func isRetryable(err error) bool {
+	var statusErr *appErrors.StatusError
+	if errors.As(err, &statusErr) {
+		return retryableHTTPStatus(statusErr.StatusCode)
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableHTTPStatus(apiErr.Code)
+	}
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) {
+		return retryableHTTPStatus(apiErrPtr.Code)
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded, codes.Aborted, codes.Internal:
+			return true
+		}
+		return false
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+func retryableHTTPStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// sleep waits for d unless the context is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
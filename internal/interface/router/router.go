@@ -15,6 +15,8 @@ func NewRouter(
 	qaHandler *handler.QAHandler,
 	fileHandler *handler.FileHandler,
 	courseHandler *handler.CourseHandler,
+	conversationHandler *handler.ConversationHandler,
+	adminHandler *handler.AdminHandler,
 	// ★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★
 	// 修正点: 引数にHealthHandlerを追加
 	// ★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★
@@ -41,16 +43,35 @@ func NewRouter(
 		qaRoutes := apiRoutes.Group("/qa")
 		{
 			qaRoutes.POST("/ask", qaHandler.Ask)
+			qaRoutes.POST("/ask/stream", qaHandler.AskStream)
+		}
+
+		conversationRoutes := apiRoutes.Group("/conversations")
+		{
+			conversationRoutes.GET("", conversationHandler.List)
+			conversationRoutes.GET("/:session_id", conversationHandler.Get)
+			conversationRoutes.DELETE("/:session_id", conversationHandler.Delete)
 		}
 
 		fileRoutes := apiRoutes.Group("/files")
 		{
 			fileRoutes.POST("/upload", fileHandler.Upload)
+			fileRoutes.DELETE("/:document_id", fileHandler.Delete)
 		}
 
 		courseRoutes := apiRoutes.Group("/courses")
 		{
 			courseRoutes.POST("/:course_id/enrollments", courseHandler.Enroll)
+			courseRoutes.GET("/:course_id/settings", courseHandler.GetSettings)
+			courseRoutes.PUT("/:course_id/settings", courseHandler.UpdateSettings)
+		}
+
+		adminRoutes := apiRoutes.Group("/admin")
+		{
+			adminRoutes.DELETE("/courses/:course_id/answer-cache", adminHandler.InvalidateAnswerCache)
+			adminRoutes.PUT("/courses/:course_id/vectors", adminHandler.CreateVectorSpace)
+			adminRoutes.GET("/courses/:course_id/vectors", adminHandler.VectorSpaceStats)
+			adminRoutes.DELETE("/courses/:course_id/vectors", adminHandler.DropVectorSpace)
 		}
 	}
 
@@ -0,0 +1,75 @@
+// internal/tests/batch/cached_embedder_test.go
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/batch/task"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+)
+
+func TestCachedEmbedder_EmbedsOnlyMisses(t *testing.T) {
+	ctx := context.Background()
+	cache := new(mocks.MockEmbeddingCacheRepository)
+	embeddingRepo := new(mocks.MockEmbeddingRepository)
+	embedder := task.NewCachedEmbedder(cache, embeddingRepo, "model-v1")
+
+	texts := []string{"cached", "new", "new", "unhashed"}
+	hashes := []string{"h-cached", "h-new", "h-new", ""}
+	cache.On("FindByHashes", ctx, []string{"h-cached", "h-new", "h-new"}).
+		Return(map[string][]float32{"h-cached": {1}}, nil).Once()
+	// Duplicates are embedded once.
+	embeddingRepo.On("CreateEmbeddings", ctx, []string{"new", "unhashed"}, "RETRIEVAL_DOCUMENT").
+		Return([][]float32{{2}, {3}}, nil).Once()
+	var saved []*model.EmbeddingCacheEntry
+	cache.On("Save", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).([]*model.EmbeddingCacheEntry) }).
+		Return(nil).Once()
+
+	vectors, err := embedder.Embed(ctx, texts, hashes)
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}, {2}, {3}}, vectors)
+	require.Len(t, saved, 1)
+	assert.Equal(t, "h-new", saved[0].VectorHash)
+	assert.Equal(t, "model-v1", saved[0].Model)
+	assert.Equal(t, []float32{2}, saved[0].Vector)
+	hits, misses := embedder.Stats()
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 3, misses)
+}
+
+func TestCachedEmbedder_CacheFailureEmbedsEverything(t *testing.T) {
+	ctx := context.Background()
+	cache := new(mocks.MockEmbeddingCacheRepository)
+	embeddingRepo := new(mocks.MockEmbeddingRepository)
+	embedder := task.NewCachedEmbedder(cache, embeddingRepo, "model-v1")
+
+	cache.On("FindByHashes", ctx, []string{"a", "b"}).Return(nil, errors.New("db down")).Once()
+	embeddingRepo.On("CreateEmbeddings", ctx, []string{"text a", "text b"}, "RETRIEVAL_DOCUMENT").
+		Return([][]float32{{1}, {2}}, nil).Once()
+	cache.On("Save", ctx, mock.Anything).Return(errors.New("db down")).Once()
+
+	vectors, err := embedder.Embed(ctx, []string{"text a", "text b"}, []string{"a", "b"})
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vectors)
+}
+
+func TestComputeVectorHash_NormalizesText(t *testing.T) {
+	assert.Equal(t,
+		model.ComputeVectorHash("model-v1", 768, "B-trees  keep\nkeys sorted. "),
+		model.ComputeVectorHash("model-v1", 768, "B-trees keep keys sorted."))
+	// The composed and decomposed forms of "é" share a hash.
+	assert.Equal(t, model.ComputeVectorHash("model-v1", 768, "caf\u00e9"), model.ComputeVectorHash("model-v1", 768, "cafe\u0301"))
+	assert.NotEqual(t, model.ComputeVectorHash("model-v1", 768, "text"), model.ComputeVectorHash("model-v2", 768, "text"))
+	// Embeddings shortened to other dimensions are cached apart.
+	assert.NotEqual(t, model.ComputeVectorHash("model-v1", 768, "text"), model.ComputeVectorHash("model-v1", 256, "text"))
+}
@@ -0,0 +1,96 @@
+// internal/tests/batch/vector_backup_test.go
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/batch/task"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/memory"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+func TestVectorPoints_RoundTrip(t *testing.T) {
+	points := []model.ChunkVector{
+		{
+			Chunk: model.Chunk{
+				Base: model.Base{ID: 1}, DocumentID: 2, CourseID: 3, SemesterID: 4, Text: "二分探索木", EmbeddingID: "a", VectorHash: "h-a",
+				Page:     model.Page{PageNumber: 5, Language: "ja"},
+				Document: model.Document{DocType: model.DocTypeSlides, Version: 2},
+			},
+			Vector: []float32{0.5, -1},
+		},
+		{Chunk: model.Chunk{Base: model.Base{ID: 6}, EmbeddingID: "b"}, Vector: []float32{1, 0}},
+		{Chunk: model.Chunk{Base: model.Base{ID: 7}, EmbeddingID: "c"}, Vector: []float32{0, 1}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, task.WriteVectorPoints(&buf, points))
+
+	var batches [][]model.ChunkVector
+	err := task.ReadVectorPoints(&buf, 2, func(batch []model.ChunkVector) error {
+		batches = append(batches, batch)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+	assert.Equal(t, points[:2], batches[0])
+	assert.Equal(t, points[2:], batches[1])
+}
+
+func TestVectorPoints_ReadRejectsInvalidLine(t *testing.T) {
+	err := task.ReadVectorPoints(bytes.NewBufferString("{\"chunk_id\":1}\nnot json\n"), 10, func([]model.ChunkVector) error { return nil })
+
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestSnapshotVectorsTask_WritesJSONLBackupWithManifest(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo, err := memory.NewMemoryVectorRepository(config.MemoryVectorConfig{}, t.Name(), 2)
+	require.NoError(t, err)
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, CourseID: 10, Text: "east", EmbeddingID: "a"},
+		{Base: model.Base{ID: 2}, CourseID: 10, Text: "north", EmbeddingID: "b"},
+	}
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, [][]float32{{1, 0}, {0, 1}}))
+	index := &model.EmbeddingIndex{Version: "fake_model_2", Model: "model", Dimensions: 2}
+	backupDir := t.TempDir()
+
+	err = task.NewSnapshotVectorsTask(index, vectorRepo, nil, backupDir).Run(ctx)
+	require.NoError(t, err)
+
+	dirs, err := os.ReadDir(backupDir)
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	dir := filepath.Join(backupDir, dirs[0].Name())
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	require.NoError(t, err)
+	var backup model.VectorBackup
+	require.NoError(t, json.Unmarshal(data, &backup))
+	assert.Equal(t, model.VectorBackupJSONL, backup.Format)
+	assert.Equal(t, "fake_model_2", backup.IndexVersion)
+	assert.Equal(t, 2, backup.Points)
+	require.Len(t, backup.Files, 1)
+	assert.Len(t, backup.Files[0].Checksum, 64)
+
+	file, err := os.Open(filepath.Join(dir, backup.Files[0].File))
+	require.NoError(t, err)
+	defer file.Close()
+	var restored []model.ChunkVector
+	require.NoError(t, task.ReadVectorPoints(file, 10, func(points []model.ChunkVector) error {
+		restored = append(restored, points...)
+		return nil
+	}))
+	require.Len(t, restored, 2)
+	assert.Equal(t, "north", restored[1].Chunk.Text)
+	assert.Equal(t, []float32{0, 1}, restored[1].Vector)
+}
@@ -0,0 +1,113 @@
+// internal/tests/handler/admin_handler_test.go
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func TestAdminHandler_InvalidateAnswerCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockAdminUsecase := new(mocks.MockAdminUsecase)
+	adminHandler := handler.NewAdminHandler(mockAdminUsecase)
+
+	const testUserID = uint64(1)
+	const testCourseID = uint64(101)
+
+	router := gin.New()
+	router.DELETE("/admin/courses/:course_id/answer-cache", authMiddlewareMock(testUserID), adminHandler.InvalidateAnswerCache)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", err: nil, wantStatus: http.StatusNoContent},
+		{name: "Failure_NotAdmin", err: appErrors.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "Failure_CourseNotFound", err: appErrors.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "Failure_Internal", err: appErrors.ErrInternalServerError, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAdminUsecase.On("InvalidateAnswerCache", mock.Anything, testUserID, testCourseID).Return(tc.err).Once()
+
+			req, _ := http.NewRequest(http.MethodDelete, "/admin/courses/101/answer-cache", nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			mockAdminUsecase.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Failure_InvalidCourseID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/admin/courses/invalid/answer-cache", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockAdminUsecase.AssertNumberOfCalls(t, "InvalidateAnswerCache", len(tests))
+	})
+}
+
+func TestAdminHandler_VectorSpace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockAdminUsecase := new(mocks.MockAdminUsecase)
+	adminHandler := handler.NewAdminHandler(mockAdminUsecase)
+
+	const testUserID = uint64(1)
+	const testCourseID = uint64(101)
+
+	router := gin.New()
+	router.PUT("/admin/courses/:course_id/vectors", authMiddlewareMock(testUserID), adminHandler.CreateVectorSpace)
+	router.GET("/admin/courses/:course_id/vectors", authMiddlewareMock(testUserID), adminHandler.VectorSpaceStats)
+	router.DELETE("/admin/courses/:course_id/vectors", authMiddlewareMock(testUserID), adminHandler.DropVectorSpace)
+
+	t.Run("Create_Success", func(t *testing.T) {
+		mockAdminUsecase.On("CreateVectorSpace", mock.Anything, testUserID, testCourseID).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPut, "/admin/courses/101/vectors", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("Stats_Success", func(t *testing.T) {
+		stats := &model.VectorSpaceStats{CourseID: testCourseID, Tenancy: model.TenancyShardKey, Collection: "lecture_chunks", ShardKey: "101", Exists: true, Points: 7}
+		mockAdminUsecase.On("VectorSpaceStats", mock.Anything, testUserID, testCourseID).Return(stats, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/admin/courses/101/vectors", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var body model.VectorSpaceStats
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, *stats, body)
+	})
+
+	t.Run("Drop_Failure_NotAdmin", func(t *testing.T) {
+		mockAdminUsecase.On("DropVectorSpace", mock.Anything, testUserID, testCourseID).Return(appErrors.ErrForbidden).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/admin/courses/101/vectors", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockAdminUsecase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,98 @@
+// internal/tests/handler/conversation_handler_test.go
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func TestConversationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockConversationUsecase := new(mocks.MockConversationUsecase)
+	conversationHandler := handler.NewConversationHandler(mockConversationUsecase)
+
+	const testUserID = uint64(1)
+	const sessionID = "6f1c2a5e-1111-4222-8333-944455556666"
+
+	router := gin.New()
+	router.GET("/conversations", authMiddlewareMock(testUserID), conversationHandler.List)
+	router.GET("/conversations/:session_id", authMiddlewareMock(testUserID), conversationHandler.Get)
+	router.DELETE("/conversations/:session_id", authMiddlewareMock(testUserID), conversationHandler.Delete)
+
+	t.Run("List_Success_FilteredByCourse", func(t *testing.T) {
+		conversations := []output.ConversationOutput{{SessionID: sessionID, CourseID: 101, Title: "What is RAG?"}}
+		mockConversationUsecase.On("List", mock.Anything, testUserID, uint64(101)).Return(conversations, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/conversations?course_id=101", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var body struct {
+			Conversations []output.ConversationOutput `json:"conversations"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, conversations, body.Conversations)
+		mockConversationUsecase.AssertExpectations(t)
+	})
+
+	t.Run("List_Failure_InvalidCourseID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/conversations?course_id=abc", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Get_Success", func(t *testing.T) {
+		conversation := &output.ConversationOutput{
+			SessionID: sessionID,
+			Messages:  []output.MessageOutput{{Role: "user", Content: "What is RAG?"}},
+		}
+		mockConversationUsecase.On("Get", mock.Anything, testUserID, sessionID).Return(conversation, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/conversations/"+sessionID, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"content":"What is RAG?"`)
+		mockConversationUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Get_Failure_NotFound", func(t *testing.T) {
+		mockConversationUsecase.On("Get", mock.Anything, testUserID, "missing").Return(nil, appErrors.ErrConversationNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/conversations/missing", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Delete_Success", func(t *testing.T) {
+		mockConversationUsecase.On("Delete", mock.Anything, testUserID, sessionID).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/conversations/"+sessionID, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockConversationUsecase.AssertExpectations(t)
+	})
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
 	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
@@ -76,3 +78,59 @@ func TestCourseHandler_Enroll(t *testing.T) {
 		mockCourseUsecase.AssertNotCalled(t, "EnrollUser")
 	})
 }
+
+func TestCourseHandler_Settings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockCourseUsecase := new(mocks.MockCourseUsecase)
+	courseHandler := handler.NewCourseHandler(mockCourseUsecase)
+
+	const testUserID = uint64(7)
+	const testCourseID = uint64(101)
+
+	router := gin.New()
+	router.GET("/courses/:course_id/settings", authMiddlewareMock(testUserID), courseHandler.GetSettings)
+	router.PUT("/courses/:course_id/settings", authMiddlewareMock(testUserID), courseHandler.UpdateSettings)
+	url := fmt.Sprintf("/courses/%d/settings", testCourseID)
+
+	t.Run("Get_Success", func(t *testing.T) {
+		vectorTopK := 8
+		mockCourseUsecase.On("GetRetrievalSettings", mock.Anything, testUserID, testCourseID).
+			Return(&model.RetrievalOverrides{VectorTopK: &vectorTopK}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"retrieval":{"vector_top_k":8}}`, rr.Body.String())
+		mockCourseUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Update_Success", func(t *testing.T) {
+		mockCourseUsecase.On("UpdateRetrievalSettings", mock.Anything, testUserID, testCourseID, mock.MatchedBy(func(retrieval model.RetrievalOverrides) bool {
+			return retrieval.ContextSize != nil && *retrieval.ContextSize == 4 && retrieval.BM25TopK == nil
+		})).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPut, url, strings.NewReader(`{"retrieval":{"context_size":4}}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockCourseUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Update_Failure_NotInstructor", func(t *testing.T) {
+		mockCourseUsecase.On("UpdateRetrievalSettings", mock.Anything, testUserID, testCourseID, mock.Anything).Return(appErrors.ErrForbidden).Once()
+
+		req, _ := http.NewRequest(http.MethodPut, url, strings.NewReader(`{"retrieval":{}}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
 	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 )
 
 func TestFileHandler_Upload(t *testing.T) {
@@ -150,3 +151,41 @@ func TestFileHandler_Upload(t *testing.T) {
 		mockFileUsecase.AssertExpectations(t)
 	})
 }
+
+func TestFileHandler_Delete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const testUserID = uint64(1)
+
+	mockFileUsecase := new(mocks.MockFileUsecase)
+	fileHandler := handler.NewFileHandler(mockFileUsecase)
+	router := gin.New()
+	router.DELETE("/api/files/:document_id", authMiddlewareMock(testUserID), fileHandler.Delete)
+
+	testCases := []struct {
+		name       string
+		documentID string
+		err        error
+		wantStatus int
+	}{
+		{name: "Success", documentID: "5", wantStatus: http.StatusNoContent},
+		{name: "Failure_NotFound", documentID: "5", err: appErrors.ErrNotFound, wantStatus: http.StatusNotFound},
+		{name: "Failure_NotInstructor", documentID: "5", err: appErrors.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "Failure_Internal", documentID: "5", err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+		{name: "Failure_InvalidDocumentID", documentID: "abc", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.wantStatus != http.StatusBadRequest {
+				mockFileUsecase.On("Delete", mock.Anything, testUserID, uint64(5)).Return(tc.err).Once()
+			}
+
+			req, _ := http.NewRequest(http.MethodDelete, "/api/files/"+tc.documentID, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			mockFileUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +16,8 @@ import (
 	"github.com/takumi-1234/OpenRAGLecture/internal/interface/handler"
 	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 )
 
@@ -44,7 +47,7 @@ func TestQAHandler_Ask(t *testing.T) {
 
 		// Mock dependencies
 		mockEnrollmentRepo.On("IsEnrolled", mock.Anything, testUserID, testCourseID).Return(true, nil).Once()
-		expectedAnswer := "This is the answer from the LLM."
+		expectedAnswer := &output.AskOutput{Answer: "This is the answer from the LLM.", AnswerID: 42}
 
 		// We need to match the input struct with the UserID filled in.
 		expectedUsecaseInput := askInput
@@ -56,9 +59,10 @@ func TestQAHandler_Ask(t *testing.T) {
 
 		// Assert
 		assert.Equal(t, http.StatusOK, rr.Code)
-		var respBody map[string]string
+		var respBody output.AskOutput
 		_ = json.Unmarshal(rr.Body.Bytes(), &respBody)
-		assert.Equal(t, expectedAnswer, respBody["answer"])
+		assert.Equal(t, expectedAnswer.Answer, respBody.Answer)
+		assert.Equal(t, expectedAnswer.AnswerID, respBody.AnswerID)
 		mockEnrollmentRepo.AssertExpectations(t)
 		mockQAUsecase.AssertExpectations(t)
 	})
@@ -136,3 +140,104 @@ func TestQAHandler_Ask(t *testing.T) {
 		mockQAUsecase.AssertNotCalled(t, "Ask")
 	})
 }
+
+func TestQAHandler_AskStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const testUserID = uint64(1)
+	const testCourseID = uint64(101)
+
+	askInput := input.AskInput{
+		CourseID: testCourseID,
+		Query:    "What is RAG?",
+	}
+	expectedUsecaseInput := askInput
+	expectedUsecaseInput.UserID = testUserID
+
+	newRequest := func() *http.Request {
+		body, _ := json.Marshal(askInput)
+		req, _ := http.NewRequest(http.MethodPost, "/api/qa/ask/stream", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("Success_StreamsSourcesTokensAndDone", func(t *testing.T) {
+		// Arrange
+		mockQAUsecase := new(mocks.MockQAUsecase)
+		mockEnrollmentRepo := new(mocks.MockEnrollmentRepository)
+		qaHandler := handler.NewQAHandler(mockQAUsecase, mockEnrollmentRepo)
+		router := gin.New()
+		router.POST("/api/qa/ask/stream", authMiddlewareMock(testUserID), qaHandler.AskStream)
+		rr := httptest.NewRecorder()
+
+		mockEnrollmentRepo.On("IsEnrolled", mock.Anything, testUserID, testCourseID).Return(true, nil).Once()
+		mockQAUsecase.On("AskStream", mock.Anything, expectedUsecaseInput, mock.Anything).
+			Return(&output.AskOutput{Answer: "Retrieval-Augmented Generation [1]", AnswerID: 42, Citations: []output.CitationOutput{{Index: 1, ChunkID: 7}}}, nil).
+			Run(func(args mock.Arguments) {
+				writer := args.Get(2).(port.AskStreamWriter)
+				_ = writer.WriteSources([]output.CitationOutput{{Index: 1, ChunkID: 7, DocumentID: 3, DocumentTitle: "Lecture 1", PageNumber: 2, Score: 0.5, Snippet: "RAG stands for"}})
+				_, _ = writer.Write([]byte("Retrieval-Augmented "))
+				_, _ = writer.Write([]byte("Generation"))
+			}).Once()
+
+		// Act
+		router.ServeHTTP(rr, newRequest())
+
+		// Assert
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		body := rr.Body.String()
+		sourcesIdx := strings.Index(body, "event:sources")
+		tokenIdx := strings.Index(body, "event:token")
+		doneIdx := strings.Index(body, "event:done")
+		assert.True(t, sourcesIdx >= 0 && tokenIdx > sourcesIdx && doneIdx > tokenIdx, "events must arrive in order: %s", body)
+		assert.Contains(t, body, `"chunk_id":7`)
+		assert.Contains(t, body, `"text":"Retrieval-Augmented "`)
+		assert.Contains(t, body, `"answer_id":42`)
+		assert.Contains(t, body, `"document_title":"Lecture 1"`)
+		assert.Contains(t, body, `"citations":[{"index":1`)
+		assert.NotContains(t, body, "event:error")
+		mockEnrollmentRepo.AssertExpectations(t)
+		mockQAUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Failure_UsecaseErrorSendsErrorEvent", func(t *testing.T) {
+		// Arrange
+		mockQAUsecase := new(mocks.MockQAUsecase)
+		mockEnrollmentRepo := new(mocks.MockEnrollmentRepository)
+		qaHandler := handler.NewQAHandler(mockQAUsecase, mockEnrollmentRepo)
+		router := gin.New()
+		router.POST("/api/qa/ask/stream", authMiddlewareMock(testUserID), qaHandler.AskStream)
+		rr := httptest.NewRecorder()
+
+		mockEnrollmentRepo.On("IsEnrolled", mock.Anything, testUserID, testCourseID).Return(true, nil).Once()
+		mockQAUsecase.On("AskStream", mock.Anything, expectedUsecaseInput, mock.Anything).Return(nil, errors.New("llm unavailable")).Once()
+
+		// Act
+		router.ServeHTTP(rr, newRequest())
+
+		// Assert
+		assert.Contains(t, rr.Body.String(), "event:error")
+		assert.NotContains(t, rr.Body.String(), "event:done")
+		mockQAUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Failure_WhenNotEnrolled", func(t *testing.T) {
+		// Arrange
+		mockQAUsecase := new(mocks.MockQAUsecase)
+		mockEnrollmentRepo := new(mocks.MockEnrollmentRepository)
+		qaHandler := handler.NewQAHandler(mockQAUsecase, mockEnrollmentRepo)
+		router := gin.New()
+		router.POST("/api/qa/ask/stream", authMiddlewareMock(testUserID), qaHandler.AskStream)
+		rr := httptest.NewRecorder()
+
+		mockEnrollmentRepo.On("IsEnrolled", mock.Anything, testUserID, testCourseID).Return(false, nil).Once()
+
+		// Act
+		router.ServeHTTP(rr, newRequest())
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockQAUsecase.AssertNotCalled(t, "AskStream")
+	})
+}
@@ -4,12 +4,14 @@ package mocks
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
 )
 
 // ==============================================================================
@@ -55,6 +57,19 @@ func (m *MockCourseRepository) FindByID(ctx context.Context, id uint64) (*model.
 	return args.Get(0).(*model.Course), args.Error(1)
 }
 
+func (m *MockCourseRepository) FindSettings(ctx context.Context, courseID uint64) (*model.CourseSettings, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CourseSettings), args.Error(1)
+}
+
+func (m *MockCourseRepository) SaveSettings(ctx context.Context, settings *model.CourseSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
 // MockEnrollmentRepository is a mock of EnrollmentRepository
 type MockEnrollmentRepository struct {
 	mock.Mock
@@ -83,19 +98,48 @@ func (m *MockDocumentRepository) FindByID(ctx context.Context, id uint64) (*mode
 	return args.Get(0).(*model.Document), args.Error(1)
 }
 
+func (m *MockDocumentRepository) FindLatestVersion(ctx context.Context, courseID uint64, title string) (*model.Document, error) {
+	args := m.Called(ctx, courseID, title)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Document), args.Error(1)
+}
+
 func (m *MockDocumentRepository) Create(ctx context.Context, doc *model.Document) error {
 	args := m.Called(ctx, doc)
 	return args.Error(0)
 }
 
-func (m *MockDocumentRepository) FullTextSearch(ctx context.Context, query string, courseID uint64, limit int) ([]model.RetrievedChunk, error) {
-	args := m.Called(ctx, query, courseID, limit)
+func (m *MockDocumentRepository) Delete(ctx context.Context, id uint64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDocumentRepository) FullTextSearch(ctx context.Context, query string, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
+	args := m.Called(ctx, query, filter, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]model.RetrievedChunk), args.Error(1)
 }
 
+func (m *MockDocumentRepository) FindChunksByIDs(ctx context.Context, ids []uint64) ([]*model.Chunk, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Chunk), args.Error(1)
+}
+
+func (m *MockDocumentRepository) FindChunksByIndexRange(ctx context.Context, documentID uint64, fromIndex, toIndex int) ([]*model.Chunk, error) {
+	args := m.Called(ctx, documentID, fromIndex, toIndex)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Chunk), args.Error(1)
+}
+
 // MockEmbeddingRepository is a mock of EmbeddingRepository
 type MockEmbeddingRepository struct {
 	mock.Mock
@@ -119,14 +163,73 @@ func (m *MockVectorRepository) Upsert(ctx context.Context, chunks []*model.Chunk
 	return args.Error(0)
 }
 
-func (m *MockVectorRepository) Search(ctx context.Context, queryVector []float32, courseID uint64, limit int) ([]model.RetrievedChunk, error) {
-	args := m.Called(ctx, queryVector, courseID, limit)
+func (m *MockVectorRepository) Search(ctx context.Context, queryVector []float32, filter model.SearchFilter, limit int) ([]model.RetrievedChunk, error) {
+	args := m.Called(ctx, queryVector, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RetrievedChunk), args.Error(1)
+}
+
+func (m *MockVectorRepository) GetVectors(ctx context.Context, embeddingIDs []string) ([]model.ChunkVector, error) {
+	args := m.Called(ctx, embeddingIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ChunkVector), args.Error(1)
+}
+
+func (m *MockVectorRepository) DeleteByIDs(ctx context.Context, embeddingIDs []string) error {
+	args := m.Called(ctx, embeddingIDs)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) HybridSearch(ctx context.Context, query model.HybridQuery, filter model.SearchFilter) ([]model.RetrievedChunk, error) {
+	args := m.Called(ctx, query, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]model.RetrievedChunk), args.Error(1)
 }
 
+func (m *MockVectorRepository) DeleteByDocument(ctx context.Context, documentID uint64) error {
+	args := m.Called(ctx, documentID)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) ReplaceDocuments(ctx context.Context, oldDocumentIDs []uint64, chunks []*model.Chunk, vectors [][]float32) error {
+	args := m.Called(ctx, oldDocumentIDs, chunks, vectors)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) CreateCourseSpace(ctx context.Context, courseID uint64) error {
+	args := m.Called(ctx, courseID)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) DropCourseSpace(ctx context.Context, courseID uint64) error {
+	args := m.Called(ctx, courseID)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) CourseSpaceStats(ctx context.Context, courseID uint64) (*model.VectorSpaceStats, error) {
+	args := m.Called(ctx, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.VectorSpaceStats), args.Error(1)
+}
+
+func (m *MockVectorRepository) Scan(ctx context.Context, batchSize int, fn func(points []model.ChunkVector) error) error {
+	args := m.Called(ctx, batchSize, fn)
+	return args.Error(0)
+}
+
+func (m *MockVectorRepository) Count(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockVectorRepository) RecreateCollection(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -152,6 +255,229 @@ func (m *MockLLMRepository) GenerateContentStream(ctx context.Context, params re
 	return args.Error(0)
 }
 
+func (m *MockLLMRepository) ModelName() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+// MockReranker is a mock of Reranker
+type MockReranker struct {
+	mock.Mock
+}
+
+func (m *MockReranker) Rerank(ctx context.Context, query string, candidates []model.RetrievedChunk, topN int) ([]model.RetrievedChunk, error) {
+	args := m.Called(ctx, query, candidates, topN)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RetrievedChunk), args.Error(1)
+}
+
+// MockQALogRepository is a mock of QALogRepository
+type MockQALogRepository struct {
+	mock.Mock
+}
+
+func (m *MockQALogRepository) CreateQuestion(ctx context.Context, question *model.Question) error {
+	args := m.Called(ctx, question)
+	return args.Error(0)
+}
+
+func (m *MockQALogRepository) CreateAnswer(ctx context.Context, answer *model.Answer) error {
+	args := m.Called(ctx, answer)
+	return args.Error(0)
+}
+
+func (m *MockQALogRepository) CreateAnswerSources(ctx context.Context, sources []*model.AnswerSource) error {
+	args := m.Called(ctx, sources)
+	return args.Error(0)
+}
+
+// MockConversationRepository is a mock of ConversationRepository
+type MockConversationRepository struct {
+	mock.Mock
+}
+
+func (m *MockConversationRepository) Create(ctx context.Context, conversation *model.Conversation) error {
+	args := m.Called(ctx, conversation)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) FindBySessionID(ctx context.Context, sessionID string) (*model.Conversation, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) ListByUser(ctx context.Context, userID, courseID uint64) ([]*model.Conversation, error) {
+	args := m.Called(ctx, userID, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) Delete(ctx context.Context, conversationID uint64) error {
+	args := m.Called(ctx, conversationID)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) AddMessages(ctx context.Context, conversationID uint64, messages []*model.ConversationMessage) error {
+	args := m.Called(ctx, conversationID, messages)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) ListMessages(ctx context.Context, conversationID uint64, limit int) ([]*model.ConversationMessage, error) {
+	args := m.Called(ctx, conversationID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ConversationMessage), args.Error(1)
+}
+
+// MockCacheRepository is a mock of CacheRepository
+type MockCacheRepository struct {
+	mock.Mock
+}
+
+func (m *MockCacheRepository) Get(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCacheRepository) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	args := m.Called(ctx, key, value, expiration)
+	return args.Error(0)
+}
+
+func (m *MockCacheRepository) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockCacheRepository) Incr(ctx context.Context, key string) (int64, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockSemanticCacheRepository is a mock of SemanticCacheRepository
+type MockSemanticCacheRepository struct {
+	mock.Mock
+}
+
+func (m *MockSemanticCacheRepository) FindNearest(ctx context.Context, courseID uint64, corpusVersion string, vector []float32, notBefore time.Time) (*model.SemanticCacheEntry, float32, error) {
+	args := m.Called(ctx, courseID, corpusVersion, vector, notBefore)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).(*model.SemanticCacheEntry), args.Get(1).(float32), args.Error(2)
+}
+
+func (m *MockSemanticCacheRepository) Save(ctx context.Context, entry *model.SemanticCacheEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockSemanticCacheRepository) DeleteByCourse(ctx context.Context, courseID uint64) error {
+	args := m.Called(ctx, courseID)
+	return args.Error(0)
+}
+
+func (m *MockSemanticCacheRepository) EnsureCollectionExists(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+// MockSearchIndexProvider is a mock of SearchIndexProvider
+type MockSearchIndexProvider struct {
+	mock.Mock
+}
+
+// NewSearchIndexProvider returns a MockSearchIndexProvider that always resolves the
+// index of the given repositories, which supports hybrid search. answerCache may be nil.
+func NewSearchIndexProvider(embeddingRepo repository.EmbeddingRepository, vectorRepo repository.VectorRepository, answerCache repository.SemanticCacheRepository) *MockSearchIndexProvider {
+	m := new(MockSearchIndexProvider)
+	m.On("ActiveSearchIndex", mock.Anything).Return(&repository.SearchIndex{
+		EmbeddingRepo: embeddingRepo,
+		VectorRepo:    vectorRepo,
+		AnswerCache:   answerCache,
+		HybridSearch:  true,
+	}, nil)
+	return m
+}
+
+func (m *MockSearchIndexProvider) ActiveSearchIndex(ctx context.Context) (*repository.SearchIndex, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.SearchIndex), args.Error(1)
+}
+
+// MockEmbeddingIndexRepository is a mock of EmbeddingIndexRepository
+type MockEmbeddingIndexRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmbeddingIndexRepository) Create(ctx context.Context, index *model.EmbeddingIndex) error {
+	args := m.Called(ctx, index)
+	return args.Error(0)
+}
+
+func (m *MockEmbeddingIndexRepository) FindByVersion(ctx context.Context, version string) (*model.EmbeddingIndex, error) {
+	args := m.Called(ctx, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmbeddingIndex), args.Error(1)
+}
+
+func (m *MockEmbeddingIndexRepository) FindActive(ctx context.Context) (*model.EmbeddingIndex, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmbeddingIndex), args.Error(1)
+}
+
+func (m *MockEmbeddingIndexRepository) List(ctx context.Context) ([]*model.EmbeddingIndex, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.EmbeddingIndex), args.Error(1)
+}
+
+func (m *MockEmbeddingIndexRepository) Update(ctx context.Context, index *model.EmbeddingIndex) error {
+	args := m.Called(ctx, index)
+	return args.Error(0)
+}
+
+func (m *MockEmbeddingIndexRepository) Activate(ctx context.Context, version string) error {
+	args := m.Called(ctx, version)
+	return args.Error(0)
+}
+
+// MockEmbeddingCacheRepository is a mock of EmbeddingCacheRepository
+type MockEmbeddingCacheRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmbeddingCacheRepository) FindByHashes(ctx context.Context, hashes []string) (map[string][]float32, error) {
+	args := m.Called(ctx, hashes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]float32), args.Error(1)
+}
+
+func (m *MockEmbeddingCacheRepository) Save(ctx context.Context, entries []*model.EmbeddingCacheEntry) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
 // MockFileStorage is a mock of FileStorage
 type MockFileStorage struct {
 	mock.Mock
@@ -207,6 +533,19 @@ func (m *MockCourseUsecase) EnrollUser(ctx context.Context, userID, courseID uin
 	return args.Error(0)
 }
 
+func (m *MockCourseUsecase) GetRetrievalSettings(ctx context.Context, userID, courseID uint64) (*model.RetrievalOverrides, error) {
+	args := m.Called(ctx, userID, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RetrievalOverrides), args.Error(1)
+}
+
+func (m *MockCourseUsecase) UpdateRetrievalSettings(ctx context.Context, userID, courseID uint64, retrieval model.RetrievalOverrides) error {
+	args := m.Called(ctx, userID, courseID, retrieval)
+	return args.Error(0)
+}
+
 // MockFileUsecase is a mock of FileUsecase
 type MockFileUsecase struct {
 	mock.Mock
@@ -228,17 +567,82 @@ func (m *MockFileUsecase) Download(ctx context.Context, documentID uint64) ([]by
 	return args.Get(0).([]byte), args.Get(1).(*model.Document), args.Error(2)
 }
 
+func (m *MockFileUsecase) Delete(ctx context.Context, userID, documentID uint64) error {
+	args := m.Called(ctx, userID, documentID)
+	return args.Error(0)
+}
+
 // MockQAUsecase is a mock of QAUsecase
 type MockQAUsecase struct {
 	mock.Mock
 }
 
-func (m *MockQAUsecase) Ask(ctx context.Context, in input.AskInput) (string, error) {
+func (m *MockQAUsecase) Ask(ctx context.Context, in input.AskInput) (*output.AskOutput, error) {
 	args := m.Called(ctx, in)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.AskOutput), args.Error(1)
 }
 
-func (m *MockQAUsecase) AskStream(ctx context.Context, in input.AskInput, writer io.Writer) error {
+func (m *MockQAUsecase) AskStream(ctx context.Context, in input.AskInput, writer port.AskStreamWriter) (*output.AskOutput, error) {
 	args := m.Called(ctx, in, writer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.AskOutput), args.Error(1)
+}
+
+// MockAdminUsecase is a mock of AdminUsecase
+type MockAdminUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAdminUsecase) InvalidateAnswerCache(ctx context.Context, userID, courseID uint64) error {
+	args := m.Called(ctx, userID, courseID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) CreateVectorSpace(ctx context.Context, userID, courseID uint64) error {
+	args := m.Called(ctx, userID, courseID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) DropVectorSpace(ctx context.Context, userID, courseID uint64) error {
+	args := m.Called(ctx, userID, courseID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUsecase) VectorSpaceStats(ctx context.Context, userID, courseID uint64) (*model.VectorSpaceStats, error) {
+	args := m.Called(ctx, userID, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.VectorSpaceStats), args.Error(1)
+}
+
+// MockConversationUsecase is a mock of ConversationUsecase
+type MockConversationUsecase struct {
+	mock.Mock
+}
+
+func (m *MockConversationUsecase) List(ctx context.Context, userID, courseID uint64) ([]output.ConversationOutput, error) {
+	args := m.Called(ctx, userID, courseID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]output.ConversationOutput), args.Error(1)
+}
+
+func (m *MockConversationUsecase) Get(ctx context.Context, userID uint64, sessionID string) (*output.ConversationOutput, error) {
+	args := m.Called(ctx, userID, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*output.ConversationOutput), args.Error(1)
+}
+
+func (m *MockConversationUsecase) Delete(ctx context.Context, userID uint64, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
 	return args.Error(0)
 }
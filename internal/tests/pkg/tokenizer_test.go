@@ -0,0 +1,46 @@
+// internal/tests/pkg/tokenizer_test.go
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
+)
+
+func TestTerms_SplitsWordsAndCJKBigrams(t *testing.T) {
+	assert.Equal(t, []string{"rag", "検索", "索拡", "拡張", "v2"}, tokenizer.Terms("RAG: 検索拡張 (v2)"))
+	assert.Equal(t, []string{"木", "trie"}, tokenizer.Terms("木 Trie"))
+	assert.Empty(t, tokenizer.Terms(" 、。!? "))
+}
+
+func TestBM25_WeightsSaturateAndMatchQueryTerms(t *testing.T) {
+	once := tokenizer.BM25Document("トライ木", 4)
+	twice := tokenizer.BM25Document("トライ木 トライ木", 4)
+	query := tokenizer.BM25Query("トライ木とは")
+
+	assert.Greater(t, twice.Dot(query), once.Dot(query))
+	// Term frequency saturates: doubling it adds less than doubling the weight.
+	assert.Less(t, twice.Dot(query), 2*once.Dot(query))
+	assert.Zero(t, tokenizer.BM25Document("binary search", 4).Dot(query))
+	assert.IsIncreasing(t, query.Indices)
+	// Against a longer average, the same document is short and weighs its terms more.
+	assert.Greater(t, tokenizer.BM25Document("トライ木", 16).Dot(query), once.Dot(query))
+}
+
+func TestBM25Scorer_WeightsTermsByInverseDocumentFrequency(t *testing.T) {
+	corpus := []tokenizer.TermCounts{
+		tokenizer.CountTerms("binary search tree"),
+		tokenizer.CountTerms("binary heap"),
+		tokenizer.CountTerms("binary trie"),
+	}
+	scorer := tokenizer.NewBM25Scorer("binary trie", corpus)
+
+	assert.Equal(t, 2, corpus[1].Length)
+	// "binary" occurs in every document and "trie" in one, so "trie" decides the match.
+	assert.Greater(t, tokenizer.IDF(3, 1), tokenizer.IDF(3, 3))
+	assert.Greater(t, scorer.Score(corpus[2]), 2*scorer.Score(corpus[1]))
+	assert.Zero(t, scorer.Score(tokenizer.CountTerms("hash table")))
+	assert.InDelta(t, 0.1335, tokenizer.IDF(3, 3), 1e-4)
+}
@@ -0,0 +1,111 @@
+// internal/tests/repository/batching_test.go
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/batching"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+// recordingEmbedder embeds every text as its number and records the size of every request.
+type recordingEmbedder struct {
+	mu       sync.Mutex
+	requests []int
+	limit    int // Requests with more texts are rejected with tooLarge
+	tooLarge error
+}
+
+func (e *recordingEmbedder) CreateEmbeddings(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	e.mu.Lock()
+	e.requests = append(e.requests, len(texts))
+	e.mu.Unlock()
+	if e.limit > 0 && len(texts) > e.limit {
+		return nil, e.tooLarge
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		n, err := strconv.Atoi(strings.Fields(text)[0])
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = []float32{float32(n)}
+	}
+	return vectors, nil
+}
+
+func numberedTexts(n int, words int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i) + strings.Repeat(" word", words)
+	}
+	return texts
+}
+
+func assertInOrder(t *testing.T, embeddings [][]float32, n int) {
+	t.Helper()
+	require.Len(t, embeddings, n)
+	for i, vector := range embeddings {
+		assert.Equal(t, []float32{float32(i)}, vector, "embedding %d", i)
+	}
+}
+
+func TestBatchingEmbeddingRepository_SplitsByInstancesAndTokens(t *testing.T) {
+	inner := &recordingEmbedder{}
+	embeddingRepo := batching.NewEmbeddingRepository(inner, config.EmbeddingBatchConfig{MaxInstances: 4, Concurrency: 3})
+
+	embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), numberedTexts(10, 1), "RETRIEVAL_DOCUMENT")
+
+	require.NoError(t, err)
+	assertInOrder(t, embeddings, 10)
+	assert.ElementsMatch(t, []int{4, 4, 2}, inner.requests)
+
+	// Every text is about 26 tokens, so 55 tokens fit two texts per request.
+	inner = &recordingEmbedder{}
+	embeddingRepo = batching.NewEmbeddingRepository(inner, config.EmbeddingBatchConfig{MaxInstances: 100, MaxTokens: 55})
+
+	embeddings, err = embeddingRepo.CreateEmbeddings(context.Background(), numberedTexts(5, 20), "RETRIEVAL_DOCUMENT")
+
+	require.NoError(t, err)
+	assertInOrder(t, embeddings, 5)
+	assert.Equal(t, []int{2, 2, 1}, inner.requests)
+}
+
+func TestBatchingEmbeddingRepository_SplitsRejectedRequests(t *testing.T) {
+	for _, tooLarge := range []error{
+		status.Error(codes.InvalidArgument, "too many instances"),
+		fmt.Errorf("request failed: %w", &appErrors.StatusError{StatusCode: http.StatusRequestEntityTooLarge}),
+	} {
+		inner := &recordingEmbedder{limit: 3, tooLarge: tooLarge}
+		embeddingRepo := batching.NewEmbeddingRepository(inner, config.EmbeddingBatchConfig{MaxInstances: 8})
+
+		embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), numberedTexts(8, 1), "RETRIEVAL_DOCUMENT")
+
+		require.NoError(t, err)
+		assertInOrder(t, embeddings, 8)
+		assert.Equal(t, []int{8, 4, 2, 2, 4, 2, 2}, inner.requests)
+	}
+}
+
+func TestBatchingEmbeddingRepository_ReturnsOtherErrors(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+	inner := &recordingEmbedder{limit: 1, tooLarge: unavailable}
+	embeddingRepo := batching.NewEmbeddingRepository(inner, config.EmbeddingBatchConfig{MaxInstances: 4})
+
+	_, err := embeddingRepo.CreateEmbeddings(context.Background(), numberedTexts(4, 1), "RETRIEVAL_DOCUMENT")
+
+	assert.ErrorIs(t, err, unavailable)
+	assert.Equal(t, []int{4}, inner.requests)
+}
@@ -0,0 +1,126 @@
+// internal/tests/repository/fake_test.go
+package repository_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/fake"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func TestFakeEmbeddingRepository_CreateEmbeddings(t *testing.T) {
+	embeddingRepo, err := fake.NewFakeEmbeddingRepository(64)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	embeddings, err := embeddingRepo.CreateEmbeddings(ctx, []string{
+		"B-trees keep their keys sorted.",
+		"Why are the keys of a B-tree sorted?",
+		"Hash tables use buckets.",
+	}, "RETRIEVAL_DOCUMENT")
+	require.NoError(t, err)
+	require.Len(t, embeddings, 3)
+
+	again, err := embeddingRepo.CreateEmbeddings(ctx, []string{"B-trees keep their keys sorted."}, "RETRIEVAL_QUERY")
+	require.NoError(t, err)
+	assert.Equal(t, embeddings[0], again[0], "embeddings must be deterministic")
+
+	for _, embedding := range embeddings {
+		assert.Len(t, embedding, 64)
+		assert.InDelta(t, 1, math.Sqrt(dot(embedding, embedding)), 1e-5)
+	}
+	assert.Greater(t, dot(embeddings[0], embeddings[1]), dot(embeddings[0], embeddings[2]),
+		"texts sharing words must be more similar")
+
+	_, err = fake.NewFakeEmbeddingRepository(0)
+	assert.Error(t, err)
+}
+
+func TestFakeLLMRepository(t *testing.T) {
+	ctx := context.Background()
+	params := repository.GenerateContentParams{
+		UserPrompt: "What is the test content?",
+		ContextChunks: []model.RetrievedChunk{
+			{Chunk: model.Chunk{Text: "The weather was fine. This PDF has some test content."}},
+			{Chunk: model.Chunk{Text: "Unrelated words only."}},
+		},
+	}
+
+	t.Run("Success_ExtractiveAnswerWithCitations", func(t *testing.T) {
+		llmRepo := fake.NewFakeLLMRepository(config.FakeLLMConfig{MaxSentences: 1})
+
+		answer, err := llmRepo.GenerateContent(ctx, params)
+
+		require.NoError(t, err)
+		assert.Equal(t, "According to the materials: This PDF has some test content. [1]", answer)
+	})
+
+	t.Run("Success_StreamMatchesAnswer", func(t *testing.T) {
+		llmRepo := fake.NewFakeLLMRepository(config.FakeLLMConfig{StreamChunkMillis: 1})
+		answer, err := llmRepo.GenerateContent(ctx, params)
+		require.NoError(t, err)
+
+		var sb strings.Builder
+		require.NoError(t, llmRepo.GenerateContentStream(ctx, params, &sb))
+		assert.Equal(t, answer, sb.String())
+	})
+
+	t.Run("Success_NoContextEchoesPrompt", func(t *testing.T) {
+		llmRepo := fake.NewFakeLLMRepository(config.FakeLLMConfig{})
+
+		answer, err := llmRepo.GenerateContent(ctx, repository.GenerateContentParams{UserPrompt: " What about heaps? "})
+
+		require.NoError(t, err)
+		assert.Equal(t, "What about heaps?", answer)
+	})
+
+	t.Run("Failure_CanceledDuringLatency", func(t *testing.T) {
+		llmRepo := fake.NewFakeLLMRepository(config.FakeLLMConfig{LatencyMillis: 10_000})
+		canceled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := llmRepo.GenerateContent(canceled, params)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestProvider_Fake(t *testing.T) {
+	cfg := config.Config{
+		VectorDB:  config.VectorDBConfig{Qdrant: config.QdrantConfig{VectorSize: 32}},
+		LLM:       config.LLMConfig{Provider: "fake"},
+		Embedding: config.EmbeddingConfig{Provider: "fake"},
+	}
+
+	llmRepo, err := provider.NewLLMRepository(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "fake-extractive", llmRepo.ModelName())
+
+	embeddingRepo, err := provider.NewEmbeddingRepository(cfg)
+	require.NoError(t, err)
+	embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), []string{"hello"}, "RETRIEVAL_QUERY")
+	require.NoError(t, err)
+	assert.Len(t, embeddings[0], 32, "the dimension defaults to the vector size")
+	assert.NoError(t, provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo))
+	assert.Equal(t, "fake-bow-32", provider.EmbeddingModelName(cfg))
+
+	cfg.Embedding.Fake.Dimensions = 16
+	assert.Error(t, provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo))
+}
@@ -0,0 +1,93 @@
+// internal/tests/repository/flat_index_test.go
+package repository_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/flatindex"
+)
+
+func TestFlatIndex_PackVectorRoundTrip(t *testing.T) {
+	vector := []float32{0, 1, -2.5, 3.14159, math.MaxFloat32, -math.SmallestNonzeroFloat32}
+
+	packed := flatindex.PackVector(vector)
+
+	assert.Len(t, packed, 4*len(vector))
+	assert.Equal(t, []byte{0, 0, 0x80, 0x3f}, packed[4:8]) // 1.0 in little-endian
+	assert.Equal(t, vector, flatindex.UnpackVector(packed))
+	assert.Empty(t, flatindex.UnpackVector(flatindex.PackVector(nil)))
+}
+
+func TestFlatIndex_SearchRanksFilteredEntriesByCosine(t *testing.T) {
+	entry := func(id, documentID uint64, docType model.DocType, vector []float32) flatindex.Entry {
+		chunk := model.Chunk{Base: model.Base{ID: id}, DocumentID: documentID, Document: model.Document{DocType: docType}}
+		return flatindex.NewEntry(chunk, vector)
+	}
+	entries := []flatindex.Entry{
+		entry(1, 1, model.DocTypeSlides, []float32{1, 1}),
+		entry(2, 1, model.DocTypeSlides, []float32{0, 2}),
+		entry(3, 2, model.DocTypeNotes, []float32{3, 0}),
+		entry(4, 2, model.DocTypeSlides, []float32{2, 0}),
+		entry(5, 3, model.DocTypeSlides, []float32{0, 0}),
+	}
+
+	results := flatindex.Search(entries, []float32{1, 0}, model.SearchFilter{CourseID: 10, DocType: model.DocTypeSlides}, 3)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, uint64(4), results[0].Chunk.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	assert.Equal(t, uint64(10), results[0].Chunk.CourseID)
+	assert.Equal(t, uint64(1), results[1].Chunk.ID)
+	assert.InDelta(t, 0.7071, results[1].Score, 1e-4)
+	// The orthogonal and zero vectors both score 0; the lower chunk ID wins.
+	assert.Equal(t, uint64(2), results[2].Chunk.ID)
+	assert.Zero(t, results[2].Score)
+
+	results = flatindex.Search(entries, []float32{1, 0}, model.SearchFilter{CourseID: 10, DocumentIDs: []uint64{2}}, 10)
+	require.Len(t, results, 2)
+	assert.Equal(t, uint64(3), results[0].Chunk.ID)
+	assert.Equal(t, uint64(4), results[1].Chunk.ID)
+
+	assert.Empty(t, flatindex.Search(entries, []float32{1, 0}, model.SearchFilter{CourseID: 10}, 0))
+}
+
+func TestFlatIndex_CacheReloadsWhenFingerprintChanges(t *testing.T) {
+	updatedAt := time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)
+	stored := []flatindex.Entry{
+		flatindex.NewEntry(model.Chunk{Base: model.Base{ID: 1}, DocumentID: 1}, []float32{1, 0}),
+		flatindex.NewEntry(model.Chunk{Base: model.Base{ID: 2}, DocumentID: 2}, []float32{0, 1}),
+	}
+	loads := 0
+	load := func() ([]flatindex.Entry, flatindex.Fingerprint, error) {
+		loads++
+		return stored, flatindex.Fingerprint{Count: int64(len(stored)), UpdatedAt: updatedAt}, nil
+	}
+	cache := flatindex.NewCache()
+
+	entries, err := cache.Entries(10, flatindex.Fingerprint{Count: 2, UpdatedAt: updatedAt}, load)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	entries, err = cache.Entries(10, flatindex.Fingerprint{Count: 2, UpdatedAt: updatedAt}, load)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, 1, loads, "an unchanged fingerprint is served from the cache")
+
+	// Deleting a document's vectors lowers the count but not the latest update.
+	stored = stored[:1]
+	entries, err = cache.Entries(10, flatindex.Fingerprint{Count: 1, UpdatedAt: updatedAt}, load)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, uint64(1), entries[0].ChunkID)
+	assert.Equal(t, 2, loads)
+
+	cache.Evict(10)
+	_, err = cache.Entries(10, flatindex.Fingerprint{Count: 1, UpdatedAt: updatedAt}, load)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads, "an evicted course is reloaded")
+}
@@ -0,0 +1,97 @@
+// internal/tests/repository/index_registry_test.go
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func fakeIndexConfig(vectorSize uint64) config.Config {
+	var cfg config.Config
+	cfg.Embedding.Provider = "fake"
+	cfg.VectorDB.Qdrant.CollectionName = "lecture_chunks"
+	cfg.VectorDB.Qdrant.VectorSize = vectorSize
+	return cfg
+}
+
+func TestIndexRegistry_BootstrapRegistersConfiguredCollection(t *testing.T) {
+	indexRepo := new(mocks.MockEmbeddingIndexRepository)
+	indexRepo.On("FindActive", mock.Anything).Return(nil, appErrors.ErrEmbeddingIndexNotFound).Once()
+	indexRepo.On("List", mock.Anything).Return([]*model.EmbeddingIndex{}, nil).Once()
+	var created *model.EmbeddingIndex
+	indexRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.EmbeddingIndex")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*model.EmbeddingIndex) }).
+		Return(nil).Once()
+
+	cfg := fakeIndexConfig(16)
+	cfg.VectorDB.Qdrant.SparseVector = "bm25"
+	registry := provider.NewIndexRegistry(cfg, nil, indexRepo, 0)
+	require.NoError(t, registry.Bootstrap(context.Background()))
+
+	require.NotNil(t, created)
+	// The collection predates sparse vectors, so "reembed" builds an index with them.
+	assert.Equal(t, "fake_fake_bow_16_16", created.Version)
+	assert.Empty(t, created.SparseVector)
+	configured := provider.ConfiguredIndex(cfg)
+	assert.Equal(t, "fake_fake_bow_16_16_bm25", configured.Version)
+	assert.Equal(t, "bm25", configured.SparseVector)
+	assert.Equal(t, "lecture_chunks", created.CollectionName)
+	assert.Equal(t, model.EmbeddingIndexActive, created.Status)
+	assert.Equal(t, 16, created.Dimensions)
+	assert.NotNil(t, created.ActivatedAt)
+	indexRepo.AssertExpectations(t)
+}
+
+func TestIndexRegistry_FollowsActiveIndex(t *testing.T) {
+	cfg := fakeIndexConfig(16)
+	current := provider.ConfiguredIndex(cfg)
+	current.Status = model.EmbeddingIndexActive
+	migrated := &model.EmbeddingIndex{
+		Version:        "fake_fake_bow_8_8",
+		Provider:       "fake",
+		Model:          "fake-bow-8",
+		Dimensions:     8,
+		CollectionName: "lecture_chunks_fake_fake_bow_8_8",
+		Status:         model.EmbeddingIndexActive,
+	}
+	indexRepo := new(mocks.MockEmbeddingIndexRepository)
+	indexRepo.On("FindActive", mock.Anything).Return(current, nil).Once()
+	indexRepo.On("FindActive", mock.Anything).Return(migrated, nil)
+
+	registry := provider.NewIndexRegistry(cfg, nil, indexRepo, 0)
+	indexes := registry.SearchIndexes(false)
+	ctx := context.Background()
+
+	pinned, err := indexes.ActiveSearchIndex(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, pinned.AnswerCache)
+	switched, err := indexes.ActiveSearchIndex(ctx)
+	require.NoError(t, err)
+
+	after, err := switched.EmbeddingRepo.CreateEmbeddings(ctx, []string{"binary search"}, "RETRIEVAL_QUERY")
+	require.NoError(t, err)
+	assert.Len(t, after[0], 8)
+
+	// A question resolved before the switch keeps the model of its index.
+	before, err := pinned.EmbeddingRepo.CreateEmbeddings(ctx, []string{"binary search"}, "RETRIEVAL_QUERY")
+	require.NoError(t, err)
+	assert.Len(t, before[0], 16)
+
+	// A query embedded for the previous index is rejected instead of searched.
+	_, err = registry.VectorRepository().Search(ctx, before[0], model.SearchFilter{CourseID: 1}, 5)
+	assert.ErrorContains(t, err, "does not match embedding index fake_fake_bow_8_8")
+	// So is a question vector looked up in the answer cache of the new index.
+	_, _, err = registry.SemanticCacheRepository().FindNearest(ctx, 1, "0", before[0], time.Time{})
+	assert.ErrorContains(t, err, "does not match embedding index fake_fake_bow_8_8")
+}
@@ -0,0 +1,237 @@
+// internal/tests/repository/memory_vector_test.go
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/memory"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+func newMemoryVectorRepository(t *testing.T, snapshotDir string) repository.VectorRepository {
+	t.Helper()
+	vectorRepo, err := memory.NewMemoryVectorRepository(config.MemoryVectorConfig{SnapshotDir: snapshotDir}, t.Name(), 2)
+	require.NoError(t, err)
+	return vectorRepo
+}
+
+func memoryChunks() ([]*model.Chunk, [][]float32) {
+	chunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, CourseID: 10, Text: "east", EmbeddingID: "a"},
+		{Base: model.Base{ID: 2}, CourseID: 10, Text: "north-east", EmbeddingID: "b"},
+		{Base: model.Base{ID: 3}, CourseID: 10, Text: "north", EmbeddingID: "c"},
+		{Base: model.Base{ID: 4}, CourseID: 20, Text: "east of another course", EmbeddingID: "d"},
+	}
+	vectors := [][]float32{{1, 0}, {1, 1}, {0, 2}, {3, 0}}
+	return chunks, vectors
+}
+
+func TestMemoryVectorRepository_SearchRanksByCosineWithinCourse(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks, vectors := memoryChunks()
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, vectors))
+
+	results, err := vectorRepo.Search(ctx, []float32{2, 0}, model.SearchFilter{CourseID: 10}, 2)
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, uint64(1), results[0].Chunk.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	assert.Equal(t, uint64(2), results[1].Chunk.ID)
+	assert.InDelta(t, 0.7071, results[1].Score, 1e-4)
+	assert.Equal(t, "north-east", results[1].Chunk.Text)
+}
+
+func TestMemoryVectorRepository_SearchAppliesFilter(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, CourseID: 10, DocumentID: 1, SemesterID: 1, EmbeddingID: "a", Document: model.Document{DocType: model.DocTypeSlides}},
+		{Base: model.Base{ID: 2}, CourseID: 10, DocumentID: 2, SemesterID: 2, EmbeddingID: "b", Document: model.Document{DocType: model.DocTypeSlides}},
+		{Base: model.Base{ID: 3}, CourseID: 10, DocumentID: 3, SemesterID: 2, EmbeddingID: "c", Document: model.Document{DocType: model.DocTypeNotes}},
+	}
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, [][]float32{{1, 0}, {1, 0}, {1, 0}}))
+
+	ids := func(filter model.SearchFilter) []uint64 {
+		results, err := vectorRepo.Search(ctx, []float32{1, 0}, filter, 10)
+		require.NoError(t, err)
+		var ids []uint64
+		for _, result := range results {
+			ids = append(ids, result.Chunk.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint64{1, 2}, ids(model.SearchFilter{CourseID: 10, DocType: model.DocTypeSlides}))
+	assert.Equal(t, []uint64{2, 3}, ids(model.SearchFilter{CourseID: 10, SemesterID: 2}))
+	assert.Equal(t, []uint64{1, 3}, ids(model.SearchFilter{CourseID: 10, DocumentIDs: []uint64{1, 3}}))
+	assert.Empty(t, ids(model.SearchFilter{CourseID: 10, DocumentIDs: []uint64{3}, DocType: model.DocTypeSlides}))
+}
+
+func TestMemoryVectorRepository_UpsertReplacesAndDeleteRemoves(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks, vectors := memoryChunks()
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, vectors))
+
+	require.NoError(t, vectorRepo.Upsert(ctx, []*model.Chunk{{Base: model.Base{ID: 3}, CourseID: 10, Text: "south", EmbeddingID: "c"}}, [][]float32{{0, -1}}))
+	require.NoError(t, vectorRepo.DeleteByIDs(ctx, []string{"a", "missing"}))
+
+	count, err := vectorRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	stored, err := vectorRepo.GetVectors(ctx, []string{"a", "c"})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "south", stored[0].Chunk.Text)
+	assert.Equal(t, []float32{0, -1}, stored[0].Vector)
+}
+
+func TestMemoryVectorRepository_RejectsMissingCollectionAndWrongSize(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+
+	_, err := vectorRepo.Search(ctx, []float32{1, 0}, model.SearchFilter{CourseID: 10}, 5)
+	assert.Error(t, err)
+
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	err = vectorRepo.Upsert(ctx, []*model.Chunk{{EmbeddingID: "a"}}, [][]float32{{1, 0, 0}})
+	assert.Error(t, err)
+}
+
+func TestMemoryVectorRepository_SnapshotSurvivesRestartAndRecreate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	vectorRepo := newMemoryVectorRepository(t, dir)
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks, vectors := memoryChunks()
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, vectors))
+
+	// A link to the snapshot directory stands in for another process reading the snapshot.
+	link := filepath.Join(t.TempDir(), "vectors")
+	require.NoError(t, os.Symlink(dir, link))
+	restarted := newMemoryVectorRepository(t, link)
+	count, err := restarted.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	results, err := restarted.Search(ctx, []float32{0, 1}, model.SearchFilter{CourseID: 10}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "north", results[0].Chunk.Text)
+
+	require.NoError(t, vectorRepo.RecreateCollection(ctx))
+	count, err = restarted.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestMemoryVectorRepository_DeleteByDocumentAndReplaceDocuments(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, CourseID: 10, DocumentID: 1, EmbeddingID: "a"},
+		{Base: model.Base{ID: 2}, CourseID: 10, DocumentID: 1, EmbeddingID: "b"},
+		{Base: model.Base{ID: 3}, CourseID: 10, DocumentID: 2, EmbeddingID: "c"},
+	}
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, [][]float32{{1, 0}, {1, 1}, {0, 1}}))
+
+	newVersion := []*model.Chunk{{Base: model.Base{ID: 4}, CourseID: 10, DocumentID: 3, Text: "v2", EmbeddingID: "d"}}
+	require.NoError(t, vectorRepo.ReplaceDocuments(ctx, []uint64{1}, newVersion, [][]float32{{1, 0}}))
+
+	results, err := vectorRepo.Search(ctx, []float32{1, 0}, model.SearchFilter{CourseID: 10}, 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "v2", results[0].Chunk.Text)
+	assert.Equal(t, uint64(2), results[1].Chunk.DocumentID)
+
+	// A failed replacement leaves the old version in place.
+	err = vectorRepo.ReplaceDocuments(ctx, []uint64{3}, []*model.Chunk{{EmbeddingID: "e"}}, [][]float32{{1, 0, 0}})
+	assert.Error(t, err)
+	require.NoError(t, vectorRepo.DeleteByDocument(ctx, 2))
+
+	count, err := vectorRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMemoryVectorRepository_HybridSearchFusesDenseAndSparse(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, CourseID: 10, Text: "二分探索木の探索", EmbeddingID: "a"},
+		{Base: model.Base{ID: 2}, CourseID: 10, Text: "ハッシュ表", EmbeddingID: "b"},
+		{Base: model.Base{ID: 3}, CourseID: 10, Text: "グラフの幅優先探索", EmbeddingID: "c"},
+		{Base: model.Base{ID: 4}, CourseID: 20, Text: "二分探索木", EmbeddingID: "d"},
+	}
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, [][]float32{{0, 1}, {1, 0}, {1, 1}, {0, 1}}))
+
+	query := model.HybridQuery{Text: "二分探索木", Vector: []float32{1, 0}, DenseLimit: 1, SparseLimit: 2, DenseWeight: 1, SparseWeight: 2, RRFK: 60}
+	results, err := vectorRepo.HybridSearch(ctx, query, model.SearchFilter{CourseID: 10})
+	require.NoError(t, err)
+
+	// Chunk 1 matches all query terms and chunk 3 some of them; chunk 2 is the only dense
+	// hit, weighted half as much as the sparse hits.
+	var ids []uint64
+	for _, result := range results {
+		ids = append(ids, result.Chunk.ID)
+	}
+	assert.Equal(t, []uint64{1, 3, 2}, ids)
+	assert.InDelta(t, 2.0/60, results[0].Score, 1e-6)
+}
+
+func TestMemoryVectorRepository_DropCourseSpaceAndStats(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks, vectors := memoryChunks()
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, vectors))
+	require.NoError(t, vectorRepo.CreateCourseSpace(ctx, 10))
+
+	stats, err := vectorRepo.CourseSpaceStats(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.TenancyShared, stats.Tenancy)
+	assert.Equal(t, 3, stats.Points)
+
+	require.NoError(t, vectorRepo.DropCourseSpace(ctx, 10))
+	stats, err = vectorRepo.CourseSpaceStats(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Points)
+	count, err := vectorRepo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMemoryVectorRepository_ScanStreamsAllPointsInBatches(t *testing.T) {
+	ctx := context.Background()
+	vectorRepo := newMemoryVectorRepository(t, "")
+	require.NoError(t, vectorRepo.EnsureCollectionExists(ctx))
+	chunks, vectors := memoryChunks()
+	require.NoError(t, vectorRepo.Upsert(ctx, chunks, vectors))
+
+	var batches []int
+	var ids []string
+	err := vectorRepo.Scan(ctx, 3, func(points []model.ChunkVector) error {
+		batches = append(batches, len(points))
+		for _, point := range points {
+			ids = append(ids, point.Chunk.EmbeddingID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{3, 1}, batches)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+}
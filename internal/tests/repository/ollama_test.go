@@ -0,0 +1,193 @@
+// internal/tests/repository/ollama_test.go
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/ollama"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// ollamaRequest is the part of the chat and embed requests the tests inspect.
+type ollamaRequest struct {
+	Model    string `json:"model"`
+	Stream   *bool  `json:"stream"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	Input []string `json:"input"`
+}
+
+// newOllamaServer starts a fake Ollama server that serves /api/chat and /api/embed.
+func newOllamaServer(t *testing.T, chat func(w http.ResponseWriter, req ollamaRequest), embed func(w http.ResponseWriter, req ollamaRequest)) *httptest.Server {
+	t.Helper()
+	decode := func(handle func(w http.ResponseWriter, req ollamaRequest)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var req ollamaRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			handle(w, req)
+		}
+	}
+	mux := http.NewServeMux()
+	if chat != nil {
+		mux.Handle("/api/chat", decode(chat))
+	}
+	if embed != nil {
+		mux.Handle("/api/embed", decode(embed))
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaLLMRepository_GenerateContent(t *testing.T) {
+	var received ollamaRequest
+	server := newOllamaServer(t, func(w http.ResponseWriter, req ollamaRequest) {
+		received = req
+		fmt.Fprint(w, `{"model":"llama3.1","message":{"role":"assistant","content":"Local answer."},"done":true}`)
+	}, nil)
+	llmRepo, err := ollama.NewOllamaLLMRepository(config.OllamaConfig{BaseURL: server.URL, Model: "llama3.1"})
+	require.NoError(t, err)
+
+	answer, err := llmRepo.GenerateContent(context.Background(), repository.GenerateContentParams{
+		SystemPrompt: "Be concise.",
+		UserPrompt:   "What is a heap?",
+		History:      []model.ConversationMessage{{Role: model.MessageRoleUser, Content: "Hi"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Local answer.", answer)
+	assert.Equal(t, "llama3.1", received.Model)
+	if assert.NotNil(t, received.Stream) {
+		assert.False(t, *received.Stream, "streaming must be disabled explicitly")
+	}
+	if assert.Len(t, received.Messages, 3) {
+		assert.Equal(t, "system", received.Messages[0].Role)
+		assert.Equal(t, "user", received.Messages[2].Role)
+		assert.Equal(t, "What is a heap?", received.Messages[2].Content)
+	}
+}
+
+func TestOllamaLLMRepository_GenerateContentStream(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		server := newOllamaServer(t, func(w http.ResponseWriter, req ollamaRequest) {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"A heap "},"done":false}`)
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"is a tree."},"done":false}`)
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"eval_count":7}`)
+		}, nil)
+		llmRepo, err := ollama.NewOllamaLLMRepository(config.OllamaConfig{BaseURL: server.URL, Model: "llama3.1"})
+		require.NoError(t, err)
+
+		var sb strings.Builder
+		err = llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{UserPrompt: "What is a heap?"}, &sb)
+
+		require.NoError(t, err)
+		assert.Equal(t, "A heap is a tree.", sb.String())
+	})
+
+	t.Run("Failure_StreamError", func(t *testing.T) {
+		server := newOllamaServer(t, func(w http.ResponseWriter, req ollamaRequest) {
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"A heap "},"done":false}`)
+			fmt.Fprintln(w, `{"error":"model runner crashed"}`)
+		}, nil)
+		llmRepo, err := ollama.NewOllamaLLMRepository(config.OllamaConfig{BaseURL: server.URL, Model: "llama3.1"})
+		require.NoError(t, err)
+
+		err = llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{UserPrompt: "What is a heap?"}, new(strings.Builder))
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "model runner crashed")
+		}
+	})
+
+	t.Run("Failure_ModelNotFound", func(t *testing.T) {
+		server := newOllamaServer(t, func(w http.ResponseWriter, req ollamaRequest) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"model \"llama3.1\" not found, try pulling it first"}`)
+		}, nil)
+		llmRepo, err := ollama.NewOllamaLLMRepository(config.OllamaConfig{BaseURL: server.URL, Model: "llama3.1"})
+		require.NoError(t, err)
+
+		err = llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{UserPrompt: "hi"}, new(strings.Builder))
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "try pulling it first")
+		}
+	})
+}
+
+func TestOllamaEmbeddingRepository_CreateEmbeddings(t *testing.T) {
+	var received ollamaRequest
+	server := newOllamaServer(t, nil, func(w http.ResponseWriter, req ollamaRequest) {
+		received = req
+		fmt.Fprint(w, `{"model":"nomic-embed-text","embeddings":[[0.1,0.2,0.3],[0.4,0.5,0.6]]}`)
+	})
+	embeddingRepo, err := ollama.NewOllamaEmbeddingRepository(config.OllamaConfig{BaseURL: server.URL, Model: "nomic-embed-text"})
+	require.NoError(t, err)
+
+	embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), []string{"first", "second"}, "RETRIEVAL_DOCUMENT")
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2, 0.3}, {0.4, 0.5, 0.6}}, embeddings)
+	assert.Equal(t, "nomic-embed-text", received.Model)
+	assert.Equal(t, []string{"first", "second"}, received.Input)
+}
+
+func TestProvider_CheckEmbeddingDimension(t *testing.T) {
+	server := newOllamaServer(t, nil, func(w http.ResponseWriter, req ollamaRequest) {
+		fmt.Fprint(w, `{"embeddings":[[0.1,0.2,0.3]]}`)
+	})
+	newConfig := func(dimensions int, vectorSize uint64) config.Config {
+		return config.Config{
+			VectorDB: config.VectorDBConfig{Qdrant: config.QdrantConfig{VectorSize: vectorSize}},
+			Embedding: config.EmbeddingConfig{
+				Provider: "ollama",
+				Ollama:   config.OllamaConfig{BaseURL: server.URL, Model: "nomic-embed-text", Dimensions: dimensions},
+			},
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		cfg := newConfig(3, 3)
+		embeddingRepo, err := provider.NewEmbeddingRepository(cfg)
+		require.NoError(t, err)
+
+		assert.NoError(t, provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo))
+	})
+
+	t.Run("Failure_ConfiguredDimensionMismatch", func(t *testing.T) {
+		cfg := newConfig(768, 3)
+		embeddingRepo, err := provider.NewEmbeddingRepository(cfg)
+		require.NoError(t, err)
+
+		assert.Error(t, provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo))
+	})
+
+	t.Run("Failure_ModelDimensionMismatch", func(t *testing.T) {
+		// Nothing configured, but the model produces 3-dimensional vectors.
+		cfg := newConfig(0, 768)
+		embeddingRepo, err := provider.NewEmbeddingRepository(cfg)
+		require.NoError(t, err)
+
+		err = provider.CheckEmbeddingDimension(context.Background(), cfg, embeddingRepo)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "768")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// internal/tests/repository/openai_test.go
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/openai"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+// openAIRequest is the part of the chat completions and embeddings requests the tests inspect.
+type openAIRequest struct {
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions"`
+}
+
+// newOpenAIServer starts a stand-in for an OpenAI-compatible server. It records the
+// last request and answers with the given handler.
+func newOpenAIServer(t *testing.T, handle func(w http.ResponseWriter, req openAIRequest)) (*httptest.Server, *http.Request) {
+	t.Helper()
+	last := new(http.Request)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handle(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return server, last
+}
+
+func TestOpenAILLMRepository_GenerateContent(t *testing.T) {
+	var received openAIRequest
+	server, last := newOpenAIServer(t, func(w http.ResponseWriter, req openAIRequest) {
+		received = req
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello from the gateway."}}]}`)
+	})
+	llmRepo, err := openai.NewOpenAILLMRepository(config.OpenAIConfig{BaseURL: server.URL + "/v1", APIKey: "secret", Model: "llama-3-70b"})
+	require.NoError(t, err)
+
+	answer, err := llmRepo.GenerateContent(context.Background(), repository.GenerateContentParams{
+		SystemPrompt: "Be concise.",
+		UserPrompt:   "And B-trees?",
+		History: []model.ConversationMessage{
+			{Role: model.MessageRoleUser, Content: "What is a binary tree?"},
+			{Role: model.MessageRoleAssistant, Content: "A tree with at most two children per node."},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Hello from the gateway.", answer)
+	assert.Equal(t, "/v1/chat/completions", last.URL.Path)
+	assert.Equal(t, "Bearer secret", last.Header.Get("Authorization"))
+	assert.Equal(t, "llama-3-70b", received.Model)
+	assert.False(t, received.Stream)
+	if assert.Len(t, received.Messages, 4) {
+		assert.Equal(t, "system", received.Messages[0].Role)
+		assert.Equal(t, "Be concise.", received.Messages[0].Content)
+		assert.Equal(t, "assistant", received.Messages[2].Role)
+		assert.Equal(t, "user", received.Messages[3].Role)
+		assert.Equal(t, "And B-trees?", received.Messages[3].Content)
+	}
+	assert.Equal(t, "llama-3-70b", llmRepo.ModelName())
+}
+
+func TestOpenAILLMRepository_GenerateContentStream(t *testing.T) {
+	server, _ := newOpenAIServer(t, func(w http.ResponseWriter, req openAIRequest) {
+		assert.True(t, req.Stream)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{`{"role":"assistant"}`, `{"content":"B-trees "}`, `{"content":"are balanced."}`} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":%s}]}\n\n", delta)
+		}
+		fmt.Fprint(w, ": keep-alive\n\ndata: [DONE]\n\n")
+	})
+	llmRepo, err := openai.NewOpenAILLMRepository(config.OpenAIConfig{BaseURL: server.URL, Model: "llama-3-70b"})
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	err = llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{UserPrompt: "What are B-trees?"}, &sb)
+
+	require.NoError(t, err)
+	assert.Equal(t, "B-trees are balanced.", sb.String())
+}
+
+func TestOpenAILLMRepository_ErrorResponse(t *testing.T) {
+	server, _ := newOpenAIServer(t, func(w http.ResponseWriter, req openAIRequest) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"rate_limit_error"}}`)
+	})
+	llmRepo, err := openai.NewOpenAILLMRepository(config.OpenAIConfig{BaseURL: server.URL, Model: "llama-3-70b"})
+	require.NoError(t, err)
+
+	_, err = llmRepo.GenerateContent(context.Background(), repository.GenerateContentParams{UserPrompt: "hi"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "429")
+		assert.Contains(t, err.Error(), "Rate limit reached")
+	}
+
+	err = llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{UserPrompt: "hi"}, new(strings.Builder))
+	assert.Error(t, err)
+}
+
+func TestOpenAIEmbeddingRepository_CreateEmbeddings(t *testing.T) {
+	var received openAIRequest
+	server, last := newOpenAIServer(t, func(w http.ResponseWriter, req openAIRequest) {
+		received = req
+		// Results may come back in any order; the index ties them to the input.
+		fmt.Fprint(w, `{"object":"list","data":[
+			{"object":"embedding","index":1,"embedding":[0.3,0.4]},
+			{"object":"embedding","index":0,"embedding":[0.1,0.2]}
+		]}`)
+	})
+	embeddingRepo, err := openai.NewOpenAIEmbeddingRepository(config.OpenAIConfig{BaseURL: server.URL + "/v1/", Model: "bge-m3", Dimensions: 2})
+	require.NoError(t, err)
+
+	embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), []string{"first", "second"}, "RETRIEVAL_DOCUMENT")
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+	assert.Equal(t, "/v1/embeddings", last.URL.Path)
+	assert.Equal(t, "bge-m3", received.Model)
+	assert.Equal(t, []string{"first", "second"}, received.Input)
+	assert.Equal(t, 2, received.Dimensions)
+}
+
+func TestOpenAIEmbeddingRepository_CountMismatch(t *testing.T) {
+	server, _ := newOpenAIServer(t, func(w http.ResponseWriter, req openAIRequest) {
+		fmt.Fprint(w, `{"data":[{"index":0,"embedding":[0.1,0.2]}]}`)
+	})
+	embeddingRepo, err := openai.NewOpenAIEmbeddingRepository(config.OpenAIConfig{BaseURL: server.URL, Model: "bge-m3"})
+	require.NoError(t, err)
+
+	_, err = embeddingRepo.CreateEmbeddings(context.Background(), []string{"first", "second"}, "RETRIEVAL_DOCUMENT")
+	assert.Error(t, err)
+}
+
+func TestProvider_OpenAI(t *testing.T) {
+	cfg := config.Config{
+		LLM:       config.LLMConfig{Provider: "openai", OpenAI: config.OpenAIConfig{Model: "llama-3-70b"}},
+		Embedding: config.EmbeddingConfig{Provider: "openai", OpenAI: config.OpenAIConfig{Model: "bge-m3"}},
+	}
+
+	llmRepo, err := provider.NewLLMRepository(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "llama-3-70b", llmRepo.ModelName())
+	_, err = provider.NewEmbeddingRepository(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "bge-m3", provider.EmbeddingModelName(cfg))
+
+	cfg.LLM.Provider = "unknown"
+	_, err = provider.NewLLMRepository(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// internal/tests/repository/rerank_test.go
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/rerank"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+)
+
+func rerankCandidates(texts ...string) []model.RetrievedChunk {
+	candidates := make([]model.RetrievedChunk, len(texts))
+	for idx, text := range texts {
+		candidates[idx] = model.RetrievedChunk{Chunk: model.Chunk{Base: model.Base{ID: uint64(idx + 1)}, Text: text}, Score: 0.5}
+	}
+	return candidates
+}
+
+func chunkIDs(chunks []model.RetrievedChunk) []uint64 {
+	ids := make([]uint64, len(chunks))
+	for idx, chunk := range chunks {
+		ids[idx] = chunk.Chunk.ID
+	}
+	return ids
+}
+
+func TestNewReranker(t *testing.T) {
+	for _, rerankerType := range []string{"", "rrf", "llm", "lexical"} {
+		reranker, err := rerank.NewReranker(config.RerankConfig{Type: rerankerType}, new(mocks.MockLLMRepository))
+		assert.NoError(t, err, rerankerType)
+		assert.NotNil(t, reranker, rerankerType)
+	}
+
+	_, err := rerank.NewReranker(config.RerankConfig{Type: "cross-encoder"}, nil)
+	assert.Error(t, err)
+}
+
+func TestRRFReranker_KeepsFusionOrder(t *testing.T) {
+	candidates := rerankCandidates("a", "b", "c")
+
+	reranked, err := rerank.NewRRFReranker().Rerank(context.Background(), "query", candidates, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, chunkIDs(reranked))
+}
+
+func TestLexicalReranker(t *testing.T) {
+	t.Run("Success_RanksByQueryTermOverlap", func(t *testing.T) {
+		candidates := rerankCandidates(
+			"Hash tables offer constant time lookups.",
+			"A binary heap is a complete binary tree.",
+			"The heap property orders parents before children in a binary heap.",
+		)
+
+		reranked, err := rerank.NewLexicalReranker().Rerank(context.Background(), "binary heap property", candidates, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{3, 2}, chunkIDs(reranked))
+		assert.Equal(t, float32(1), reranked[0].Score)
+	})
+
+	t.Run("Success_MatchesJapaneseText", func(t *testing.T) {
+		candidates := rerankCandidates("ハッシュ表は定数時間で検索できる。", "二分ヒープは完全二分木である。")
+
+		reranked, err := rerank.NewLexicalReranker().Rerank(context.Background(), "二分ヒープとは", candidates, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{2}, chunkIDs(reranked))
+	})
+}
+
+func TestLLMReranker(t *testing.T) {
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	reranker := rerank.NewLLMReranker(mockLLMRepo)
+	candidates := rerankCandidates("passage one", "passage two", "passage three")
+	passage := func(text string) interface{} {
+		return mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+			return strings.HasSuffix(params.UserPrompt, text)
+		})
+	}
+
+	t.Run("Success_OrdersByLLMScore", func(t *testing.T) {
+		mockLLMRepo.On("GenerateContent", mock.Anything, passage("passage one")).Return("3", nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, passage("passage two")).Return("Score: 9", nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, passage("passage three")).Return("no idea", nil).Once()
+
+		reranked, err := reranker.Rerank(context.Background(), "query", candidates, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{2, 1}, chunkIDs(reranked))
+		assert.InDelta(t, 0.9, reranked[0].Score, 1e-6)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure_LLMError", func(t *testing.T) {
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("", fmt.Errorf("quota exceeded"))
+
+		_, err := reranker.Rerank(context.Background(), "query", candidates, 2)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,141 @@
+// internal/tests/repository/resilient_test.go
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/resilient"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func newMockLLM(model string) *mocks.MockLLMRepository {
+	llm := new(mocks.MockLLMRepository)
+	llm.On("ModelName").Return(model)
+	return llm
+}
+
+func httpStatusError(code int) error {
+	return fmt.Errorf("request failed: %w", &appErrors.StatusError{StatusCode: code, Message: http.StatusText(code)})
+}
+
+func TestResilientLLMRepository_RetriesTransientErrors(t *testing.T) {
+	primary := newMockLLM("primary")
+	primary.On("GenerateContent", mock.Anything, mock.Anything).Return("", httpStatusError(http.StatusServiceUnavailable)).Once()
+	primary.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).Once()
+	llmRepo := resilient.NewLLMRepository([]repository.LLMRepository{primary}, config.ResilienceConfig{MaxRetries: 2})
+
+	info := &repository.GenerationInfo{}
+	response, err := llmRepo.GenerateContent(repository.WithGenerationInfo(context.Background(), info), repository.GenerateContentParams{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "answer", response)
+	assert.Equal(t, "primary", info.Model)
+	primary.AssertNumberOfCalls(t, "GenerateContent", 2)
+}
+
+func TestResilientLLMRepository_FallsOverToNextProvider(t *testing.T) {
+	primary := newMockLLM("primary")
+	primary.On("GenerateContent", mock.Anything, mock.Anything).Return("", status.Error(codes.ResourceExhausted, "quota exceeded"))
+	secondary := newMockLLM("secondary")
+	secondary.On("GenerateContent", mock.Anything, mock.Anything).Return("fallback answer", nil)
+	llmRepo := resilient.NewLLMRepository([]repository.LLMRepository{primary, secondary}, config.ResilienceConfig{MaxRetries: 1})
+
+	info := &repository.GenerationInfo{}
+	response, err := llmRepo.GenerateContent(repository.WithGenerationInfo(context.Background(), info), repository.GenerateContentParams{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "fallback answer", response)
+	assert.Equal(t, "secondary", info.Model)
+	assert.Equal(t, "primary", llmRepo.ModelName())
+	primary.AssertNumberOfCalls(t, "GenerateContent", 2)
+}
+
+func TestResilientLLMRepository_DoesNotRetryPermanentErrors(t *testing.T) {
+	primary := newMockLLM("primary")
+	primary.On("GenerateContent", mock.Anything, mock.Anything).Return("", httpStatusError(http.StatusBadRequest))
+	llmRepo := resilient.NewLLMRepository([]repository.LLMRepository{primary}, config.ResilienceConfig{MaxRetries: 3})
+
+	_, err := llmRepo.GenerateContent(context.Background(), repository.GenerateContentParams{})
+
+	var statusErr *appErrors.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	primary.AssertNumberOfCalls(t, "GenerateContent", 1)
+}
+
+func TestResilientLLMRepository_OpenBreakerSkipsProvider(t *testing.T) {
+	primary := newMockLLM("primary")
+	primary.On("GenerateContent", mock.Anything, mock.Anything).Return("", httpStatusError(http.StatusServiceUnavailable))
+	secondary := newMockLLM("secondary")
+	secondary.On("GenerateContent", mock.Anything, mock.Anything).Return("fallback answer", nil)
+	llmRepo := resilient.NewLLMRepository([]repository.LLMRepository{primary, secondary}, config.ResilienceConfig{
+		BreakerFailureThreshold: 2,
+		BreakerCooldownSeconds:  60,
+	})
+
+	for range 4 {
+		_, err := llmRepo.GenerateContent(context.Background(), repository.GenerateContentParams{})
+		require.NoError(t, err)
+	}
+
+	primary.AssertNumberOfCalls(t, "GenerateContent", 2)
+	secondary.AssertNumberOfCalls(t, "GenerateContent", 4)
+}
+
+func TestResilientLLMRepository_StreamFallsOverOnlyBeforeOutput(t *testing.T) {
+	unavailable := httpStatusError(http.StatusServiceUnavailable)
+
+	primary := newMockLLM("primary")
+	primary.On("GenerateContentStream", mock.Anything, mock.Anything, mock.Anything).Return(unavailable)
+	secondary := newMockLLM("secondary")
+	secondary.On("GenerateContentStream", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { _, _ = io.WriteString(args.Get(2).(io.Writer), "streamed") }).
+		Return(nil)
+	llmRepo := resilient.NewLLMRepository([]repository.LLMRepository{primary, secondary}, config.ResilienceConfig{})
+
+	var out bytes.Buffer
+	require.NoError(t, llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{}, &out))
+	assert.Equal(t, "streamed", out.String())
+
+	// A provider that fails after it started writing is not retried.
+	partial := newMockLLM("partial")
+	partial.On("GenerateContentStream", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { _, _ = io.WriteString(args.Get(2).(io.Writer), "half an ans") }).
+		Return(unavailable)
+	llmRepo = resilient.NewLLMRepository([]repository.LLMRepository{partial, secondary}, config.ResilienceConfig{MaxRetries: 2})
+
+	out.Reset()
+	err := llmRepo.GenerateContentStream(context.Background(), repository.GenerateContentParams{}, &out)
+	assert.True(t, errors.Is(err, unavailable))
+	assert.Equal(t, "half an ans", out.String())
+	partial.AssertNumberOfCalls(t, "GenerateContentStream", 1)
+}
+
+func TestResilientEmbeddingRepository_FallsOverToNextProvider(t *testing.T) {
+	primary := new(mocks.MockEmbeddingRepository)
+	primary.On("CreateEmbeddings", mock.Anything, []string{"text"}, "RETRIEVAL_QUERY").Return(nil, status.Error(codes.Unavailable, "down"))
+	secondary := new(mocks.MockEmbeddingRepository)
+	secondary.On("CreateEmbeddings", mock.Anything, []string{"text"}, "RETRIEVAL_QUERY").Return([][]float32{{0.1, 0.2}}, nil)
+	embeddingRepo := resilient.NewEmbeddingRepository(
+		[]repository.EmbeddingRepository{primary, secondary}, []string{"google", "openai"}, config.ResilienceConfig{})
+
+	embeddings, err := embeddingRepo.CreateEmbeddings(context.Background(), []string{"text"}, "RETRIEVAL_QUERY")
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}}, embeddings)
+}
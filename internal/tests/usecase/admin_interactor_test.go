@@ -0,0 +1,165 @@
+// internal/tests/usecase/admin_interactor_test.go
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/interactor"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func TestAdminInteractor_InvalidateAnswerCache(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+	mockSemanticCache := new(mocks.MockSemanticCacheRepository)
+	adminInteractor := interactor.NewAdminInteractor(mockUserRepo, mockCourseRepo, mockCacheRepo, new(mocks.MockVectorRepository), mockSemanticCache)
+
+	adminID := uint64(1)
+	studentID := uint64(2)
+	courseID := uint64(101)
+	mockUserRepo.On("FindByID", ctx, adminID).Return(&model.User{Base: model.Base{ID: adminID}, Role: model.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", ctx, studentID).Return(&model.User{Base: model.Base{ID: studentID}, Role: model.RoleStudent}, nil)
+
+	t.Run("Success_BumpsCorpusVersionAndDeletesEntries", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, courseID).Return(&model.Course{Base: model.Base{ID: courseID}}, nil).Once()
+		mockCacheRepo.On("Incr", ctx, "course:101:corpus_version").Return(int64(4), nil).Once()
+		mockSemanticCache.On("DeleteByCourse", ctx, courseID).Return(nil).Once()
+
+		// Act
+		err := adminInteractor.InvalidateAnswerCache(ctx, adminID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockCacheRepo.AssertExpectations(t)
+		mockSemanticCache.AssertExpectations(t)
+	})
+
+	t.Run("Success_IgnoresSemanticCacheFailure", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, courseID).Return(&model.Course{Base: model.Base{ID: courseID}}, nil).Once()
+		mockCacheRepo.On("Incr", ctx, "course:101:corpus_version").Return(int64(5), nil).Once()
+		mockSemanticCache.On("DeleteByCourse", ctx, courseID).Return(errors.New("qdrant unavailable")).Once()
+
+		// Act
+		err := adminInteractor.InvalidateAnswerCache(ctx, adminID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockCacheRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure_NotAdmin", func(t *testing.T) {
+		// Act
+		err := adminInteractor.InvalidateAnswerCache(ctx, studentID, courseID)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+		mockCacheRepo.AssertNumberOfCalls(t, "Incr", 2)
+	})
+
+	t.Run("Failure_CourseNotFound", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, uint64(999)).Return(nil, appErrors.ErrCourseNotFound).Once()
+
+		// Act
+		err := adminInteractor.InvalidateAnswerCache(ctx, adminID, 999)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrNotFound)
+		mockCacheRepo.AssertNumberOfCalls(t, "Incr", 2)
+	})
+}
+
+func TestAdminInteractor_VectorSpace(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	adminInteractor := interactor.NewAdminInteractor(mockUserRepo, mockCourseRepo, mockCacheRepo, mockVectorRepo, nil)
+
+	adminID := uint64(1)
+	studentID := uint64(2)
+	courseID := uint64(101)
+	mockUserRepo.On("FindByID", ctx, adminID).Return(&model.User{Base: model.Base{ID: adminID}, Role: model.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", ctx, studentID).Return(&model.User{Base: model.Base{ID: studentID}, Role: model.RoleStudent}, nil)
+	mockCourseRepo.On("FindByID", ctx, courseID).Return(&model.Course{Base: model.Base{ID: courseID}}, nil)
+	mockCourseRepo.On("FindByID", ctx, uint64(999)).Return(nil, appErrors.ErrCourseNotFound)
+
+	t.Run("Create_Success", func(t *testing.T) {
+		// Arrange
+		mockVectorRepo.On("CreateCourseSpace", ctx, courseID).Return(nil).Once()
+
+		// Act
+		err := adminInteractor.CreateVectorSpace(ctx, adminID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockVectorRepo.AssertExpectations(t)
+	})
+
+	t.Run("Drop_Success_BumpsCorpusVersion", func(t *testing.T) {
+		// Arrange
+		mockVectorRepo.On("DropCourseSpace", ctx, courseID).Return(nil).Once()
+		mockCacheRepo.On("Incr", ctx, "course:101:corpus_version").Return(int64(2), nil).Once()
+
+		// Act
+		err := adminInteractor.DropVectorSpace(ctx, adminID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockVectorRepo.AssertExpectations(t)
+		mockCacheRepo.AssertExpectations(t)
+	})
+
+	t.Run("Drop_Failure_VectorStore", func(t *testing.T) {
+		// Arrange
+		mockVectorRepo.On("DropCourseSpace", ctx, courseID).Return(errors.New("qdrant unavailable")).Once()
+
+		// Act
+		err := adminInteractor.DropVectorSpace(ctx, adminID, courseID)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrInternalServerError)
+		mockCacheRepo.AssertNumberOfCalls(t, "Incr", 1)
+	})
+
+	t.Run("Stats_Success", func(t *testing.T) {
+		// Arrange
+		stats := &model.VectorSpaceStats{CourseID: courseID, Tenancy: model.TenancyCollection, Collection: "lecture_chunks_course_101", Exists: true, Points: 42}
+		mockVectorRepo.On("CourseSpaceStats", ctx, courseID).Return(stats, nil).Once()
+
+		// Act
+		got, err := adminInteractor.VectorSpaceStats(ctx, adminID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, stats, got)
+	})
+
+	t.Run("Failure_NotAdmin", func(t *testing.T) {
+		// Act
+		err := adminInteractor.DropVectorSpace(ctx, studentID, courseID)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+		mockVectorRepo.AssertNumberOfCalls(t, "DropCourseSpace", 2)
+	})
+
+	t.Run("Failure_CourseNotFound", func(t *testing.T) {
+		// Act
+		_, err := adminInteractor.VectorSpaceStats(ctx, adminID, 999)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrNotFound)
+		mockVectorRepo.AssertNumberOfCalls(t, "CourseSpaceStats", 1)
+	})
+}
@@ -0,0 +1,99 @@
+// internal/tests/usecase/conversation_interactor_test.go
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/interactor"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+func TestConversationInteractor(t *testing.T) {
+	ctx := context.Background()
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+	conversationInteractor := interactor.NewConversationInteractor(mockConvRepo, mockCacheRepo)
+
+	userID := uint64(1)
+	sessionID := "6f1c2a5e-1111-4222-8333-944455556666"
+	conversation := &model.Conversation{Base: model.Base{ID: 7}, SessionID: sessionID, UserID: userID, CourseID: 101, Title: "What is RAG?"}
+
+	t.Run("List_Success", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("ListByUser", ctx, userID, uint64(101)).Return([]*model.Conversation{conversation}, nil).Once()
+
+		// Act
+		conversations, err := conversationInteractor.List(ctx, userID, 101)
+
+		// Assert
+		assert.NoError(t, err)
+		if assert.Len(t, conversations, 1) {
+			assert.Equal(t, sessionID, conversations[0].SessionID)
+			assert.Equal(t, "What is RAG?", conversations[0].Title)
+			assert.Nil(t, conversations[0].Messages)
+		}
+		mockConvRepo.AssertExpectations(t)
+	})
+
+	t.Run("Get_Success", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", ctx, sessionID).Return(conversation, nil).Once()
+		mockConvRepo.On("ListMessages", ctx, uint64(7), 0).Return([]*model.ConversationMessage{
+			{Role: model.MessageRoleUser, Content: "What is RAG?"},
+			{Role: model.MessageRoleAssistant, Content: "Retrieval-Augmented Generation."},
+		}, nil).Once()
+
+		// Act
+		out, err := conversationInteractor.Get(ctx, userID, sessionID)
+
+		// Assert
+		assert.NoError(t, err)
+		if assert.Len(t, out.Messages, 2) {
+			assert.Equal(t, "user", out.Messages[0].Role)
+			assert.Equal(t, "assistant", out.Messages[1].Role)
+		}
+		mockConvRepo.AssertExpectations(t)
+	})
+
+	t.Run("Get_Failure_OtherUser", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", ctx, sessionID).Return(conversation, nil).Once()
+
+		// Act
+		_, err := conversationInteractor.Get(ctx, uint64(2), sessionID)
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrConversationNotFound)
+		mockConvRepo.AssertNumberOfCalls(t, "ListMessages", 1) // Only the call from Get_Success
+	})
+
+	t.Run("Delete_Success_InvalidatesCache", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", ctx, sessionID).Return(conversation, nil).Once()
+		mockConvRepo.On("Delete", ctx, uint64(7)).Return(nil).Once()
+		mockCacheRepo.On("Delete", ctx, "conversation:"+sessionID+":recent").Return(nil).Once()
+
+		// Act
+		err := conversationInteractor.Delete(ctx, userID, sessionID)
+
+		// Assert
+		assert.NoError(t, err)
+		mockConvRepo.AssertExpectations(t)
+		mockCacheRepo.AssertExpectations(t)
+	})
+
+	t.Run("Delete_Failure_NotFound", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", ctx, "missing").Return(nil, appErrors.ErrConversationNotFound).Once()
+
+		// Act
+		err := conversationInteractor.Delete(ctx, userID, "missing")
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrConversationNotFound)
+	})
+}
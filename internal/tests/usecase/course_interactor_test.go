@@ -68,3 +68,55 @@ func TestCourseInteractor_EnrollUser(t *testing.T) {
 		mockEnrollmentRepo.AssertNotCalled(t, "Create")
 	})
 }
+
+func TestCourseInteractor_RetrievalSettings(t *testing.T) {
+	ctx := context.Background()
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockEnrollmentRepo := new(mocks.MockEnrollmentRepository)
+	courseInteractor := interactor.NewCourseInteractor(mockCourseRepo, mockEnrollmentRepo)
+
+	instructorID := uint64(7)
+	courseID := uint64(101)
+	course := &model.Course{Base: model.Base{ID: courseID}, InstructorID: instructorID}
+	contextSize := 5
+
+	t.Run("Success_InstructorUpdatesSettings", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, courseID).Return(course, nil).Once()
+		mockCourseRepo.On("SaveSettings", ctx, mock.MatchedBy(func(settings *model.CourseSettings) bool {
+			return settings.CourseID == courseID && *settings.Retrieval.ContextSize == contextSize
+		})).Return(nil).Once()
+
+		// Act
+		err := courseInteractor.UpdateRetrievalSettings(ctx, instructorID, courseID, model.RetrievalOverrides{ContextSize: &contextSize})
+
+		// Assert
+		assert.NoError(t, err)
+		mockCourseRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_NoSavedSettings", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, courseID).Return(course, nil).Once()
+		mockCourseRepo.On("FindSettings", ctx, courseID).Return(nil, nil).Once()
+
+		// Act
+		retrieval, err := courseInteractor.GetRetrievalSettings(ctx, instructorID, courseID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &model.RetrievalOverrides{}, retrieval)
+	})
+
+	t.Run("Failure_NotInstructor_ReturnsForbidden", func(t *testing.T) {
+		// Arrange
+		mockCourseRepo.On("FindByID", ctx, courseID).Return(course, nil).Once()
+
+		// Act
+		err := courseInteractor.UpdateRetrievalSettings(ctx, uint64(1), courseID, model.RetrievalOverrides{})
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+		mockCourseRepo.AssertNumberOfCalls(t, "SaveSettings", 1) // Only the call from the first case
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/interactor"
 	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
@@ -36,7 +37,7 @@ func TestFileInteractor_Upload(t *testing.T) {
 		mockDocRepo := new(mocks.MockDocumentRepository)
 		mockFileStorage := new(mocks.MockFileStorage)
 		mockCourseRepo := new(mocks.MockCourseRepository)
-		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo)
+		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo, new(mocks.MockVectorRepository), new(mocks.MockCacheRepository))
 
 		// ★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★
 		// 修正点: io.Readerをサブテスト内で初期化
@@ -44,6 +45,7 @@ func TestFileInteractor_Upload(t *testing.T) {
 		var file io.Reader = bytes.NewBufferString(fileContent)
 
 		mockCourseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		mockDocRepo.On("FindLatestVersion", mock.Anything, courseID, fileName).Return(nil, appErrors.ErrDocumentNotFound).Once()
 		mockFileStorage.On("Save", mock.Anything, courseID, fileName, []byte(fileContent)).Return(savedPath, nil).Once()
 		mockDocRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Document")).Return(nil).Once()
 
@@ -57,17 +59,40 @@ func TestFileInteractor_Upload(t *testing.T) {
 		assert.Equal(t, semesterID, doc.SemesterID)
 		assert.Equal(t, fileName, doc.Title)
 		assert.Equal(t, savedPath, doc.SourceURI)
+		assert.Equal(t, 1, doc.Version)
 		mockCourseRepo.AssertExpectations(t)
 		mockFileStorage.AssertExpectations(t)
 		mockDocRepo.AssertExpectations(t)
 	})
 
+	t.Run("Success_NewVersionOfExistingTitle", func(t *testing.T) {
+		// Arrange
+		mockDocRepo := new(mocks.MockDocumentRepository)
+		mockFileStorage := new(mocks.MockFileStorage)
+		mockCourseRepo := new(mocks.MockCourseRepository)
+		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo, new(mocks.MockVectorRepository), new(mocks.MockCacheRepository))
+		var file io.Reader = bytes.NewBufferString(fileContent)
+
+		mockCourseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		mockDocRepo.On("FindLatestVersion", mock.Anything, courseID, fileName).Return(&model.Document{Base: model.Base{ID: 7}, Version: 2}, nil).Once()
+		mockFileStorage.On("Save", mock.Anything, courseID, fileName, []byte(fileContent)).Return(savedPath, nil).Once()
+		mockDocRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Document")).Return(nil).Once()
+
+		// Act
+		doc, err := fileInteractor.Upload(ctx, courseID, fileName, file)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 3, doc.Version)
+		mockDocRepo.AssertExpectations(t)
+	})
+
 	t.Run("Failure_WhenCourseNotFound", func(t *testing.T) {
 		// Arrange
 		mockDocRepo := new(mocks.MockDocumentRepository)
 		mockFileStorage := new(mocks.MockFileStorage)
 		mockCourseRepo := new(mocks.MockCourseRepository)
-		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo)
+		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo, new(mocks.MockVectorRepository), new(mocks.MockCacheRepository))
 		var file io.Reader = bytes.NewBufferString(fileContent)
 
 		mockCourseRepo.On("FindByID", mock.Anything, courseID).Return(nil, appErrors.ErrCourseNotFound).Once()
@@ -87,10 +112,11 @@ func TestFileInteractor_Upload(t *testing.T) {
 		mockDocRepo := new(mocks.MockDocumentRepository)
 		mockFileStorage := new(mocks.MockFileStorage)
 		mockCourseRepo := new(mocks.MockCourseRepository)
-		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo)
+		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo, new(mocks.MockVectorRepository), new(mocks.MockCacheRepository))
 		var file io.Reader = bytes.NewBufferString(fileContent)
 
 		mockCourseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		mockDocRepo.On("FindLatestVersion", mock.Anything, courseID, fileName).Return(nil, appErrors.ErrDocumentNotFound).Once()
 		mockFileStorage.On("Save", mock.Anything, courseID, fileName, []byte(fileContent)).Return("", errors.New("disk full")).Once()
 
 		// Act
@@ -109,10 +135,11 @@ func TestFileInteractor_Upload(t *testing.T) {
 		mockDocRepo := new(mocks.MockDocumentRepository)
 		mockFileStorage := new(mocks.MockFileStorage)
 		mockCourseRepo := new(mocks.MockCourseRepository)
-		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo)
+		fileInteractor := interactor.NewFileInteractor(mockDocRepo, mockFileStorage, mockCourseRepo, new(mocks.MockVectorRepository), new(mocks.MockCacheRepository))
 		var file io.Reader = bytes.NewBufferString(fileContent)
 
 		mockCourseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		mockDocRepo.On("FindLatestVersion", mock.Anything, courseID, fileName).Return(nil, appErrors.ErrDocumentNotFound).Once()
 		mockFileStorage.On("Save", mock.Anything, courseID, fileName, []byte(fileContent)).Return(savedPath, nil).Once()
 		mockDocRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Document")).Return(errors.New("db error")).Once()
 		mockFileStorage.On("Delete", mock.Anything, savedPath).Return(nil).Once()
@@ -127,3 +154,85 @@ func TestFileInteractor_Upload(t *testing.T) {
 		mockDocRepo.AssertExpectations(t)
 	})
 }
+
+func TestFileInteractor_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	instructorID := uint64(1)
+	courseID := uint64(101)
+	doc := &model.Document{Base: model.Base{ID: 5}, CourseID: courseID, SourceURI: "101/some-uuid-lecture.pdf"}
+	course := &model.Course{Base: model.Base{ID: courseID}, InstructorID: instructorID}
+
+	type deps struct {
+		docRepo     *mocks.MockDocumentRepository
+		fileStorage *mocks.MockFileStorage
+		courseRepo  *mocks.MockCourseRepository
+		vectorRepo  *mocks.MockVectorRepository
+		cacheRepo   *mocks.MockCacheRepository
+	}
+	setup := func() (deps, func(userID, documentID uint64) error) {
+		d := deps{
+			docRepo:     new(mocks.MockDocumentRepository),
+			fileStorage: new(mocks.MockFileStorage),
+			courseRepo:  new(mocks.MockCourseRepository),
+			vectorRepo:  new(mocks.MockVectorRepository),
+			cacheRepo:   new(mocks.MockCacheRepository),
+		}
+		fileInteractor := interactor.NewFileInteractor(d.docRepo, d.fileStorage, d.courseRepo, d.vectorRepo, d.cacheRepo)
+		return d, func(userID, documentID uint64) error {
+			return fileInteractor.Delete(ctx, userID, documentID)
+		}
+	}
+
+	t.Run("Success_RemovesVectorsRowsAndFile", func(t *testing.T) {
+		d, deleteDoc := setup()
+		d.docRepo.On("FindByID", mock.Anything, doc.ID).Return(doc, nil).Once()
+		d.courseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		d.vectorRepo.On("DeleteByDocument", mock.Anything, doc.ID).Return(nil).Once()
+		d.docRepo.On("Delete", mock.Anything, doc.ID).Return(nil).Once()
+		d.fileStorage.On("Delete", mock.Anything, doc.SourceURI).Return(nil).Once()
+		d.cacheRepo.On("Incr", mock.Anything, repository.CorpusVersionKey(courseID)).Return(int64(2), nil).Once()
+
+		err := deleteDoc(instructorID, doc.ID)
+
+		assert.NoError(t, err)
+		d.docRepo.AssertExpectations(t)
+		d.vectorRepo.AssertExpectations(t)
+		d.fileStorage.AssertExpectations(t)
+		d.cacheRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure_WhenNotInstructor", func(t *testing.T) {
+		d, deleteDoc := setup()
+		d.docRepo.On("FindByID", mock.Anything, doc.ID).Return(doc, nil).Once()
+		d.courseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+
+		err := deleteDoc(instructorID+1, doc.ID)
+
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+		d.vectorRepo.AssertNotCalled(t, "DeleteByDocument", mock.Anything, mock.Anything)
+		d.docRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failure_WhenDocumentNotFound", func(t *testing.T) {
+		d, deleteDoc := setup()
+		d.docRepo.On("FindByID", mock.Anything, doc.ID).Return(nil, appErrors.ErrDocumentNotFound).Once()
+
+		err := deleteDoc(instructorID, doc.ID)
+
+		assert.ErrorIs(t, err, appErrors.ErrNotFound)
+	})
+
+	t.Run("Failure_WhenVectorDeletionFails_KeepsRows", func(t *testing.T) {
+		d, deleteDoc := setup()
+		d.docRepo.On("FindByID", mock.Anything, doc.ID).Return(doc, nil).Once()
+		d.courseRepo.On("FindByID", mock.Anything, courseID).Return(course, nil).Once()
+		d.vectorRepo.On("DeleteByDocument", mock.Anything, doc.ID).Return(errors.New("qdrant unavailable")).Once()
+
+		err := deleteDoc(instructorID, doc.ID)
+
+		assert.ErrorIs(t, err, appErrors.ErrInternalServerError)
+		d.docRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		d.fileStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
@@ -3,17 +3,29 @@ package usecase_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/provider"
+	"github.com/takumi-1234/OpenRAGLecture/internal/interface/repository/rerank"
 	"github.com/takumi-1234/OpenRAGLecture/internal/tests/mocks"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/interactor"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/config"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 )
 
 func TestQAInteractor_Ask(t *testing.T) {
@@ -22,13 +34,36 @@ func TestQAInteractor_Ask(t *testing.T) {
 	mockVectorRepo := new(mocks.MockVectorRepository)
 	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
 	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
 
 	qaInteractor := interactor.NewQAInteractor(
 		mockDocRepo,
-		mockVectorRepo,
-		mockEmbeddingRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
 		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
 	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	// Every question without a session ID starts a new conversation.
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	// Logging runs partly in the background and is covered by its own test case.
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
 
 	askInput := input.AskInput{
 		UserID:   1,
@@ -53,8 +88,8 @@ func TestQAInteractor_Ask(t *testing.T) {
 		// これにより、errgroupが生成する *context.cancelCtx 型にもマッチするようになります。
 		// ★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★★
 		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
-		mockVectorRepo.On("Search", mock.Anything, queryVector, askInput.CourseID, 5).Return(vectorResults, nil).Once()
-		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, askInput.CourseID, 5).Return(bm25Results, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(bm25Results, nil).Once()
 
 		expectedLLMAnswer := "Retrieval-Augmented Generation (RAG) is a technique..."
 		mockLLMRepo.On("GenerateContent", mock.Anything, mock.AnythingOfType("repository.GenerateContentParams")).
@@ -70,7 +105,8 @@ func TestQAInteractor_Ask(t *testing.T) {
 
 		// Assert
 		assert.NoError(t, err)
-		assert.Equal(t, expectedLLMAnswer, answer)
+		assert.Equal(t, expectedLLMAnswer, answer.Answer)
+		assert.NotEmpty(t, answer.SessionID)
 		mockEmbeddingRepo.AssertExpectations(t)
 		mockVectorRepo.AssertExpectations(t)
 		mockDocRepo.AssertExpectations(t)
@@ -80,15 +116,15 @@ func TestQAInteractor_Ask(t *testing.T) {
 	t.Run("Success_NoResultsFound", func(t *testing.T) {
 		// Arrange
 		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
-		mockVectorRepo.On("Search", mock.Anything, queryVector, askInput.CourseID, 5).Return([]model.RetrievedChunk{}, nil).Once()
-		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, askInput.CourseID, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
 
 		// Act
 		answer, err := qaInteractor.Ask(ctx, askInput)
 
 		// Assert
 		assert.NoError(t, err)
-		assert.True(t, strings.HasPrefix(answer, "I could not find any relevant information"))
+		assert.True(t, strings.HasPrefix(answer.Answer, "I could not find any relevant information"))
 		mockLLMRepo.AssertNotCalled(t, "GenerateContent")
 	})
 
@@ -105,4 +141,1518 @@ func TestQAInteractor_Ask(t *testing.T) {
 		mockVectorRepo.AssertNotCalled(t, "Search")
 		mockDocRepo.AssertNotCalled(t, "FullTextSearch")
 	})
+
+	t.Run("Failure_EmbeddingCountMismatch", func(t *testing.T) {
+		// Arrange
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{}, nil).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.EqualError(t, err, "embedding provider returned 0 vectors for 1 texts")
+		mockVectorRepo.AssertNotCalled(t, "Search")
+	})
+}
+
+func TestQAInteractor_Ask_PersistsQuestionAnswerAndSources(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	// Every question without a session ID starts a new conversation.
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "RAG stands for Retrieval-Augmented Generation."}, Score: 0.9},
+		{Chunk: model.Chunk{Base: model.Base{ID: 2}, Text: "RAG combines search and generation."}, Score: 0.7},
+	}
+
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("RAG is a technique.", nil).Once()
+	mockLLMRepo.On("ModelName").Return("gemini-test").Once()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, []uint64{1, 2}).Return([]*model.Chunk{}, nil).Once()
+
+	var savedQuestion *model.Question
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.AnythingOfType("*model.Question")).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			savedQuestion = args.Get(1).(*model.Question)
+			savedQuestion.ID = 11
+		}).Once()
+	var savedAnswer *model.Answer
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.AnythingOfType("*model.Answer")).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			savedAnswer = args.Get(1).(*model.Answer)
+			savedAnswer.ID = 22
+		}).Once()
+	sourcesSaved := make(chan []*model.AnswerSource, 1)
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			sourcesSaved <- args.Get(1).([]*model.AnswerSource)
+		}).Once()
+
+	// Act
+	answer, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(22), answer.AnswerID)
+
+	assert.Equal(t, askInput.Query, savedQuestion.RawQuery)
+	assert.Equal(t, askInput.UserID, savedQuestion.UserID)
+	assert.Equal(t, askInput.CourseID, savedQuestion.CourseID)
+	assert.NotEqual(t, uuid.Nil, savedQuestion.QueryID)
+
+	assert.Equal(t, uint64(11), savedAnswer.QuestionID)
+	assert.Equal(t, "RAG is a technique.", savedAnswer.ResponseText)
+	assert.Equal(t, "gemini-test", savedAnswer.ResponseModel)
+
+	select {
+	case sources := <-sourcesSaved:
+		if assert.Len(t, sources, 2) {
+			assert.Equal(t, uint64(22), sources[0].AnswerID)
+			assert.Equal(t, uint64(1), sources[0].ChunkID)
+			assert.Equal(t, 1, sources[0].Rank)
+			assert.Equal(t, float32(0.9), sources[0].Score)
+			assert.Equal(t, 2, sources[1].Rank)
+			assert.NotEmpty(t, sources[1].ExtractedSnippet)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("answer sources were not saved")
+	}
+	mockQALogRepo.AssertExpectations(t)
+}
+
+func TestQAInteractor_Ask_RecordsModelThatAnswered(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo, mockCourseRepo, mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil), mockLLMRepo,
+		rerank.NewRRFReranker(), mockQALogRepo, mockConvRepo, mockCacheRepo,
+		model.DefaultRetrievalConfig(), interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).
+		Return([]model.RetrievedChunk{{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "RAG retrieves context."}, Score: 0.9}}, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, []uint64{1}).Return([]*model.Chunk{}, nil).Once()
+	// The primary model is down and a fallback provider answers.
+	mockLLMRepo.On("ModelName").Return("primary-model").Maybe()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			repository.GenerationInfoFromContext(args.Get(0).(context.Context)).Model = "fallback-model"
+		}).
+		Return("RAG is a technique.", nil).Once()
+
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.AnythingOfType("*model.Question")).Return(nil).Once()
+	var savedAnswer *model.Answer
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.AnythingOfType("*model.Answer")).
+		Return(nil).
+		Run(func(args mock.Arguments) { savedAnswer = args.Get(1).(*model.Answer) }).Once()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	require.NoError(t, err)
+	require.NotNil(t, savedAnswer)
+	assert.Equal(t, "fallback-model", savedAnswer.ResponseModel)
+}
+
+func TestQAInteractor_Ask_FollowUpUsesConversationHistory(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	sessionID := uuid.New().String()
+	conversation := &model.Conversation{Base: model.Base{ID: 7}, SessionID: sessionID, UserID: 1, CourseID: 101}
+	history := []*model.ConversationMessage{
+		{Role: model.MessageRoleUser, Content: "What is a B-tree?"},
+		{Role: model.MessageRoleAssistant, Content: "A B-tree is a self-balancing tree."},
+	}
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "Why do databases use it?", SessionID: sessionID}
+	standaloneQuery := "Why do databases use B-trees?"
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "B-trees keep disk reads low."}, Score: 0.9},
+	}
+
+	t.Run("Success_RewritesQueryAndPassesHistory", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", mock.Anything, sessionID).Return(conversation, nil).Once()
+		mockCacheRepo.On("Get", mock.Anything, "conversation:"+sessionID+":recent").Return("", nil).Once()
+		mockConvRepo.On("ListMessages", mock.Anything, uint64(7), 6).Return(history, nil).Once()
+		mockCacheRepo.On("Set", mock.Anything, "conversation:"+sessionID+":recent", mock.Anything, mock.Anything).Return(nil).Twice()
+
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+			return params.UserPrompt == askInput.Query && len(params.ContextChunks) == 0
+		})).Return(standaloneQuery+"\n", nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				assert.Contains(t, params.SystemPrompt, "user: What is a B-tree?")
+			}).Once()
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{standaloneQuery}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, standaloneQuery, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+			return len(params.ContextChunks) > 0
+		})).Return("Because they keep disk reads low [1].", nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				assert.Equal(t, askInput.Query, params.UserPrompt)
+				assert.Len(t, params.History, 2)
+			}).Once()
+
+		var savedQuestion *model.Question
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+		mockConvRepo.On("AddMessages", mock.Anything, uint64(7), mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) {
+				messages := args.Get(2).([]*model.ConversationMessage)
+				if assert.Len(t, messages, 2) {
+					assert.Equal(t, model.MessageRoleUser, messages[0].Role)
+					assert.Equal(t, askInput.Query, messages[0].Content)
+					assert.Equal(t, model.MessageRoleAssistant, messages[1].Role)
+				}
+			}).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, sessionID, answer.SessionID)
+		assert.Equal(t, "Because they keep disk reads low [1].", answer.Answer)
+		assert.Equal(t, standaloneQuery, savedQuestion.TracingMeta["standalone_query"])
+		mockConvRepo.AssertExpectations(t)
+		mockCacheRepo.AssertExpectations(t)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure_SessionOfAnotherUser", func(t *testing.T) {
+		// Arrange
+		mockConvRepo.On("FindBySessionID", mock.Anything, sessionID).Return(conversation, nil).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, input.AskInput{UserID: 2, CourseID: 101, Query: "Hi", SessionID: sessionID})
+
+		// Assert
+		assert.ErrorIs(t, err, appErrors.ErrConversationNotFound)
+		mockEmbeddingRepo.AssertNumberOfCalls(t, "CreateEmbeddings", 1)
+	})
+}
+
+func TestQAInteractor_Ask_QueryExpansion(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}, UseLLMExpansion: true}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	isExpansionRequest := mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+		return strings.Contains(params.SystemPrompt, "hypothetical_answer")
+	})
+
+	t.Run("Success_FusesResultsOfExpandedQueries", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "How do B-trees stay balanced?"}
+		mockLLMRepo.On("GenerateContent", mock.Anything, isExpansionRequest).
+			Return("```json\n{\"ambiguity\": 0.1, \"clarifying_question\": \"\", \"paraphrases\": [\"B-tree rebalancing\"], \"hypothetical_answer\": \"Nodes are split and merged.\"}\n```", nil).Once()
+
+		texts := []string{askInput.Query, "B-tree rebalancing", "Nodes are split and merged."}
+		vectors := [][]float32{{0.1}, {0.2}, {0.3}}
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, texts, "RETRIEVAL_QUERY").Return(vectors, nil).Once()
+		for idx, text := range texts {
+			mockDocRepo.On("FullTextSearch", mock.Anything, text, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+			mockVectorRepo.On("Search", mock.Anything, vectors[idx], model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{
+				{Chunk: model.Chunk{Base: model.Base{ID: uint64(idx + 1)}, Text: text}, Score: 0.5},
+				{Chunk: model.Chunk{Base: model.Base{ID: 9}, Text: "Splitting full nodes keeps B-trees balanced."}, Score: 0.4},
+			}, nil).Once()
+		}
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+			return len(params.ContextChunks) > 0
+		})).Return("By splitting full nodes [1].", nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				assert.Equal(t, uint64(9), params.ContextChunks[0].Chunk.ID, "a chunk found by every search text ranks first")
+			}).Once()
+
+		var savedQuestion *model.Question
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, answer.NeedsClarification)
+		assert.True(t, savedQuestion.UseLLMExpansion)
+		assert.Equal(t, float32(0.1), savedQuestion.AmbiguityScore)
+		assert.Equal(t, "B-tree rebalancing\nNodes are split and merged.", savedQuestion.ExpandedQuery)
+		mockEmbeddingRepo.AssertExpectations(t)
+		mockVectorRepo.AssertExpectations(t)
+		mockDocRepo.AssertExpectations(t)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_AmbiguousQueryAsksForClarification", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What about the second one?"}
+		mockLLMRepo.On("GenerateContent", mock.Anything, isExpansionRequest).
+			Return(`{"ambiguity": 0.9, "clarifying_question": "Which topic do you mean?", "paraphrases": [], "hypothetical_answer": ""}`, nil).Once()
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, answer.NeedsClarification)
+		assert.Equal(t, "Which topic do you mean?", answer.Answer)
+		assert.Empty(t, answer.Citations)
+		mockEmbeddingRepo.AssertNumberOfCalls(t, "CreateEmbeddings", 1) // Only the call from the previous case
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_ExpansionFailureFallsBackToQuery", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is a heap?"}
+		mockLLMRepo.On("GenerateContent", mock.Anything, isExpansionRequest).Return("not json", nil).Once()
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Once()
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{{0.4}}, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, []float32{0.4}, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, answer.NeedsClarification)
+		assert.True(t, strings.HasPrefix(answer.Answer, "I could not find any relevant information"))
+		mockEmbeddingRepo.AssertExpectations(t)
+	})
+}
+
+func TestQAInteractor_Ask_RerankStage(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockReranker := new(mocks.MockReranker)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		mockReranker,
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.RetrievalConfig{BM25TopK: 5, VectorTopK: 5, BM25Weight: 1, VectorWeight: 1, RerankCandidates: 4, ContextSize: 2, MMRLambda: 1},
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is a heap?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	var vectorResults []model.RetrievedChunk
+	for id := uint64(1); id <= 5; id++ {
+		vectorResults = append(vectorResults, model.RetrievedChunk{Chunk: model.Chunk{Base: model.Base{ID: id}, Text: fmt.Sprintf("chunk %d", id)}, Score: 0.5})
+	}
+	candidatesWithIDs := func(ids ...uint64) interface{} {
+		return mock.MatchedBy(func(candidates []model.RetrievedChunk) bool {
+			if len(candidates) != len(ids) {
+				return false
+			}
+			for idx, id := range ids {
+				if candidates[idx].Chunk.ID != id {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	arrangeSearch := func() {
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	}
+
+	t.Run("Success_UsesRerankedChunks", func(t *testing.T) {
+		// Arrange
+		arrangeSearch()
+		mockReranker.On("Rerank", mock.Anything, askInput.Query, candidatesWithIDs(1, 2, 3, 4), 2).
+			Return([]model.RetrievedChunk{vectorResults[3], vectorResults[1]}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				if assert.Len(t, params.ContextChunks, 2) {
+					assert.Equal(t, uint64(4), params.ContextChunks[0].Chunk.ID)
+					assert.Equal(t, uint64(2), params.ContextChunks[1].Chunk.ID)
+				}
+			}).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		mockReranker.AssertExpectations(t)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_FallsBackToFusedRankingOnError", func(t *testing.T) {
+		// Arrange
+		arrangeSearch()
+		mockReranker.On("Rerank", mock.Anything, askInput.Query, mock.Anything, 2).Return(nil, fmt.Errorf("LLM unavailable")).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				if assert.Len(t, params.ContextChunks, 2) {
+					assert.Equal(t, uint64(1), params.ContextChunks[0].Chunk.ID)
+				}
+			}).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		mockReranker.AssertExpectations(t)
+	})
+
+	t.Run("Success_EqualFusedScoresKeepSearchOrder", func(t *testing.T) {
+		// Arrange: both chunks are ranked first by one search and second by the other.
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).
+			Return([]model.RetrievedChunk{vectorResults[1], vectorResults[0]}, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).
+			Return([]model.RetrievedChunk{vectorResults[0], vectorResults[1]}, nil).Once()
+		mockReranker.On("Rerank", mock.Anything, askInput.Query, candidatesWithIDs(1, 2), 2).
+			Return([]model.RetrievedChunk{vectorResults[0], vectorResults[1]}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		mockReranker.AssertExpectations(t)
+	})
+}
+
+func TestQAInteractor_Ask_DiversifiesContext(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	retrievalCfg := model.DefaultRetrievalConfig()
+	retrievalCfg.ContextSize = 2
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		retrievalCfg,
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is a heap?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	newChunk := func(id uint64, embeddingID, vectorHash string) model.RetrievedChunk {
+		return model.RetrievedChunk{Chunk: model.Chunk{
+			Base:        model.Base{ID: id},
+			Text:        fmt.Sprintf("chunk %d", id),
+			EmbeddingID: embeddingID,
+			VectorHash:  vectorHash,
+		}}
+	}
+	vectorResults := []model.RetrievedChunk{
+		newChunk(1, "e1", "h1"),
+		newChunk(2, "e2", "h1"), // Same slide uploaded twice
+		newChunk(3, "e3", "h3"), // Overlaps chunk 1
+		newChunk(4, "e4", "h4"),
+	}
+
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockVectorRepo.On("GetVectors", mock.Anything, []string{"e1", "e3", "e4"}).Return([]model.ChunkVector{
+		{Chunk: model.Chunk{EmbeddingID: "e1"}, Vector: []float32{1, 0}},
+		{Chunk: model.Chunk{EmbeddingID: "e3"}, Vector: []float32{0.99, 0.1}},
+		{Chunk: model.Chunk{EmbeddingID: "e4"}, Vector: []float32{0, 1}},
+	}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repository.GenerateContentParams)
+			if assert.Len(t, params.ContextChunks, 2) {
+				assert.Equal(t, uint64(1), params.ContextChunks[0].Chunk.ID)
+				assert.Equal(t, uint64(4), params.ContextChunks[1].Chunk.ID, "the near-duplicate chunk 3 is passed over")
+			}
+		}).Once()
+
+	// Act
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVectorRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+}
+
+func TestQAInteractor_Ask_RetrievalOverrides(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	vectorTopK, contextSize := 8, 1
+	minScore := float32(0.5)
+	bm25TopK := 0
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(&model.CourseSettings{
+		CourseID:  101,
+		Retrieval: model.RetrievalOverrides{VectorTopK: &vectorTopK, ContextSize: &contextSize, MinScore: &minScore},
+	}, nil)
+
+	askInput := input.AskInput{
+		UserID:    1,
+		CourseID:  101,
+		Query:     "What is a trie?",
+		Retrieval: &model.RetrievalOverrides{BM25TopK: &bm25TopK}, // The request disables full-text search
+	}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 8).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "A trie is a prefix tree."}, Score: 0.3},
+		{Chunk: model.Chunk{Base: model.Base{ID: 2}, Text: "Tries store strings by prefix."}, Score: 0.7},
+		{Chunk: model.Chunk{Base: model.Base{ID: 3}, Text: "Prefix trees support autocomplete."}, Score: 0.6},
+	}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("A prefix tree [1].", nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repository.GenerateContentParams)
+			if assert.Len(t, params.ContextChunks, 1, "the course limits the context to one chunk") {
+				assert.Equal(t, uint64(2), params.ContextChunks[0].Chunk.ID, "hits below the minimum score are dropped")
+			}
+		}).Once()
+	var savedQuestion *model.Question
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+	// Act
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	mockDocRepo.AssertNotCalled(t, "FullTextSearch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockVectorRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+
+	expected := model.DefaultRetrievalConfig()
+	expected.BM25TopK = 0
+	expected.VectorTopK = 8
+	expected.ContextSize = 1
+	expected.MinScore = 0.5
+	assert.Equal(t, expected, savedQuestion.TracingMeta["retrieval"])
+}
+
+func TestQAInteractor_Ask_SearchFilter(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{TTL: time.Hour},
+	)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil)
+
+	askInput := input.AskInput{
+		UserID:   1,
+		CourseID: 101,
+		Query:    "What is a trie?",
+		// The course ID of the filter is taken from the request, not from the filter.
+		Filter: &model.SearchFilter{CourseID: 999, DocumentIDs: []uint64{5}, DocType: model.DocTypeSlides},
+	}
+	filter := model.SearchFilter{CourseID: 101, DocumentIDs: []uint64{5}, DocType: model.DocTypeSlides}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, filter, 5).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "A trie is a prefix tree."}, Score: 0.8},
+	}, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, filter, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("A prefix tree [1].", nil).Once()
+	var savedQuestion *model.Question
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+	// Act
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVectorRepo.AssertExpectations(t)
+	mockDocRepo.AssertExpectations(t)
+	// Answers to filtered questions are neither looked up in nor written to the answer cache.
+	mockCacheRepo.AssertNotCalled(t, "Get", mock.Anything, repository.CorpusVersionKey(101))
+	mockCacheRepo.AssertNotCalled(t, "Set", mock.Anything,
+		mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "answer:") }), mock.Anything, mock.Anything)
+	assert.Equal(t, filter, savedQuestion.TracingMeta["filter"])
+}
+
+func TestQAInteractor_Ask_SparseKeywordSearch(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	retrievalCfg := model.DefaultRetrievalConfig()
+	retrievalCfg.KeywordSearch = model.KeywordSearchSparse
+	retrievalCfg.BM25Weight = 2
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		retrievalCfg,
+		interactor.AnswerCacheConfig{},
+	)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil)
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "トライ木とは"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("HybridSearch", mock.Anything, model.HybridQuery{
+		Text:         askInput.Query,
+		Vector:       queryVector,
+		DenseLimit:   5,
+		SparseLimit:  5,
+		DenseWeight:  1,
+		SparseWeight: 2,
+		RRFK:         60,
+	}, model.SearchFilter{CourseID: 101}).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "トライ木は接頭辞木である。"}, Score: 0.05},
+	}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+		return strings.Contains(params.SystemPrompt, "トライ木は接頭辞木である。")
+	})).Return("接頭辞木です [1]。", nil).Once()
+
+	// Act
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	mockVectorRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+	mockVectorRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockDocRepo.AssertNotCalled(t, "FullTextSearch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestQAInteractor_Ask_SparseKeywordSearchFallsBackWithoutSparseVectors(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	// The active index was built before the collection had a sparse vector.
+	indexes := new(mocks.MockSearchIndexProvider)
+	indexes.On("ActiveSearchIndex", mock.Anything).Return(&repository.SearchIndex{
+		EmbeddingRepo: mockEmbeddingRepo,
+		VectorRepo:    mockVectorRepo,
+	}, nil)
+	retrievalCfg := model.DefaultRetrievalConfig()
+	retrievalCfg.KeywordSearch = model.KeywordSearchSparse
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		indexes,
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		retrievalCfg,
+		interactor.AnswerCacheConfig{},
+	)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil)
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "トライ木とは"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: 101}, 5).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "トライ木は接頭辞木である。"}, Score: 3},
+	}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: 101}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.MatchedBy(func(params repository.GenerateContentParams) bool {
+		return strings.Contains(params.SystemPrompt, "トライ木は接頭辞木である。")
+	})).Return("接頭辞木です [1]。", nil).Once()
+
+	// Act
+	_, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	mockDocRepo.AssertExpectations(t)
+	mockVectorRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+	mockVectorRepo.AssertNotCalled(t, "HybridSearch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestQAInteractor_Ask_ReturnsCitations(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	// Every question without a session ID starts a new conversation.
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is a B-tree?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, DocumentID: 10, Text: "A B-tree is a self-balancing tree."}, Score: 0.9},
+		{Chunk: model.Chunk{Base: model.Base{ID: 2}, DocumentID: 20, Text: "B-trees are used by databases."}, Score: 0.8},
+		{Chunk: model.Chunk{Base: model.Base{ID: 3}, DocumentID: 20, Text: "Hash indexes do not support ranges."}, Score: 0.7},
+	}
+	storedChunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, DocumentID: 10, PageID: 100, Page: model.Page{PageNumber: 4}, Document: model.Document{Title: "Lecture 5 Slides"}},
+		{Base: model.Base{ID: 2}, DocumentID: 20, PageID: 200, Page: model.Page{PageNumber: 12}, Document: model.Document{Title: "Database Notes"}},
+		{Base: model.Base{ID: 3}, DocumentID: 20, PageID: 201, Page: model.Page{PageNumber: 13}, Document: model.Document{Title: "Database Notes"}},
+	}
+
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, []uint64{1, 2, 3}).Return(storedChunks, nil).Once()
+	mockDocRepo.On("FindChunksByIndexRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.AnythingOfType("repository.GenerateContentParams")).
+		Return("Databases use B-trees [2]. A B-tree is self-balancing [1, 2]. See also [9].", nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repository.GenerateContentParams)
+			assert.Contains(t, params.SystemPrompt, "=== Lecture 5 Slides ===\n--- p. 4 ---\n[1] A B-tree is a self-balancing tree.")
+			assert.Contains(t, params.SystemPrompt, "=== Database Notes ===\n--- p. 12 ---\n[2] B-trees are used by databases.")
+			assert.Contains(t, params.SystemPrompt, "--- p. 13 ---\n[3] Hash indexes do not support ranges.")
+		}).Once()
+
+	// Act
+	answer, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, answer.QueryID)
+	if assert.Len(t, answer.Citations, 2, "only markers that refer to a context chunk are cited") {
+		assert.Equal(t, 2, answer.Citations[0].Index)
+		assert.Equal(t, uint64(2), answer.Citations[0].ChunkID)
+		assert.Equal(t, uint64(20), answer.Citations[0].DocumentID)
+		assert.Equal(t, "Database Notes", answer.Citations[0].DocumentTitle)
+		assert.Equal(t, 12, answer.Citations[0].PageNumber)
+		assert.Equal(t, float32(0.8), answer.Citations[0].Score)
+		assert.Equal(t, "B-trees are used by databases.", answer.Citations[0].Snippet)
+		assert.Equal(t, 1, answer.Citations[1].Index)
+		assert.Equal(t, "Lecture 5 Slides", answer.Citations[1].DocumentTitle)
+		assert.Equal(t, 4, answer.Citations[1].PageNumber)
+	}
+	mockDocRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+}
+
+func TestQAInteractor_Ask_ContextAssembly(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+
+	// Page 1 of "Fox Notes" is split into three overlapping chunks:
+	// "The quick brown fox jumps over the lazy dog."
+	foxDoc := model.Document{Base: model.Base{ID: 10}, Title: "Fox Notes"}
+	foxPage := model.Page{Base: model.Base{ID: 100}, DocumentID: 10, PageNumber: 1}
+	foxChunks := []*model.Chunk{
+		{Base: model.Base{ID: 1}, DocumentID: 10, PageID: 100, ChunkIndex: 0, StartOffset: 0, EndOffset: 20, TokenCount: 30, Text: "The quick brown fox ", Page: foxPage, Document: foxDoc},
+		{Base: model.Base{ID: 2}, DocumentID: 10, PageID: 100, ChunkIndex: 1, StartOffset: 15, EndOffset: 35, TokenCount: 30, Text: " fox jumps over the ", Page: foxPage, Document: foxDoc},
+		{Base: model.Base{ID: 3}, DocumentID: 10, PageID: 100, ChunkIndex: 2, StartOffset: 30, EndOffset: 44, TokenCount: 30, Text: " the lazy dog.", Page: foxPage, Document: foxDoc},
+	}
+	dogDoc := model.Document{Base: model.Base{ID: 20}, Title: "Dog Notes"}
+	dogChunks := []*model.Chunk{
+		{Base: model.Base{ID: 5}, DocumentID: 20, PageID: 203, ChunkIndex: 7, StartOffset: 0, EndOffset: 16, TokenCount: 20, Text: "Dogs are lazy.", Page: model.Page{PageNumber: 3}, Document: dogDoc},
+		{Base: model.Base{ID: 6}, DocumentID: 20, PageID: 204, ChunkIndex: 8, StartOffset: 0, EndOffset: 40, TokenCount: 50, Text: "Foxes are quick.", Page: model.Page{PageNumber: 4}, Document: dogDoc},
+	}
+
+	// The best chunk fits with its neighbors (90 tokens); the second one only fits alone (20 tokens).
+	budget := 120
+	askInput := input.AskInput{
+		UserID: 1, CourseID: 101, Query: "What does the fox jump over?",
+		Retrieval: &model.RetrievalOverrides{ContextTokenBudget: &budget},
+	}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 2}, DocumentID: 10, Text: foxChunks[1].Text}, Score: 0.9},
+		{Chunk: model.Chunk{Base: model.Base{ID: 5}, DocumentID: 20, Text: dogChunks[0].Text}, Score: 0.8},
+	}
+
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, []uint64{2, 5}).Return([]*model.Chunk{foxChunks[1], dogChunks[0]}, nil).Once()
+	mockDocRepo.On("FindChunksByIndexRange", mock.Anything, uint64(10), 0, 2).Return(foxChunks, nil).Once()
+	mockDocRepo.On("FindChunksByIndexRange", mock.Anything, uint64(20), 6, 8).Return(dogChunks, nil).Once()
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.AnythingOfType("repository.GenerateContentParams")).
+		Return("It jumps over the lazy dog [1].", nil).
+		Run(func(args mock.Arguments) {
+			params := args.Get(1).(repository.GenerateContentParams)
+			assert.Contains(t, params.SystemPrompt,
+				"=== Fox Notes ===\n--- p. 1 ---\n[1] The quick brown fox jumps over the lazy dog.\n\n"+
+					"=== Dog Notes ===\n--- p. 3 ---\n[2] Dogs are lazy.\n\n",
+				"overlapping neighbors are merged into one passage")
+			assert.NotContains(t, params.SystemPrompt, "Foxes are quick.", "neighbors that exceed the token budget are dropped")
+		}).Once()
+
+	// Act
+	answer, err := qaInteractor.Ask(ctx, askInput)
+
+	// Assert
+	assert.NoError(t, err)
+	if assert.Len(t, answer.Citations, 1) {
+		assert.Equal(t, uint64(2), answer.Citations[0].ChunkID, "a passage is cited through its retrieved chunk")
+		assert.Equal(t, float32(0.9), answer.Citations[0].Score)
+		assert.Equal(t, "Fox Notes", answer.Citations[0].DocumentTitle)
+	}
+	mockDocRepo.AssertExpectations(t)
+	mockLLMRepo.AssertExpectations(t)
+}
+
+func TestQAInteractor_Ask_AnswerCache(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{TTL: time.Hour},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "conversation:") }), mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockCacheRepo.On("Get", mock.Anything, "course:101:corpus_version").Return("3", nil).Maybe()
+
+	queryHash := sha256.Sum256([]byte("what is rag"))
+	answerKey := "answer:101:v3:" + hex.EncodeToString(queryHash[:])
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "RAG stands for Retrieval-Augmented Generation."}, Score: 0.9},
+	}
+
+	var cachedValue string
+	t.Run("Success_MissGeneratesAndStoresAnswer", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?"}
+		mockCacheRepo.On("Get", mock.Anything, answerKey).Return("", nil).Once()
+		mockCacheRepo.On("Incr", mock.Anything, "stats:answer_cache:101:misses").Return(int64(1), nil).Once()
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("RAG is Retrieval-Augmented Generation [1].", nil).Once()
+		mockCacheRepo.On("Set", mock.Anything, answerKey, mock.Anything, time.Hour).Return(nil).
+			Run(func(args mock.Arguments) { cachedValue = args.Get(2).(string) }).Once()
+		var savedQuestion *model.Question
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "RAG is Retrieval-Augmented Generation [1].", answer.Answer)
+		assert.Equal(t, false, savedQuestion.TracingMeta["answer_cache_hit"])
+		var entry map[string]interface{}
+		if assert.NoError(t, json.Unmarshal([]byte(cachedValue), &entry)) {
+			assert.Equal(t, answer.Answer, entry["answer"])
+			assert.Len(t, entry["sources"], 1)
+		}
+		mockCacheRepo.AssertExpectations(t)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_HitSkipsRetrievalAndGeneration", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 2, CourseID: 101, Query: "  what is   RAG "}
+		mockCacheRepo.On("Get", mock.Anything, answerKey).Return(cachedValue, nil).Once()
+		mockCacheRepo.On("Incr", mock.Anything, "stats:answer_cache:101:hits").Return(int64(1), nil).Once()
+		var savedQuestion *model.Question
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "RAG is Retrieval-Augmented Generation [1].", answer.Answer)
+		assert.NotEmpty(t, answer.SessionID)
+		if assert.Len(t, answer.Citations, 1) {
+			assert.Equal(t, uint64(1), answer.Citations[0].ChunkID)
+		}
+		assert.Equal(t, true, savedQuestion.TracingMeta["answer_cache_hit"])
+		mockEmbeddingRepo.AssertNumberOfCalls(t, "CreateEmbeddings", 1)
+		mockLLMRepo.AssertNumberOfCalls(t, "GenerateContent", 1)
+		mockCacheRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success_RequestOverridesBypassCache", func(t *testing.T) {
+		// Arrange
+		contextSize := 1
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?", Retrieval: &model.RetrievalOverrides{ContextSize: &contextSize}}
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("answer", nil).Once()
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Act
+		_, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		// Only the two previous questions looked up the cache.
+		mockCacheRepo.AssertNumberOfCalls(t, "Get", 4)
+		mockCacheRepo.AssertNumberOfCalls(t, "Incr", 2)
+		mockLLMRepo.AssertExpectations(t)
+	})
+}
+
+func TestQAInteractor_Ask_SemanticAnswerCache(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+	mockSemanticCache := new(mocks.MockSemanticCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, mockSemanticCache),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{TTL: time.Hour, SimilarityThreshold: 0.9},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Incr", mock.Anything, mock.Anything).Return(int64(1), nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockCacheRepo.On("Get", mock.Anything, "course:101:corpus_version").Return("3", nil).Maybe()
+	// Exact matches never hit: every question below is worded differently.
+	mockCacheRepo.On("Get", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "answer:101:v3:") })).Return("", nil)
+
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "A B-tree is a self-balancing search tree."}, Score: 0.9},
+	}
+
+	var saved *model.SemanticCacheEntry
+	t.Run("Success_MissReusesEmbeddingAndStoresEntry", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is a B-tree?"}
+		queryVector := []float32{1, 0, 0}
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockSemanticCache.On("FindNearest", mock.Anything, uint64(101), "3", queryVector, mock.Anything).Return(nil, float32(0), nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("A B-tree is a balanced search tree [1].", nil).Once()
+		mockSemanticCache.On("Save", mock.Anything, mock.AnythingOfType("*model.SemanticCacheEntry")).Return(nil).
+			Run(func(args mock.Arguments) { saved = args.Get(1).(*model.SemanticCacheEntry) }).Once()
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, answer.Cached)
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, uint64(101), saved.CourseID)
+			assert.Equal(t, "3", saved.CorpusVersion)
+			assert.Equal(t, queryVector, saved.Vector)
+			assert.Contains(t, saved.Answer, answer.Answer)
+		}
+		// The query was embedded once, for both the cache lookup and retrieval.
+		mockEmbeddingRepo.AssertNumberOfCalls(t, "CreateEmbeddings", 1)
+		mockSemanticCache.AssertExpectations(t)
+	})
+
+	t.Run("Success_ParaphraseServedFromCache", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 2, CourseID: 101, Query: "Explain B-trees"}
+		queryVector := []float32{0.99, 0.1, 0}
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockSemanticCache.On("FindNearest", mock.Anything, uint64(101), "3", queryVector, mock.Anything).Return(saved, float32(0.95), nil).Once()
+		var savedQuestion *model.Question
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).
+			Run(func(args mock.Arguments) { savedQuestion = args.Get(1).(*model.Question) }).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, answer.Cached)
+		assert.Equal(t, "A B-tree is a balanced search tree [1].", answer.Answer)
+		assert.Len(t, answer.Citations, 1)
+		assert.Equal(t, float32(0.95), savedQuestion.TracingMeta["answer_cache_similarity"])
+		mockLLMRepo.AssertNumberOfCalls(t, "GenerateContent", 1)
+		mockVectorRepo.AssertNumberOfCalls(t, "Search", 1)
+		mockSemanticCache.AssertNumberOfCalls(t, "Save", 1)
+	})
+
+	t.Run("Success_DissimilarQuestionGenerates", func(t *testing.T) {
+		// Arrange
+		askInput := input.AskInput{UserID: 2, CourseID: 101, Query: "What is a hash table?"}
+		queryVector := []float32{0, 1, 0}
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockSemanticCache.On("FindNearest", mock.Anything, uint64(101), "3", queryVector, mock.Anything).Return(saved, float32(0.4), nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("A hash table maps keys to buckets.", nil).Once()
+		mockSemanticCache.On("Save", mock.Anything, mock.AnythingOfType("*model.SemanticCacheEntry")).Return(nil).Once()
+		mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Once()
+
+		// Act
+		answer, err := qaInteractor.Ask(ctx, askInput)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, answer.Cached)
+		assert.Equal(t, "A hash table maps keys to buckets.", answer.Answer)
+		mockLLMRepo.AssertExpectations(t)
+		mockSemanticCache.AssertExpectations(t)
+	})
+}
+
+func TestQAInteractor_Ask_ResolvesSearchIndexOncePerQuestion(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+	// The index switches right after the question resolved it; the repositories of the
+	// new index fail the test if they are used.
+	indexes := new(mocks.MockSearchIndexProvider)
+	indexes.On("ActiveSearchIndex", mock.Anything).Return(&repository.SearchIndex{EmbeddingRepo: mockEmbeddingRepo, VectorRepo: mockVectorRepo}, nil).Once()
+	indexes.On("ActiveSearchIndex", mock.Anything).Return(&repository.SearchIndex{
+		EmbeddingRepo: new(mocks.MockEmbeddingRepository),
+		VectorRepo:    new(mocks.MockVectorRepository),
+	}, nil)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		indexes,
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil)
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil)
+	mockLLMRepo.On("ModelName").Return("test-model")
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil)
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "RAG stands for Retrieval-Augmented Generation."}, Score: 0.9},
+	}, nil).Once()
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil)
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("RAG is Retrieval-Augmented Generation [1].", nil)
+
+	answer, err := qaInteractor.Ask(ctx, askInput)
+
+	require.NoError(t, err)
+	assert.Len(t, answer.Citations, 1)
+	indexes.AssertNumberOfCalls(t, "ActiveSearchIndex", 1)
+	mockEmbeddingRepo.AssertExpectations(t)
+	mockVectorRepo.AssertExpectations(t)
+}
+
+func TestQAInteractor_Ask_UnreachableRedisDisablesAnswerCache(t *testing.T) {
+	ctx := context.Background()
+	cacheRepo, cacheCfg := provider.NewCacheRepository(config.CacheConfig{
+		Redis:             config.RedisConfig{Host: "127.0.0.1", Port: "1"},
+		AnswerTTLMinutes:  60,
+		SemanticThreshold: 0.9,
+	})
+	assert.Zero(t, cacheCfg.AnswerTTLMinutes)
+	assert.Zero(t, cacheCfg.SemanticThreshold)
+
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockSemanticCache := new(mocks.MockSemanticCacheRepository) // Any call fails the test
+
+	// The cache settings are wired like in cmd/api.
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, mockSemanticCache),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		cacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{
+			TTL:                 time.Duration(cacheCfg.AnswerTTLMinutes) * time.Minute,
+			SimilarityThreshold: cacheCfg.SemanticThreshold,
+		},
+	)
+	askInput := input.AskInput{UserID: 1, CourseID: 101, Query: "What is RAG?"}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil)
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil)
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil)
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil)
+	mockLLMRepo.On("ModelName").Return("test-model")
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil)
+	mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil)
+	mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, Text: "RAG stands for Retrieval-Augmented Generation."}, Score: 0.9},
+	}, nil)
+	mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil)
+	mockLLMRepo.On("GenerateContent", mock.Anything, mock.Anything).Return("RAG is Retrieval-Augmented Generation [1].", nil)
+
+	for range 2 {
+		answer, err := qaInteractor.Ask(ctx, askInput)
+		require.NoError(t, err)
+		assert.False(t, answer.Cached)
+	}
+
+	// Both questions were answered by the LLM.
+	mockLLMRepo.AssertNumberOfCalls(t, "GenerateContent", 2)
+	mockSemanticCache.AssertNotCalled(t, "FindNearest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// recordingStreamWriter is a port.AskStreamWriter that records what it receives.
+type recordingStreamWriter struct {
+	strings.Builder
+	sources []output.CitationOutput
+}
+
+func (w *recordingStreamWriter) WriteSources(sources []output.CitationOutput) error {
+	w.sources = sources
+	return nil
+}
+
+func TestQAInteractor_AskStream(t *testing.T) {
+	ctx := context.Background()
+	mockDocRepo := new(mocks.MockDocumentRepository)
+	mockVectorRepo := new(mocks.MockVectorRepository)
+	mockEmbeddingRepo := new(mocks.MockEmbeddingRepository)
+	mockLLMRepo := new(mocks.MockLLMRepository)
+	mockCourseRepo := new(mocks.MockCourseRepository)
+	mockQALogRepo := new(mocks.MockQALogRepository)
+	mockConvRepo := new(mocks.MockConversationRepository)
+	mockCacheRepo := new(mocks.MockCacheRepository)
+
+	qaInteractor := interactor.NewQAInteractor(
+		mockDocRepo,
+		mockCourseRepo,
+		mocks.NewSearchIndexProvider(mockEmbeddingRepo, mockVectorRepo, nil),
+		mockLLMRepo,
+		rerank.NewRRFReranker(),
+		mockQALogRepo,
+		mockConvRepo,
+		mockCacheRepo,
+		model.DefaultRetrievalConfig(),
+		interactor.AnswerCacheConfig{},
+	)
+	mockCourseRepo.On("FindByID", mock.Anything, uint64(101)).Return(&model.Course{Base: model.Base{ID: 101}}, nil).Maybe()
+	mockCourseRepo.On("FindSettings", mock.Anything, uint64(101)).Return(nil, nil).Maybe()
+	// Every question without a session ID starts a new conversation.
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*model.Conversation")).Return(nil).Maybe()
+	mockConvRepo.On("AddMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockCacheRepo.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	// Logging runs partly in the background and is covered by its own test case.
+	mockQALogRepo.On("CreateQuestion", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswer", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockQALogRepo.On("CreateAnswerSources", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockLLMRepo.On("ModelName").Return("test-model").Maybe()
+	mockDocRepo.On("FindChunksByIDs", mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+	mockDocRepo.On("FindChunksByIndexRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*model.Chunk{}, nil).Maybe()
+
+	askInput := input.AskInput{
+		UserID:   1,
+		CourseID: 101,
+		Query:    "What is RAG?",
+	}
+	queryVector := []float32{0.1, 0.2, 0.3}
+	vectorResults := []model.RetrievedChunk{
+		{Chunk: model.Chunk{Base: model.Base{ID: 1}, DocumentID: 10, Text: "RAG stands for Retrieval-Augmented Generation."}, Score: 0.9},
+	}
+
+	t.Run("Success_UsesRetrievalPipeline", func(t *testing.T) {
+		// Arrange
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(vectorResults, nil).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		mockLLMRepo.On("GenerateContentStream", mock.Anything, mock.AnythingOfType("repository.GenerateContentParams"), mock.Anything).
+			Return(nil).
+			Run(func(args mock.Arguments) {
+				params := args.Get(1).(repository.GenerateContentParams)
+				assert.Contains(t, params.SystemPrompt, "RAG stands for")
+				assert.Len(t, params.ContextChunks, 1)
+				_, _ = args.Get(2).(io.Writer).Write([]byte("streamed answer"))
+			}).Once()
+		writer := &recordingStreamWriter{}
+
+		// Act
+		answer, err := qaInteractor.AskStream(ctx, askInput, writer)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "streamed answer", writer.String())
+		assert.Equal(t, "streamed answer", answer.Answer)
+		if assert.Len(t, writer.sources, 1) {
+			assert.Equal(t, 1, writer.sources[0].Index)
+			assert.Equal(t, uint64(1), writer.sources[0].ChunkID)
+			assert.Equal(t, uint64(10), writer.sources[0].DocumentID)
+		}
+		mockEmbeddingRepo.AssertExpectations(t)
+		mockVectorRepo.AssertExpectations(t)
+		mockDocRepo.AssertExpectations(t)
+		mockLLMRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failure_SearchFails", func(t *testing.T) {
+		// Arrange
+		mockEmbeddingRepo.On("CreateEmbeddings", mock.Anything, []string{askInput.Query}, "RETRIEVAL_QUERY").Return([][]float32{queryVector}, nil).Once()
+		mockVectorRepo.On("Search", mock.Anything, queryVector, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return(nil, fmt.Errorf("qdrant down")).Once()
+		mockDocRepo.On("FullTextSearch", mock.Anything, askInput.Query, model.SearchFilter{CourseID: askInput.CourseID}, 5).Return([]model.RetrievedChunk{}, nil).Once()
+		writer := &recordingStreamWriter{}
+
+		// Act
+		_, err := qaInteractor.AskStream(ctx, askInput, writer)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "vector search failed")
+		assert.Nil(t, writer.sources)
+		assert.Empty(t, writer.String())
+	})
 }
@@ -1,10 +1,14 @@
 // OpenRAGLecture/internal/usecase/input/qa_input.go
 package input
 
+import "github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+
 // AskInput represents the data for a user's question.
 type AskInput struct {
-	UserID    uint64 `json:"-"` // From JWT, not from request body
-	CourseID  uint64 `json:"course_id" binding:"required"`
-	Query     string `json:"query" binding:"required"`
-	SessionID string `json:"session_id"` // For conversation history (optional)
+	UserID    uint64                    `json:"-"` // From JWT, not from request body
+	CourseID  uint64                    `json:"course_id" binding:"required"`
+	Query     string                    `json:"query" binding:"required"`
+	SessionID string                    `json:"session_id"` // For conversation history (optional). Empty starts a new conversation.
+	Retrieval *model.RetrievalOverrides `json:"retrieval"`  // Overrides the course's retrieval settings (optional)
+	Filter    *model.SearchFilter       `json:"filter"`     // Restricts retrieval to some documents, a semester or a document type (optional)
 }
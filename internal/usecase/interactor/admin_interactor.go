@@ -0,0 +1,126 @@
+// open-rag-lecture/internal/usecase/interactor/admin_interactor.go
+package interactor
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+type adminInteractor struct {
+	userRepo      repository.UserRepository
+	courseRepo    repository.CourseRepository
+	cacheRepo     repository.CacheRepository
+	vectorRepo    repository.VectorRepository
+	semanticCache repository.SemanticCacheRepository // May be nil
+}
+
+// NewAdminInteractor creates a new instance of AdminUsecase.
+func NewAdminInteractor(
+	userRepo repository.UserRepository,
+	courseRepo repository.CourseRepository,
+	cacheRepo repository.CacheRepository,
+	vectorRepo repository.VectorRepository,
+	semanticCache repository.SemanticCacheRepository,
+) port.AdminUsecase {
+	return &adminInteractor{
+		userRepo:      userRepo,
+		courseRepo:    courseRepo,
+		cacheRepo:     cacheRepo,
+		vectorRepo:    vectorRepo,
+		semanticCache: semanticCache,
+	}
+}
+
+// InvalidateAnswerCache bumps the corpus version of the course, which makes all of its
+// cached answers unreachable, and then deletes its semantic cache entries.
+func (i *adminInteractor) InvalidateAnswerCache(ctx context.Context, userID, courseID uint64) error {
+	if err := i.checkCourse(ctx, userID, courseID); err != nil {
+		return err
+	}
+
+	if _, err := i.cacheRepo.Incr(ctx, repository.CorpusVersionKey(courseID)); err != nil {
+		return appErrors.ErrInternalServerError
+	}
+	if i.semanticCache != nil {
+		// The entries are already unreachable; deleting them only frees the space.
+		if err := i.semanticCache.DeleteByCourse(ctx, courseID); err != nil {
+			log.Printf("WARN: failed to delete semantic answer cache of course %d: %v", courseID, err)
+		}
+	}
+	return nil
+}
+
+// CreateVectorSpace creates the vector space of a course ahead of its first upload.
+func (i *adminInteractor) CreateVectorSpace(ctx context.Context, userID, courseID uint64) error {
+	if err := i.checkCourse(ctx, userID, courseID); err != nil {
+		return err
+	}
+	if err := i.vectorRepo.CreateCourseSpace(ctx, courseID); err != nil {
+		log.Printf("ERROR: failed to create vector space of course %d: %v", courseID, err)
+		return appErrors.ErrInternalServerError
+	}
+	return nil
+}
+
+// DropVectorSpace drops the vector space of a course and bumps its corpus version, as
+// the cached answers cite chunks that can no longer be retrieved.
+func (i *adminInteractor) DropVectorSpace(ctx context.Context, userID, courseID uint64) error {
+	if err := i.checkCourse(ctx, userID, courseID); err != nil {
+		return err
+	}
+	if err := i.vectorRepo.DropCourseSpace(ctx, courseID); err != nil {
+		log.Printf("ERROR: failed to drop vector space of course %d: %v", courseID, err)
+		return appErrors.ErrInternalServerError
+	}
+	if _, err := i.cacheRepo.Incr(ctx, repository.CorpusVersionKey(courseID)); err != nil {
+		log.Printf("WARN: failed to bump corpus version of course %d: %v", courseID, err)
+	}
+	return nil
+}
+
+func (i *adminInteractor) VectorSpaceStats(ctx context.Context, userID, courseID uint64) (*model.VectorSpaceStats, error) {
+	if err := i.checkCourse(ctx, userID, courseID); err != nil {
+		return nil, err
+	}
+	stats, err := i.vectorRepo.CourseSpaceStats(ctx, courseID)
+	if err != nil {
+		log.Printf("ERROR: failed to get vector space stats of course %d: %v", courseID, err)
+		return nil, appErrors.ErrInternalServerError
+	}
+	return stats, nil
+}
+
+// checkCourse verifies that the user has the admin role and that the course exists.
+func (i *adminInteractor) checkCourse(ctx context.Context, userID, courseID uint64) error {
+	if err := i.checkAdmin(ctx, userID); err != nil {
+		return err
+	}
+	if _, err := i.courseRepo.FindByID(ctx, courseID); err != nil {
+		if errors.Is(err, appErrors.ErrCourseNotFound) {
+			return appErrors.ErrNotFound
+		}
+		return appErrors.ErrInternalServerError
+	}
+	return nil
+}
+
+// checkAdmin verifies that the user has the admin role.
+func (i *adminInteractor) checkAdmin(ctx context.Context, userID uint64) error {
+	user, err := i.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrUserNotFound) {
+			return appErrors.ErrUnauthorized
+		}
+		return appErrors.ErrInternalServerError
+	}
+	if user.Role != model.RoleAdmin {
+		return appErrors.ErrForbidden
+	}
+	return nil
+}
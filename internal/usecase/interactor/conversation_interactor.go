@@ -0,0 +1,101 @@
+// OpenRAGLecture/internal/usecase/interactor/conversation_interactor.go
+package interactor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
+)
+
+type conversationInteractor struct {
+	convRepo      repository.ConversationRepository
+	conversations *conversationStore
+}
+
+// NewConversationInteractor creates a new instance of ConversationUsecase.
+func NewConversationInteractor(
+	convRepo repository.ConversationRepository,
+	cacheRepo repository.CacheRepository,
+) port.ConversationUsecase {
+	return &conversationInteractor{
+		convRepo:      convRepo,
+		conversations: &conversationStore{convRepo: convRepo, cacheRepo: cacheRepo},
+	}
+}
+
+func (i *conversationInteractor) List(ctx context.Context, userID, courseID uint64) ([]output.ConversationOutput, error) {
+	conversations, err := i.convRepo.ListByUser(ctx, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	outputs := make([]output.ConversationOutput, len(conversations))
+	for idx, conversation := range conversations {
+		outputs[idx] = toConversationOutput(conversation)
+	}
+	return outputs, nil
+}
+
+func (i *conversationInteractor) Get(ctx context.Context, userID uint64, sessionID string) (*output.ConversationOutput, error) {
+	conversation, err := i.findOwned(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := i.convRepo.ListMessages(ctx, conversation.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversation messages: %w", err)
+	}
+
+	out := toConversationOutput(conversation)
+	out.Messages = make([]output.MessageOutput, len(messages))
+	for idx, message := range messages {
+		out.Messages[idx] = output.MessageOutput{
+			Role:      string(message.Role),
+			Content:   message.Content,
+			CreatedAt: message.CreatedAt,
+		}
+	}
+	return &out, nil
+}
+
+func (i *conversationInteractor) Delete(ctx context.Context, userID uint64, sessionID string) error {
+	conversation, err := i.findOwned(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := i.convRepo.Delete(ctx, conversation.ID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	i.conversations.invalidate(ctx, sessionID)
+	return nil
+}
+
+// findOwned returns the conversation if it belongs to the user.
+// Conversations of other users are reported as not found.
+func (i *conversationInteractor) findOwned(ctx context.Context, userID uint64, sessionID string) (*model.Conversation, error) {
+	conversation, err := i.convRepo.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.UserID != userID {
+		return nil, appErrors.ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+func toConversationOutput(conversation *model.Conversation) output.ConversationOutput {
+	return output.ConversationOutput{
+		SessionID: conversation.SessionID,
+		CourseID:  conversation.CourseID,
+		Title:     conversation.Title,
+		CreatedAt: conversation.CreatedAt,
+		UpdatedAt: conversation.UpdatedAt,
+	}
+}
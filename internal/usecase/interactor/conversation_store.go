@@ -0,0 +1,132 @@
+// OpenRAGLecture/internal/usecase/interactor/conversation_store.go
+package interactor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+const (
+	// conversationHistoryMessages is the number of most recent messages kept as history.
+	conversationHistoryMessages = 6
+	// conversationHistoryMaxRunes bounds the size of the history passed to the LLM.
+	conversationHistoryMaxRunes = 4000
+	conversationTitleMaxRunes   = 100
+	conversationCacheTTL        = 24 * time.Hour
+)
+
+// conversationStore keeps conversation history in the ConversationRepository and
+// caches the most recent messages of each session in the CacheRepository.
+type conversationStore struct {
+	convRepo  repository.ConversationRepository
+	cacheRepo repository.CacheRepository
+}
+
+// cachedMessage is the cache representation of a conversation message.
+type cachedMessage struct {
+	Role    model.MessageRole `json:"role"`
+	Content string            `json:"content"`
+}
+
+func conversationCacheKey(sessionID string) string {
+	return fmt.Sprintf("conversation:%s:recent", sessionID)
+}
+
+// recentMessages returns the latest messages of a conversation, oldest first.
+// The cache is consulted first; on a miss the messages are loaded and cached.
+func (s *conversationStore) recentMessages(ctx context.Context, conversation *model.Conversation) ([]model.ConversationMessage, error) {
+	key := conversationCacheKey(conversation.SessionID)
+	if cached, err := s.cacheRepo.Get(ctx, key); err != nil {
+		log.Printf("WARN: failed to read conversation cache %s: %v", key, err)
+	} else if cached != "" {
+		var entries []cachedMessage
+		if err := json.Unmarshal([]byte(cached), &entries); err == nil {
+			messages := make([]model.ConversationMessage, len(entries))
+			for i, entry := range entries {
+				messages[i] = model.ConversationMessage{Role: entry.Role, Content: entry.Content}
+			}
+			return messages, nil
+		}
+	}
+
+	stored, err := s.convRepo.ListMessages(ctx, conversation.ID, conversationHistoryMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation history: %w", err)
+	}
+	messages := make([]model.ConversationMessage, len(stored))
+	for i, message := range stored {
+		messages[i] = *message
+	}
+	s.cache(ctx, conversation.SessionID, messages)
+	return messages, nil
+}
+
+// append stores new messages and refreshes the cached recent messages, which are
+// derived from the recent messages the caller loaded before this turn.
+func (s *conversationStore) append(ctx context.Context, conversation *model.Conversation, recent []model.ConversationMessage, messages ...model.ConversationMessage) error {
+	toStore := make([]*model.ConversationMessage, len(messages))
+	for i := range messages {
+		toStore[i] = &messages[i]
+	}
+	if err := s.convRepo.AddMessages(ctx, conversation.ID, toStore); err != nil {
+		return fmt.Errorf("failed to save conversation messages: %w", err)
+	}
+
+	updated := append(append([]model.ConversationMessage{}, recent...), messages...)
+	if len(updated) > conversationHistoryMessages {
+		updated = updated[len(updated)-conversationHistoryMessages:]
+	}
+	s.cache(ctx, conversation.SessionID, updated)
+	return nil
+}
+
+// invalidate drops the cached messages of a session.
+func (s *conversationStore) invalidate(ctx context.Context, sessionID string) {
+	if err := s.cacheRepo.Delete(ctx, conversationCacheKey(sessionID)); err != nil {
+		log.Printf("WARN: failed to invalidate conversation cache for %s: %v", sessionID, err)
+	}
+}
+
+func (s *conversationStore) cache(ctx context.Context, sessionID string, messages []model.ConversationMessage) {
+	entries := make([]cachedMessage, len(messages))
+	for i, message := range messages {
+		entries[i] = cachedMessage{Role: message.Role, Content: message.Content}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	if err := s.cacheRepo.Set(ctx, conversationCacheKey(sessionID), string(data), conversationCacheTTL); err != nil {
+		log.Printf("WARN: failed to cache conversation %s: %v", sessionID, err)
+	}
+}
+
+// trimHistory keeps the newest messages whose combined length fits into maxRunes.
+func trimHistory(messages []model.ConversationMessage, maxRunes int) []model.ConversationMessage {
+	total := 0
+	start := len(messages)
+	for start > 0 {
+		size := len([]rune(messages[start-1].Content))
+		if total+size > maxRunes {
+			break
+		}
+		total += size
+		start--
+	}
+	return messages[start:]
+}
+
+// truncateRunes shortens text to at most maxRunes runes.
+func truncateRunes(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes])
+}
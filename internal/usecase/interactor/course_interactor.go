@@ -60,3 +60,49 @@ func (i *courseInteractor) EnrollUser(ctx context.Context, userID, courseID uint
 
 	return nil
 }
+
+// GetRetrievalSettings returns the retrieval overrides of a course. Courses without
+// saved settings have no overrides.
+func (i *courseInteractor) GetRetrievalSettings(ctx context.Context, userID, courseID uint64) (*model.RetrievalOverrides, error) {
+	if err := i.checkInstructor(ctx, userID, courseID); err != nil {
+		return nil, err
+	}
+
+	settings, err := i.courseRepo.FindSettings(ctx, courseID)
+	if err != nil {
+		return nil, appErrors.ErrInternalServerError
+	}
+	if settings == nil {
+		return &model.RetrievalOverrides{}, nil
+	}
+	return &settings.Retrieval, nil
+}
+
+// UpdateRetrievalSettings replaces the retrieval overrides of a course.
+// Values outside the supported ranges are clamped when the settings are used.
+func (i *courseInteractor) UpdateRetrievalSettings(ctx context.Context, userID, courseID uint64, retrieval model.RetrievalOverrides) error {
+	if err := i.checkInstructor(ctx, userID, courseID); err != nil {
+		return err
+	}
+
+	settings := &model.CourseSettings{CourseID: courseID, Retrieval: retrieval}
+	if err := i.courseRepo.SaveSettings(ctx, settings); err != nil {
+		return appErrors.ErrInternalServerError
+	}
+	return nil
+}
+
+// checkInstructor verifies that the course exists and that the user is its instructor.
+func (i *courseInteractor) checkInstructor(ctx context.Context, userID, courseID uint64) error {
+	course, err := i.courseRepo.FindByID(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrCourseNotFound) {
+			return appErrors.ErrNotFound
+		}
+		return appErrors.ErrInternalServerError
+	}
+	if course.InstructorID != userID {
+		return appErrors.ErrForbidden
+	}
+	return nil
+}
@@ -7,8 +7,10 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
@@ -20,6 +22,8 @@ type fileInteractor struct {
 	docRepo     repository.DocumentRepository
 	fileStorage repository.FileStorage
 	courseRepo  repository.CourseRepository // ★ 依存関係に CourseRepository を追加
+	vectorRepo  repository.VectorRepository
+	cacheRepo   repository.CacheRepository
 }
 
 // NewFileInteractor creates a new instance of FileUsecase.
@@ -28,11 +32,15 @@ func NewFileInteractor(
 	docRepo repository.DocumentRepository,
 	fileStorage repository.FileStorage,
 	courseRepo repository.CourseRepository,
+	vectorRepo repository.VectorRepository,
+	cacheRepo repository.CacheRepository,
 ) port.FileUsecase {
 	return &fileInteractor{
 		docRepo:     docRepo,
 		fileStorage: fileStorage,
 		courseRepo:  courseRepo,
+		vectorRepo:  vectorRepo,
+		cacheRepo:   cacheRepo,
 	}
 }
 
@@ -57,6 +65,17 @@ func (i *fileInteractor) Upload(ctx context.Context, courseID uint64, fileName s
 	}
 	// ★★★ 修正ロジックここまで ★★★
 
+	// Uploading a file with the title of an existing document adds a new version of it;
+	// the batch job replaces the previous version once the new one is indexed.
+	version := 1
+	latest, err := i.docRepo.FindLatestVersion(ctx, courseID, fileName)
+	switch {
+	case err == nil:
+		version = latest.Version + 1
+	case !errors.Is(err, appErrors.ErrDocumentNotFound):
+		return nil, appErrors.ErrInternalServerError
+	}
+
 	// Save the file using the file storage interface
 	filePath, err := i.fileStorage.Save(ctx, courseID, fileName, buf.Bytes())
 	if err != nil {
@@ -71,7 +90,7 @@ func (i *fileInteractor) Upload(ctx context.Context, courseID uint64, fileName s
 		SourceURI:  filePath,
 		DocType:    model.DocTypePDF, // Assuming PDF, can be detected from mime-type
 		Checksum:   checksum,
-		Version:    1,
+		Version:    version,
 	}
 
 	if err := i.docRepo.Create(ctx, doc); err != nil {
@@ -101,3 +120,40 @@ func (i *fileInteractor) Download(ctx context.Context, documentID uint64) ([]byt
 
 	return data, doc, nil
 }
+
+func (i *fileInteractor) Delete(ctx context.Context, userID, documentID uint64) error {
+	doc, err := i.docRepo.FindByID(ctx, documentID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrDocumentNotFound) {
+			return appErrors.ErrNotFound
+		}
+		return appErrors.ErrInternalServerError
+	}
+	course, err := i.courseRepo.FindByID(ctx, doc.CourseID)
+	if err != nil {
+		return appErrors.ErrInternalServerError
+	}
+	if course.InstructorID != userID {
+		return appErrors.ErrForbidden
+	}
+
+	// The vectors go first: if deleting the rows fails afterwards, the document is no
+	// longer retrieved and the deletion can simply be retried.
+	if err := i.vectorRepo.DeleteByDocument(ctx, doc.ID); err != nil {
+		return fmt.Errorf("%w: failed to delete vectors of document %d: %v", appErrors.ErrInternalServerError, doc.ID, err)
+	}
+	if err := i.docRepo.Delete(ctx, doc.ID); err != nil {
+		if errors.Is(err, appErrors.ErrDocumentNotFound) {
+			return appErrors.ErrNotFound
+		}
+		return appErrors.ErrInternalServerError
+	}
+	if err := i.fileStorage.Delete(ctx, doc.SourceURI); err != nil {
+		log.Printf("WARN: failed to delete file %s of document %d: %v", doc.SourceURI, doc.ID, err)
+	}
+	// Cached answers may cite the deleted document.
+	if _, err := i.cacheRepo.Incr(ctx, repository.CorpusVersionKey(doc.CourseID)); err != nil {
+		log.Printf("WARN: failed to bump corpus version of course %d: %v", doc.CourseID, err)
+	}
+	return nil
+}
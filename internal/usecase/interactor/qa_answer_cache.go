@@ -0,0 +1,178 @@
+// OpenRAGLecture/internal/usecase/interactor/qa_answer_cache.go
+package interactor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+)
+
+// AnswerCacheConfig configures the answer cache of the QA interactor.
+type AnswerCacheConfig struct {
+	TTL time.Duration // Lifetime of cached answers; zero disables the cache
+	// SimilarityThreshold is the minimum cosine similarity between the embeddings of two
+	// questions for the cached answer of one to be served for the other. Zero disables
+	// the semantic cache and leaves exact matches only.
+	SimilarityThreshold float32
+}
+
+// cachedAnswer is the cache representation of an answer and the sources it was
+// generated from.
+type cachedAnswer struct {
+	Answer  string                  `json:"answer"`
+	Sources []output.CitationOutput `json:"sources"`
+}
+
+// answerCacheLookup is the answer cache state of a cacheable turn.
+type answerCacheLookup struct {
+	courseID   uint64
+	version    string    // Corpus version of the course
+	key        string    // Exact-match key of the question
+	vector     []float32 // Embedding of the question; nil unless the semantic cache was queried
+	similarity float32   // Similarity of the question a semantic hit was cached for
+}
+
+// normalizeQuery maps trivially different spellings of a question to the same
+// string: case, surrounding punctuation and runs of whitespace are ignored.
+func normalizeQuery(query string) string {
+	query = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	return strings.TrimFunc(query, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
+
+// corpusVersion returns the current corpus version of the course. Bumping the version
+// makes all answers cached for older versions unreachable.
+func (i *qaInteractor) corpusVersion(ctx context.Context, courseID uint64) (string, error) {
+	version, err := i.cacheRepo.Get(ctx, repository.CorpusVersionKey(courseID))
+	if err != nil {
+		return "", fmt.Errorf("failed to read corpus version: %w", err)
+	}
+	if version == "" {
+		version = "0"
+	}
+	return version, nil
+}
+
+// answerCacheKey returns the exact-match cache key of a question in a corpus version.
+func answerCacheKey(courseID uint64, version, query string) string {
+	sum := sha256.Sum256([]byte(normalizeQuery(query)))
+	return fmt.Sprintf("answer:%d:v%s:%s", courseID, version, hex.EncodeToString(sum[:]))
+}
+
+// lookupAnswer looks up the answer to a question, first by its normalized text and then,
+// if the semantic cache is enabled, by the similarity of its embedding to the questions
+// answered before with the index. Cache failures are logged and treated as a miss; a nil
+// lookup disables caching for the turn.
+func (i *qaInteractor) lookupAnswer(ctx context.Context, index *repository.SearchIndex, courseID uint64, query string) (*answerCacheLookup, *cachedAnswer) {
+	version, err := i.corpusVersion(ctx, courseID)
+	if err != nil {
+		log.Printf("WARN: answer cache unavailable: %v", err)
+		return nil, nil
+	}
+	lookup := &answerCacheLookup{courseID: courseID, version: version, key: answerCacheKey(courseID, version, query)}
+
+	cached := i.lookupExactAnswer(ctx, lookup.key)
+	if cached == nil && index.AnswerCache != nil && i.answerCache.SimilarityThreshold > 0 {
+		cached = i.lookupSimilarAnswer(ctx, index, lookup, query)
+	}
+	i.recordAnswerCacheResult(ctx, courseID, cached != nil)
+	return lookup, cached
+}
+
+func (i *qaInteractor) lookupExactAnswer(ctx context.Context, key string) *cachedAnswer {
+	value, err := i.cacheRepo.Get(ctx, key)
+	if err != nil {
+		log.Printf("WARN: failed to read answer cache %s: %v", key, err)
+		return nil
+	}
+	return decodeCachedAnswer(value)
+}
+
+// lookupSimilarAnswer embeds the question and returns the answer cached for the most
+// similar question of the same corpus version, if it is similar enough. The embedding
+// is kept in the lookup so that retrieval and storing the answer can reuse it.
+func (i *qaInteractor) lookupSimilarAnswer(ctx context.Context, index *repository.SearchIndex, lookup *answerCacheLookup, query string) *cachedAnswer {
+	embeddings, err := index.EmbeddingRepo.CreateEmbeddings(ctx, []string{query}, "RETRIEVAL_QUERY")
+	if err != nil || len(embeddings) != 1 {
+		log.Printf("WARN: failed to embed question for the semantic answer cache: %v", err)
+		return nil
+	}
+	lookup.vector = embeddings[0]
+
+	notBefore := time.Now().Add(-i.answerCache.TTL)
+	entry, similarity, err := index.AnswerCache.FindNearest(ctx, lookup.courseID, lookup.version, lookup.vector, notBefore)
+	if err != nil {
+		log.Printf("WARN: failed to search semantic answer cache: %v", err)
+		return nil
+	}
+	if entry == nil || similarity < i.answerCache.SimilarityThreshold {
+		return nil
+	}
+	cached := decodeCachedAnswer(entry.Answer)
+	if cached != nil {
+		lookup.similarity = similarity
+	}
+	return cached
+}
+
+func decodeCachedAnswer(value string) *cachedAnswer {
+	if value == "" {
+		return nil
+	}
+	var entry cachedAnswer
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// storeAnswer caches an answer under the key of the lookup and, if the question was
+// embedded, under its embedding in the answer cache of the index.
+func (i *qaInteractor) storeAnswer(ctx context.Context, index *repository.SearchIndex, lookup *answerCacheLookup, query string, entry cachedAnswer) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("ERROR: failed to encode answer cache entry %s: %v", lookup.key, err)
+		return
+	}
+	if err := i.cacheRepo.Set(ctx, lookup.key, string(value), i.answerCache.TTL); err != nil {
+		log.Printf("WARN: failed to write answer cache %s: %v", lookup.key, err)
+	}
+
+	if lookup.vector == nil {
+		return
+	}
+	err = index.AnswerCache.Save(ctx, &model.SemanticCacheEntry{
+		CourseID:      lookup.courseID,
+		CorpusVersion: lookup.version,
+		Query:         query,
+		Vector:        lookup.vector,
+		Answer:        string(value),
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		log.Printf("WARN: failed to write semantic answer cache: %v", err)
+	}
+}
+
+// recordAnswerCacheResult counts answer cache hits and misses per course.
+func (i *qaInteractor) recordAnswerCacheResult(ctx context.Context, courseID uint64, hit bool) {
+	result := "misses"
+	if hit {
+		result = "hits"
+	}
+	key := fmt.Sprintf("stats:answer_cache:%d:%s", courseID, result)
+	if _, err := i.cacheRepo.Incr(ctx, key); err != nil {
+		log.Printf("WARN: failed to record answer cache %s: %v", result, err)
+	}
+}
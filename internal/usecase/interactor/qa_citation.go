@@ -0,0 +1,63 @@
+// OpenRAGLecture/internal/usecase/interactor/qa_citation.go
+package interactor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+)
+
+// citationMarkerPattern matches reference markers such as "[1]" or "[2, 3]".
+var citationMarkerPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// toCitationOutputs converts the context chunks into citations numbered in prompt order.
+func toCitationOutputs(chunks []model.RetrievedChunk) []output.CitationOutput {
+	citations := make([]output.CitationOutput, len(chunks))
+	for i, chunk := range chunks {
+		citations[i] = newCitationOutput(i+1, chunk)
+	}
+	return citations
+}
+
+// extractCitations parses the reference markers in an answer and maps them back to
+// the sources they refer to, as numbered by toCitationOutputs. Citations are returned
+// in order of first mention; markers that do not refer to a source are ignored.
+func extractCitations(answer string, sources []output.CitationOutput) []output.CitationOutput {
+	citations := []output.CitationOutput{}
+	seen := make(map[int]bool)
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(answer, -1) {
+		for _, part := range strings.Split(match[1], ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || index < 1 || index > len(sources) || seen[index] {
+				continue
+			}
+			seen[index] = true
+			citations = append(citations, sources[index-1])
+		}
+	}
+	return citations
+}
+
+// makeSnippet shortens a chunk text to at most snippetMaxRunes runes.
+func makeSnippet(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= snippetMaxRunes {
+		return string(runes)
+	}
+	return string(runes[:snippetMaxRunes]) + "..."
+}
+
+func newCitationOutput(index int, chunk model.RetrievedChunk) output.CitationOutput {
+	return output.CitationOutput{
+		Index:         index,
+		DocumentID:    chunk.Chunk.DocumentID,
+		DocumentTitle: chunk.Chunk.Document.Title,
+		PageNumber:    chunk.Chunk.Page.PageNumber,
+		ChunkID:       chunk.Chunk.ID,
+		Score:         chunk.Score,
+		Snippet:       makeSnippet(chunk.Chunk.Text),
+	}
+}
@@ -0,0 +1,257 @@
+// OpenRAGLecture/internal/usecase/interactor/qa_context.go
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/pkg/tokenizer"
+)
+
+// contextPassage is a contiguous piece of a page in the LLM context. It is made of a
+// retrieved chunk and the neighboring chunks merged into it.
+type contextPassage struct {
+	source model.RetrievedChunk // Best-ranked retrieved chunk of the passage, used for citations
+	text   string
+}
+
+// contextEntry is a chunk selected for the context.
+type contextEntry struct {
+	chunk     model.Chunk
+	rank      int  // Rank of the retrieved chunk that selected this chunk
+	retrieved bool // Whether the chunk was retrieved itself rather than added as a neighbor
+	score     float32
+}
+
+// indexRange is an inclusive range of chunk indexes within a document.
+type indexRange struct {
+	from, to int
+}
+
+// assembleContext packs the retrieved chunks into the LLM context. Every retrieved
+// chunk is taken in rank order together with its cfg.NeighborChunks neighbors on each
+// side; if they do not fit into cfg.ContextTokenBudget the chunk is taken alone, and if
+// it does not fit either it is skipped. The best chunk is always taken. The selected
+// chunks are merged into passages where their spans on a page overlap or touch, and
+// the passages are ordered by document (best rank first), page and position.
+func (i *qaInteractor) assembleContext(ctx context.Context, cfg model.RetrievalConfig, retrieved []model.RetrievedChunk) []contextPassage {
+	if len(retrieved) == 0 {
+		return nil
+	}
+	neighbors := i.loadNeighbors(ctx, cfg.NeighborChunks, retrieved)
+
+	selected := make(map[uint64]*contextEntry)
+	usedTokens := 0
+	for rank, hit := range retrieved {
+		if entry, ok := selected[hit.Chunk.ID]; ok {
+			// Already added as a neighbor of a better chunk.
+			entry.retrieved = true
+			entry.score = hit.Score
+			continue
+		}
+
+		group := []model.Chunk{hit.Chunk}
+		for offset := 1; offset <= cfg.NeighborChunks; offset++ {
+			for _, index := range []int{hit.Chunk.ChunkIndex - offset, hit.Chunk.ChunkIndex + offset} {
+				neighbor, ok := neighbors[hit.Chunk.DocumentID][index]
+				if ok && selected[neighbor.ID] == nil {
+					group = append(group, *neighbor)
+				}
+			}
+		}
+
+		cost := chunkTokens(group...)
+		if usedTokens+cost > cfg.ContextTokenBudget && len(group) > 1 {
+			group = group[:1]
+			cost = chunkTokens(group...)
+		}
+		if usedTokens+cost > cfg.ContextTokenBudget && len(selected) > 0 {
+			continue
+		}
+
+		usedTokens += cost
+		for idx, chunk := range group {
+			entry := &contextEntry{chunk: chunk, rank: rank}
+			if idx == 0 {
+				entry.retrieved = true
+				entry.score = hit.Score
+			}
+			selected[chunk.ID] = entry
+		}
+	}
+
+	return buildPassages(selected)
+}
+
+// loadNeighbors fetches the chunks around the retrieved chunks, keyed by document ID
+// and chunk index. Lookup failures only cost the neighbors and are logged.
+func (i *qaInteractor) loadNeighbors(ctx context.Context, n int, retrieved []model.RetrievedChunk) map[uint64]map[int]*model.Chunk {
+	neighbors := make(map[uint64]map[int]*model.Chunk)
+	if n <= 0 {
+		return neighbors
+	}
+
+	ranges := make(map[uint64][]indexRange)
+	for _, hit := range retrieved {
+		if hit.Chunk.DocumentID == 0 {
+			continue
+		}
+		ranges[hit.Chunk.DocumentID] = append(ranges[hit.Chunk.DocumentID],
+			indexRange{from: max(hit.Chunk.ChunkIndex-n, 0), to: hit.Chunk.ChunkIndex + n})
+	}
+
+	for documentID, docRanges := range ranges {
+		neighbors[documentID] = make(map[int]*model.Chunk)
+		for _, r := range mergeIndexRanges(docRanges) {
+			chunks, err := i.docRepo.FindChunksByIndexRange(ctx, documentID, r.from, r.to)
+			if err != nil {
+				log.Printf("WARN: failed to load neighbor chunks of document %d: %v", documentID, err)
+				continue
+			}
+			for _, chunk := range chunks {
+				neighbors[documentID][chunk.ChunkIndex] = chunk
+			}
+		}
+	}
+	return neighbors
+}
+
+// mergeIndexRanges merges overlapping and adjacent ranges so that every chunk is fetched once.
+func mergeIndexRanges(ranges []indexRange) []indexRange {
+	sort.Slice(ranges, func(a, b int) bool { return ranges[a].from < ranges[b].from })
+	merged := []indexRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.from <= last.to+1 {
+			last.to = max(last.to, r.to)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// chunkTokens returns the estimated number of tokens of the given chunks. Overlapping
+// text is counted twice, which keeps the estimate on the safe side.
+func chunkTokens(chunks ...model.Chunk) int {
+	total := 0
+	for _, chunk := range chunks {
+		if chunk.TokenCount > 0 {
+			total += chunk.TokenCount
+		} else {
+			total += tokenizer.EstimateTokens(chunk.Text)
+		}
+	}
+	return total
+}
+
+// buildPassages orders the selected chunks by document, page and position and merges
+// chunks whose spans on the same page overlap or touch into a single passage.
+func buildPassages(selected map[uint64]*contextEntry) []contextPassage {
+	entries := make([]*contextEntry, 0, len(selected))
+	docRank := make(map[uint64]int)
+	for _, entry := range selected {
+		entries = append(entries, entry)
+		if rank, ok := docRank[entry.chunk.DocumentID]; !ok || entry.rank < rank {
+			docRank[entry.chunk.DocumentID] = entry.rank
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		ca, cb := entries[a].chunk, entries[b].chunk
+		if ca.DocumentID != cb.DocumentID {
+			return docRank[ca.DocumentID] < docRank[cb.DocumentID]
+		}
+		if ca.Page.PageNumber != cb.Page.PageNumber {
+			return ca.Page.PageNumber < cb.Page.PageNumber
+		}
+		if ca.ChunkIndex != cb.ChunkIndex {
+			return ca.ChunkIndex < cb.ChunkIndex
+		}
+		return entries[a].rank < entries[b].rank
+	})
+
+	var passages []contextPassage
+	var first *contextEntry // First entry of the current passage
+	var best *contextEntry  // Best-ranked retrieved entry of the current passage
+	var end int             // End offset of the current passage on its page
+	var text strings.Builder
+	flush := func() {
+		if first == nil {
+			return
+		}
+		source := model.RetrievedChunk{Chunk: first.chunk}
+		if best != nil {
+			source = model.RetrievedChunk{Chunk: best.chunk, Score: best.score}
+		}
+		passages = append(passages, contextPassage{source: source, text: text.String()})
+		first, best = nil, nil
+		text.Reset()
+	}
+
+	for _, entry := range entries {
+		chunk := entry.chunk
+		if first != nil && hasSpan(chunk) && hasSpan(first.chunk) && chunk.PageID == first.chunk.PageID &&
+			chunk.StartOffset >= first.chunk.StartOffset && chunk.StartOffset <= end {
+			// The chunk continues the passage; only append the text beyond its end.
+			if overlap := end - chunk.StartOffset; overlap < len(chunk.Text) {
+				text.WriteString(chunk.Text[overlap:])
+			}
+			end = max(end, chunk.EndOffset)
+		} else {
+			flush()
+			first = entry
+			end = chunk.EndOffset
+			text.WriteString(chunk.Text)
+		}
+		if entry.retrieved && (best == nil || entry.rank < best.rank) {
+			best = entry
+		}
+	}
+	flush()
+	return passages
+}
+
+// hasSpan reports whether the chunk knows its position on a stored page. Chunks
+// created before offsets were recorded are never merged.
+func hasSpan(chunk model.Chunk) bool {
+	return chunk.PageID != 0 && chunk.EndOffset > chunk.StartOffset
+}
+
+// buildContextString renders the passages grouped by document and page. Every passage
+// is headed by its reference number.
+func buildContextString(passages []contextPassage) string {
+	var sb strings.Builder
+	var documentID uint64
+	pageNumber := -1
+	for idx, passage := range passages {
+		chunk := passage.source.Chunk
+		if idx == 0 || chunk.DocumentID != documentID {
+			documentID = chunk.DocumentID
+			pageNumber = -1
+			if chunk.Document.Title != "" {
+				sb.WriteString(fmt.Sprintf("=== %s ===\n", chunk.Document.Title))
+			}
+		}
+		if chunk.Page.PageNumber != pageNumber {
+			pageNumber = chunk.Page.PageNumber
+			if pageNumber > 0 {
+				sb.WriteString(fmt.Sprintf("--- p. %d ---\n", pageNumber))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("[%d] %s\n\n", idx+1, passage.text))
+	}
+	return sb.String()
+}
+
+// passageSources returns the citation source of every passage, in context order.
+func passageSources(passages []contextPassage) []model.RetrievedChunk {
+	sources := make([]model.RetrievedChunk, len(passages))
+	for idx, passage := range passages {
+		sources[idx] = passage.source
+	}
+	return sources
+}
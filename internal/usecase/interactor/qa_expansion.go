@@ -0,0 +1,89 @@
+// OpenRAGLecture/internal/usecase/interactor/qa_expansion.go
+package interactor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+const (
+	// ambiguityClarifyThreshold is the ambiguity score from which a clarifying question
+	// is returned instead of an answer.
+	ambiguityClarifyThreshold = 0.7
+	maxQueryParaphrases       = 3
+	queryExpansionPrompt      = `You help a search engine for university lecture materials.
+Analyse the student's question and respond with a single JSON object with exactly these fields:
+- "ambiguity": a number from 0 to 1. 0 means the question is clear; 1 means it cannot be answered without more information from the student.
+- "clarifying_question": if the question is ambiguous, one short question asking the student for the missing information; otherwise "".
+- "paraphrases": up to 3 alternative phrasings of the question that use different keywords.
+- "hypothetical_answer": a short passage, as it could appear in lecture material, that answers the question.
+Write all texts in the language of the question. Return only the JSON object.`
+)
+
+// queryExpansion is the result of the LLM query expansion stage.
+type queryExpansion struct {
+	Ambiguity          float32  `json:"ambiguity"`
+	ClarifyingQuestion string   `json:"clarifying_question"`
+	Paraphrases        []string `json:"paraphrases"`
+	HypotheticalAnswer string   `json:"hypothetical_answer"`
+}
+
+// expandQuery asks the LLM for paraphrases and a hypothetical answer (HyDE) of the
+// query, and for an estimate of how ambiguous the query is.
+func (i *qaInteractor) expandQuery(ctx context.Context, query string) (*queryExpansion, error) {
+	raw, err := i.llmRepo.GenerateContent(ctx, repository.GenerateContentParams{
+		SystemPrompt: queryExpansionPrompt,
+		UserPrompt:   query,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand query: %w", err)
+	}
+	return parseQueryExpansion(raw)
+}
+
+// parseQueryExpansion extracts the JSON object from the LLM response, which may be
+// wrapped in a Markdown code block.
+func parseQueryExpansion(raw string) (*queryExpansion, error) {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("query expansion response contains no JSON object")
+	}
+
+	var expansion queryExpansion
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &expansion); err != nil {
+		return nil, fmt.Errorf("failed to parse query expansion: %w", err)
+	}
+	if expansion.Ambiguity < 0 {
+		expansion.Ambiguity = 0
+	} else if expansion.Ambiguity > 1 {
+		expansion.Ambiguity = 1
+	}
+	return &expansion, nil
+}
+
+// needsClarification reports whether the query is too ambiguous to be answered.
+func (e *queryExpansion) needsClarification() bool {
+	return e.Ambiguity >= ambiguityClarifyThreshold && strings.TrimSpace(e.ClarifyingQuestion) != ""
+}
+
+// searchTexts returns the additional texts to search with besides the query itself.
+func (e *queryExpansion) searchTexts() []string {
+	var texts []string
+	for _, paraphrase := range e.Paraphrases {
+		if len(texts) == maxQueryParaphrases {
+			break
+		}
+		if paraphrase = strings.TrimSpace(paraphrase); paraphrase != "" {
+			texts = append(texts, paraphrase)
+		}
+	}
+	if answer := strings.TrimSpace(e.HypotheticalAnswer); answer != "" {
+		texts = append(texts, answer)
+	}
+	return texts
+}
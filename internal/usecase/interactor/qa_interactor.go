@@ -5,192 +5,642 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/port"
+	appErrors "github.com/takumi-1234/OpenRAGLecture/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
 const (
-	hybridSearchTopK     = 5
-	rerankTopN           = 3
+	snippetMaxRunes      = 200
+	qaLogTimeout         = 10 * time.Second
+	noRelevantInfoAnswer = "I could not find any relevant information in the provided materials to answer your question."
 	systemPromptTemplate = `You are an excellent AI assistant for university lectures.
 Please answer the user's question based ONLY on the provided context information below.
 If the context does not contain the answer, state that you cannot answer based on the provided materials.
 Do not make up information. Be concise, helpful, and accurate.
+The context is grouped under document ("=== title ===") and page ("--- p. N ---") headers.
+Each snippet starts with a reference number such as [1]. After every statement, cite the snippets
+that support it using their numbers in square brackets, e.g. [1] or [2][3]. Only cite snippets you actually used.
 
 ---
 %s
 ---
 `
+	queryRewritePromptTemplate = `Given the conversation below and a follow-up question, rewrite the follow-up question
+into a standalone question that can be understood without the conversation.
+Keep the language of the original question. Return only the rewritten question.
+
+Conversation:
+%s`
 )
 
 type qaInteractor struct {
 	docRepo       repository.DocumentRepository
-	vectorRepo    repository.VectorRepository
-	embeddingRepo repository.EmbeddingRepository
+	courseRepo    repository.CourseRepository
+	indexes       repository.SearchIndexProvider
 	llmRepo       repository.LLMRepository
-	// TODO: Add cacheRepo and other dependencies
+	reranker      repository.Reranker
+	retrievalCfg  model.RetrievalConfig
+	qaLogRepo     repository.QALogRepository
+	convRepo      repository.ConversationRepository
+	cacheRepo     repository.CacheRepository
+	answerCache   AnswerCacheConfig
+	conversations *conversationStore
 }
 
 // NewQAInteractor creates a new instance of QAUsecase.
 func NewQAInteractor(
 	docRepo repository.DocumentRepository,
-	vectorRepo repository.VectorRepository,
-	embeddingRepo repository.EmbeddingRepository,
+	courseRepo repository.CourseRepository,
+	indexes repository.SearchIndexProvider,
 	llmRepo repository.LLMRepository,
+	reranker repository.Reranker,
+	qaLogRepo repository.QALogRepository,
+	convRepo repository.ConversationRepository,
+	cacheRepo repository.CacheRepository,
+	retrievalCfg model.RetrievalConfig,
+	answerCache AnswerCacheConfig,
 ) port.QAUsecase {
 	return &qaInteractor{
 		docRepo:       docRepo,
-		vectorRepo:    vectorRepo,
-		embeddingRepo: embeddingRepo,
+		courseRepo:    courseRepo,
+		indexes:       indexes,
 		llmRepo:       llmRepo,
+		reranker:      reranker,
+		retrievalCfg:  retrievalCfg.Apply(nil),
+		qaLogRepo:     qaLogRepo,
+		convRepo:      convRepo,
+		cacheRepo:     cacheRepo,
+		answerCache:   answerCache,
+		conversations: &conversationStore{convRepo: convRepo, cacheRepo: cacheRepo},
 	}
 }
 
-func (i *qaInteractor) Ask(ctx context.Context, in input.AskInput) (string, error) {
-	// 1. Create query embedding
-	// ★★★ 修正点: taskTypeに "RETRIEVAL_QUERY" を指定 ★★★
-	queryEmbeddings, err := i.embeddingRepo.CreateEmbeddings(ctx, []string{in.Query}, "RETRIEVAL_QUERY")
-	if err != nil || len(queryEmbeddings) == 0 {
-		return "", fmt.Errorf("failed to create query embedding: %w", err)
+// qaTurn carries the state of a single question through the QA pipeline.
+type qaTurn struct {
+	in            input.AskInput
+	conversation  *model.Conversation
+	history       []model.ConversationMessage // Recent messages before this turn, oldest first
+	searchQuery   string                      // Standalone query used for retrieval
+	expansion     *queryExpansion             // Nil unless query expansion is enabled for the course
+	retrieval     model.RetrievalConfig       // Global config with course and request overrides applied
+	filter        model.SearchFilter          // Course of the question and the filter of the request
+	index         *repository.SearchIndex     // Embedding index the question is embedded for and searched in
+	question      *model.Question
+	questionSaved <-chan error
+	cacheLookup   *answerCacheLookup        // Nil if the answer is not cached
+	cached        *cachedAnswer             // Cached answer, if there was a cache hit
+	passages      []contextPassage          // LLM context, in prompt order
+	chunks        []model.RetrievedChunk    // Citation source of every passage, in prompt order
+	generation    repository.GenerationInfo // Filled in by the LLM repository when it answers
+}
+
+// responseModel returns the model that generated the answer: the one reported by the
+// LLM repository, which may have fallen over to another provider, or else its primary model.
+func (t *qaTurn) responseModel(llmRepo repository.LLMRepository) string {
+	if t.generation.Model != "" {
+		return t.generation.Model
 	}
-	queryVector := queryEmbeddings[0]
+	return llmRepo.ModelName()
+}
 
-	// 2. Hybrid Search (BM25 + Vector) in parallel
-	var bm25Results, vectorResults []model.RetrievedChunk
-	eg, gCtx := errgroup.WithContext(ctx)
+// cannedAnswer returns the answer to send without calling the LLM, if any: a cached
+// answer, a clarifying question for ambiguous queries, or a notice when nothing was
+// retrieved.
+func (t *qaTurn) cannedAnswer() string {
+	if t.cached != nil {
+		return t.cached.Answer
+	}
+	if t.needsClarification() {
+		return t.expansion.ClarifyingQuestion
+	}
+	if len(t.chunks) == 0 {
+		return noRelevantInfoAnswer
+	}
+	return ""
+}
 
-	// BM25 (Full-text) search
-	eg.Go(func() error {
-		var err error
-		bm25Results, err = i.docRepo.FullTextSearch(gCtx, in.Query, in.CourseID, hybridSearchTopK)
+func (t *qaTurn) needsClarification() bool {
+	return t.expansion != nil && t.expansion.needsClarification()
+}
+
+// sources returns the context of the answer as citations numbered in prompt order.
+func (t *qaTurn) sources() []output.CitationOutput {
+	if t.cached != nil {
+		return t.cached.Sources
+	}
+	return toCitationOutputs(t.chunks)
+}
+
+func (i *qaInteractor) Ask(ctx context.Context, in input.AskInput) (*output.AskOutput, error) {
+	turn, err := i.prepare(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	response := turn.cannedAnswer()
+	if response == "" {
+		// 4. Generate response using LLM
+		genCtx := repository.WithGenerationInfo(ctx, &turn.generation)
+		response, err = i.llmRepo.GenerateContent(genCtx, i.buildGenerateParams(turn))
 		if err != nil {
-			return fmt.Errorf("BM25 search failed: %w", err)
+			return nil, fmt.Errorf("failed to generate content: %w", err)
 		}
-		return nil
-	})
+	}
 
-	// Vector search
-	eg.Go(func() error {
-		var err error
-		vectorResults, err = i.vectorRepo.Search(gCtx, queryVector, in.CourseID, hybridSearchTopK)
-		if err != nil {
-			return fmt.Errorf("vector search failed: %w", err)
+	return i.finish(ctx, turn, response), nil
+}
+
+// AskStream runs the same retrieval pipeline as Ask, reports the retrieved
+// sources to the writer and then streams the generated answer into it.
+// The returned output holds the complete answer once the stream has finished.
+func (i *qaInteractor) AskStream(ctx context.Context, in input.AskInput, writer port.AskStreamWriter) (*output.AskOutput, error) {
+	turn, err := i.prepare(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writer.WriteSources(turn.sources()); err != nil {
+		return nil, fmt.Errorf("failed to write sources: %w", err)
+	}
+
+	// Keep a copy of everything streamed to the client so the full answer can be logged.
+	var answer strings.Builder
+	tee := io.MultiWriter(writer, &answer)
+
+	if canned := turn.cannedAnswer(); canned != "" {
+		if _, err := io.WriteString(tee, canned); err != nil {
+			return nil, fmt.Errorf("failed to write answer: %w", err)
 		}
-		return nil
-	})
+	} else if err := i.llmRepo.GenerateContentStream(repository.WithGenerationInfo(ctx, &turn.generation), i.buildGenerateParams(turn), tee); err != nil {
+		return nil, fmt.Errorf("failed to generate content stream: %w", err)
+	}
 
-	if err := eg.Wait(); err != nil {
-		return "", err
+	return i.finish(ctx, turn, answer.String()), nil
+}
+
+// prepare resolves the conversation, rewrites follow-up questions into standalone
+// queries, looks up the answer cache, expands the query if the course enables it,
+// starts logging the question and retrieves and assembles the context.
+func (i *qaInteractor) prepare(ctx context.Context, in input.AskInput) (*qaTurn, error) {
+	turn := &qaTurn{in: in, searchQuery: in.Query}
+
+	conversation, history, err := i.loadConversation(ctx, in)
+	if err != nil {
+		return nil, err
 	}
+	turn.conversation = conversation
+	turn.history = history
 
-	// 3. Rerank/Merge results (using Reciprocal Rank Fusion - RRF)
-	rerankedChunks := i.rerank(bm25Results, vectorResults, rerankTopN)
-	if len(rerankedChunks) == 0 {
-		return "I could not find any relevant information in the provided materials to answer your question.", nil
+	if len(history) > 0 {
+		turn.searchQuery = i.rewriteQuery(ctx, in.Query, history)
 	}
 
-	// 4. Generate response using LLM
-	contextStr := i.buildContextString(rerankedChunks)
-	finalPrompt := fmt.Sprintf(systemPromptTemplate, contextStr)
+	course, err := i.courseRepo.FindByID(ctx, in.CourseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load course: %w", err)
+	}
+	turn.retrieval, err = i.retrievalConfig(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if in.Filter != nil {
+		turn.filter = *in.Filter
+	}
+	turn.filter.CourseID = in.CourseID
+	turn.index, err = i.indexes.ActiveSearchIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve search index: %w", err)
+	}
+	// Only standalone questions over the whole course with its own retrieval settings are
+	// cached; the answer to a follow-up depends on the conversation.
+	if i.answerCache.TTL > 0 && len(history) == 0 && in.Retrieval == nil && !turn.filter.Narrowed() {
+		turn.cacheLookup, turn.cached = i.lookupAnswer(ctx, turn.index, in.CourseID, in.Query)
+	}
+	if course.UseLLMExpansion && turn.cached == nil {
+		turn.expansion, err = i.expandQuery(ctx, turn.searchQuery)
+		if err != nil {
+			log.Printf("WARN: %v; continuing without query expansion", err)
+		}
+	}
 
-	params := repository.GenerateContentParams{
-		SystemPrompt:  finalPrompt,
-		UserPrompt:    in.Query,
-		ContextChunks: rerankedChunks,
+	turn.question = newQuestion(in)
+	turn.question.TracingMeta = model.JSONB{
+		"session_id": conversation.SessionID,
+		"retrieval":  turn.retrieval,
+	}
+	if turn.filter.Narrowed() {
+		turn.question.TracingMeta["filter"] = turn.filter
 	}
+	if turn.searchQuery != in.Query {
+		turn.question.TracingMeta["standalone_query"] = turn.searchQuery
+	}
+	if turn.cacheLookup != nil {
+		turn.question.TracingMeta["answer_cache_hit"] = turn.cached != nil
+		if turn.cacheLookup.similarity > 0 {
+			turn.question.TracingMeta["answer_cache_similarity"] = turn.cacheLookup.similarity
+		}
+	}
+	var searchTexts []string
+	if turn.expansion != nil {
+		searchTexts = turn.expansion.searchTexts()
+		turn.question.UseLLMExpansion = true
+		turn.question.AmbiguityScore = turn.expansion.Ambiguity
+		turn.question.ExpandedQuery = strings.Join(searchTexts, "\n")
+	}
+	turn.questionSaved = i.saveQuestionAsync(ctx, turn.question)
 
-	response, err := i.llmRepo.GenerateContent(ctx, params)
+	if turn.cached != nil || turn.needsClarification() {
+		return turn, nil
+	}
+	var queryVector []float32
+	if turn.cacheLookup != nil {
+		queryVector = turn.cacheLookup.vector
+	}
+	retrieved, err := i.retrieve(ctx, turn.index, turn.filter, turn.retrieval, turn.searchQuery, queryVector, searchTexts...)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate content: %w", err)
+		return nil, err
 	}
+	turn.passages = i.assembleContext(ctx, turn.retrieval, retrieved)
+	turn.chunks = passageSources(turn.passages)
+	return turn, nil
+}
 
-	// TODO: Save question, answer, sources to DB asynchronously
+// finish records the answer in the QA log, the conversation and the answer cache and
+// builds the output.
+func (i *qaInteractor) finish(ctx context.Context, turn *qaTurn, response string) *output.AskOutput {
+	answerID := i.saveAnswer(ctx, turn.questionSaved, turn.question, turn.responseModel(i.llmRepo), response, turn.chunks)
+	sources := turn.sources()
+	if turn.cacheLookup != nil && turn.cached == nil && !turn.needsClarification() {
+		i.storeAnswer(context.WithoutCancel(ctx), turn.index, turn.cacheLookup, turn.in.Query, cachedAnswer{Answer: response, Sources: sources})
+	}
 
-	return response, nil
+	err := i.conversations.append(context.WithoutCancel(ctx), turn.conversation, turn.history,
+		model.ConversationMessage{Role: model.MessageRoleUser, Content: turn.in.Query},
+		model.ConversationMessage{Role: model.MessageRoleAssistant, Content: response},
+	)
+	if err != nil {
+		log.Printf("ERROR: failed to update conversation %s: %v", turn.conversation.SessionID, err)
+	}
+
+	return &output.AskOutput{
+		Answer:             response,
+		AnswerID:           answerID,
+		QueryID:            turn.question.QueryID.String(),
+		SessionID:          turn.conversation.SessionID,
+		NeedsClarification: turn.needsClarification(),
+		Cached:             turn.cached != nil,
+		Citations:          extractCitations(response, sources),
+	}
 }
 
-func (i *qaInteractor) AskStream(ctx context.Context, in input.AskInput, writer io.Writer) error {
-	// Similar logic as Ask, but calls GenerateContentStream
-	// (Implementation is omitted for brevity but would follow the same RAG pipeline)
-	// For this example, we'll just show the final LLM call part.
+// loadConversation returns the conversation referenced by the input together with its
+// recent messages. Without a session ID a new conversation is started.
+func (i *qaInteractor) loadConversation(ctx context.Context, in input.AskInput) (*model.Conversation, []model.ConversationMessage, error) {
+	if in.SessionID == "" {
+		conversation := &model.Conversation{
+			SessionID: uuid.New().String(),
+			UserID:    in.UserID,
+			CourseID:  in.CourseID,
+			Title:     truncateRunes(strings.TrimSpace(in.Query), conversationTitleMaxRunes),
+		}
+		if err := i.convRepo.Create(ctx, conversation); err != nil {
+			return nil, nil, fmt.Errorf("failed to create conversation: %w", err)
+		}
+		return conversation, nil, nil
+	}
 
-	// (Steps 1-3 would be identical to the Ask method)
-	rerankedChunks := []model.RetrievedChunk{} // Assume this is populated
+	conversation, err := i.convRepo.FindBySessionID(ctx, in.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Sessions of other users or courses are reported as missing so their existence is not leaked.
+	if conversation.UserID != in.UserID || conversation.CourseID != in.CourseID {
+		return nil, nil, appErrors.ErrConversationNotFound
+	}
 
-	contextStr := i.buildContextString(rerankedChunks)
-	finalPrompt := fmt.Sprintf(systemPromptTemplate, contextStr)
+	history, err := i.conversations.recentMessages(ctx, conversation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conversation, history, nil
+}
 
-	params := repository.GenerateContentParams{
-		SystemPrompt:  finalPrompt,
-		UserPrompt:    in.Query,
-		ContextChunks: rerankedChunks,
+// rewriteQuery turns a follow-up question into a standalone query using the conversation
+// history. If the rewrite fails the original query is used.
+func (i *qaInteractor) rewriteQuery(ctx context.Context, query string, history []model.ConversationMessage) string {
+	var sb strings.Builder
+	for _, message := range trimHistory(history, conversationHistoryMaxRunes) {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", message.Role, message.Content))
 	}
 
-	return i.llmRepo.GenerateContentStream(ctx, params, writer)
+	rewritten, err := i.llmRepo.GenerateContent(ctx, repository.GenerateContentParams{
+		SystemPrompt: fmt.Sprintf(queryRewritePromptTemplate, sb.String()),
+		UserPrompt:   query,
+	})
+	if err != nil {
+		log.Printf("WARN: failed to rewrite follow-up question, using it as is: %v", err)
+		return query
+	}
+	if rewritten = strings.TrimSpace(rewritten); rewritten == "" {
+		return query
+	}
+	return rewritten
 }
 
-// rerank combines and ranks search results using Reciprocal Rank Fusion (RRF).
-// A simple k=60 is used for the ranking formula.
-func (i *qaInteractor) rerank(listA, listB []model.RetrievedChunk, topN int) []model.RetrievedChunk {
-	const k = 60.0
-	scores := make(map[uint64]float64)
-	chunkMap := make(map[uint64]model.RetrievedChunk)
+// retrievalConfig returns the retrieval parameters for a question: the global config
+// overridden by the course settings, which are in turn overridden by the request.
+func (i *qaInteractor) retrievalConfig(ctx context.Context, in input.AskInput) (model.RetrievalConfig, error) {
+	cfg := i.retrievalCfg
+	settings, err := i.courseRepo.FindSettings(ctx, in.CourseID)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load course settings: %w", err)
+	}
+	if settings != nil {
+		cfg = cfg.Apply(&settings.Retrieval)
+	}
+	return cfg.Apply(in.Retrieval), nil
+}
 
-	for rank, chunk := range listA {
-		id := chunk.Chunk.ID
-		scores[id] += 1.0 / (float64(rank) + k)
-		if _, ok := chunkMap[id]; !ok {
-			chunkMap[id] = chunk
+// retrieve runs the retrieval part of the RAG pipeline. It embeds the query (unless
+// queryVector is given) and the expansion texts, then searches the chunks passing the
+// filter for each text in parallel. Each text gets a BM25 and a vector search, or one
+// hybrid query on the vector store when the keyword search uses its sparse vectors.
+// The result lists are fused and deduplicated, and the best candidates are reranked.
+// A diverse set of them is picked, and their pages and documents are loaded. All texts
+// are embedded and searched in the given index.
+func (i *qaInteractor) retrieve(ctx context.Context, index *repository.SearchIndex, filter model.SearchFilter, cfg model.RetrievalConfig, query string, queryVector []float32, expansions ...string) ([]model.RetrievedChunk, error) {
+	texts := append([]string{query}, expansions...)
+
+	// 1. Create query embeddings
+	toEmbed := texts
+	if queryVector != nil {
+		toEmbed = expansions
+	}
+	var queryEmbeddings [][]float32
+	if len(toEmbed) > 0 {
+		var err error
+		// ★★★ 修正点: taskTypeに "RETRIEVAL_QUERY" を指定 ★★★
+		queryEmbeddings, err = index.EmbeddingRepo.CreateEmbeddings(ctx, toEmbed, "RETRIEVAL_QUERY")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create query embedding: %w", err)
 		}
+		if len(queryEmbeddings) != len(toEmbed) {
+			return nil, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(queryEmbeddings), len(toEmbed))
+		}
+	}
+	if queryVector != nil {
+		queryEmbeddings = append([][]float32{queryVector}, queryEmbeddings...)
 	}
-	for rank, chunk := range listB {
-		id := chunk.Chunk.ID
-		scores[id] += 1.0 / (float64(rank) + k)
-		if _, ok := chunkMap[id]; !ok {
-			chunkMap[id] = chunk
+
+	// 2. Hybrid Search (BM25 + Vector) in parallel, once per search text
+	bm25Results := make([][]model.RetrievedChunk, len(texts))
+	vectorResults := make([][]model.RetrievedChunk, len(texts))
+	eg, gCtx := errgroup.WithContext(ctx)
+
+	// Indexes built without sparse vectors fall back to MySQL full-text search.
+	sparse := cfg.KeywordSearch == model.KeywordSearchSparse && index.HybridSearch
+	for idx := range texts {
+		// Sparse and dense search in a single query, fused by the vector store
+		if sparse {
+			if cfg.BM25TopK > 0 || cfg.VectorTopK > 0 {
+				eg.Go(func() error {
+					var err error
+					vectorResults[idx], err = index.VectorRepo.HybridSearch(gCtx, model.HybridQuery{
+						Text:         texts[idx],
+						Vector:       queryEmbeddings[idx],
+						DenseLimit:   cfg.VectorTopK,
+						SparseLimit:  cfg.BM25TopK,
+						DenseWeight:  cfg.VectorWeight,
+						SparseWeight: cfg.BM25Weight,
+						RRFK:         cfg.RRFK,
+						MinScore:     cfg.MinScore,
+					}, filter)
+					if err != nil {
+						return fmt.Errorf("hybrid search failed: %w", err)
+					}
+					return nil
+				})
+			}
+			continue
+		}
+
+		// BM25 (Full-text) search
+		if cfg.BM25TopK > 0 {
+			eg.Go(func() error {
+				var err error
+				bm25Results[idx], err = i.docRepo.FullTextSearch(gCtx, texts[idx], filter, cfg.BM25TopK)
+				if err != nil {
+					return fmt.Errorf("BM25 search failed: %w", err)
+				}
+				return nil
+			})
 		}
+
+		// Vector search
+		if cfg.VectorTopK > 0 {
+			eg.Go(func() error {
+				results, err := index.VectorRepo.Search(gCtx, queryEmbeddings[idx], filter, cfg.VectorTopK)
+				if err != nil {
+					return fmt.Errorf("vector search failed: %w", err)
+				}
+				vectorResults[idx] = filterByMinScore(results, cfg.MinScore)
+				return nil
+			})
+		}
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
 
-	type rankedResult struct {
-		ID    uint64
-		Score float64
+	// 3. Merge results (using Reciprocal Rank Fusion - RRF), drop duplicates and rerank the best candidates
+	fuseCfg := cfg
+	if sparse {
+		// The hybrid hits are weighted already; only the lists of the search texts are fused.
+		fuseCfg.VectorWeight = 1
+	}
+	candidates := dedupeByVectorHash(i.fuse(fuseCfg, bm25Results, vectorResults))
+	poolSize := cfg.ContextSize
+	if cfg.MMRLambda < 1 {
+		// Diversification chooses the context chunks from the whole reranked pool.
+		poolSize = len(candidates)
+	}
+	rerankedChunks, err := i.reranker.Rerank(ctx, query, candidates, poolSize)
+	if err != nil {
+		log.Printf("WARN: reranking failed, using fused ranking: %v", err)
+		rerankedChunks = candidates[:min(len(candidates), poolSize)]
 	}
 
-	var ranked []rankedResult
-	for id, score := range scores {
-		ranked = append(ranked, rankedResult{ID: id, Score: score})
+	// 4. Diversify the context with Maximal Marginal Relevance
+	contextChunks := i.diversify(ctx, index.VectorRepo, cfg, rerankedChunks)
+	if err := i.loadChunkSources(ctx, contextChunks); err != nil {
+		return nil, err
 	}
+	return contextChunks, nil
+}
 
-	// Sort by score descending
-	// (Using sort.Slice for production is better)
-	for i := 0; i < len(ranked); i++ {
-		for j := i + 1; j < len(ranked); j++ {
-			if ranked[i].Score < ranked[j].Score {
-				ranked[i], ranked[j] = ranked[j], ranked[i]
+// filterByMinScore drops the hits scoring below minScore.
+func filterByMinScore(chunks []model.RetrievedChunk, minScore float32) []model.RetrievedChunk {
+	if minScore <= 0 {
+		return chunks
+	}
+	filtered := chunks[:0:0]
+	for _, chunk := range chunks {
+		if chunk.Score >= minScore {
+			filtered = append(filtered, chunk)
+		}
+	}
+	return filtered
+}
+
+// loadChunkSources fills in the Page and Document and the position of the given chunks,
+// which the search backends do not return, so that the context can be assembled around
+// them and prompts and citations can refer to them.
+func (i *qaInteractor) loadChunkSources(ctx context.Context, chunks []model.RetrievedChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	ids := make([]uint64, len(chunks))
+	for idx, chunk := range chunks {
+		ids[idx] = chunk.Chunk.ID
+	}
+	found, err := i.docRepo.FindChunksByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load chunk sources: %w", err)
+	}
+	byID := make(map[uint64]*model.Chunk, len(found))
+	for _, chunk := range found {
+		byID[chunk.ID] = chunk
+	}
+	for idx := range chunks {
+		if full, ok := byID[chunks[idx].Chunk.ID]; ok {
+			chunks[idx].Chunk.PageID = full.PageID
+			chunks[idx].Chunk.DocumentID = full.DocumentID
+			chunks[idx].Chunk.ChunkIndex = full.ChunkIndex
+			chunks[idx].Chunk.StartOffset = full.StartOffset
+			chunks[idx].Chunk.EndOffset = full.EndOffset
+			chunks[idx].Chunk.TokenCount = full.TokenCount
+			chunks[idx].Chunk.Page = full.Page
+			chunks[idx].Chunk.Document = full.Document
+		}
+	}
+	return nil
+}
+
+// buildGenerateParams builds the LLM request for a turn from its context chunks and history.
+func (i *qaInteractor) buildGenerateParams(turn *qaTurn) repository.GenerateContentParams {
+	contextStr := buildContextString(turn.passages)
+	return repository.GenerateContentParams{
+		SystemPrompt:  fmt.Sprintf(systemPromptTemplate, contextStr),
+		UserPrompt:    turn.in.Query,
+		ContextChunks: turn.chunks,
+		History:       trimHistory(turn.history, conversationHistoryMaxRunes),
+	}
+}
+
+// fuse combines and ranks search results using weighted Reciprocal Rank Fusion (RRF)
+// and returns the best cfg.RerankCandidates chunks. The chunks keep the score of the
+// search that found them first, which sources and citations report.
+func (i *qaInteractor) fuse(cfg model.RetrievalConfig, bm25Lists, vectorLists [][]model.RetrievedChunk) []model.RetrievedChunk {
+	lists := append(append([][]model.RetrievedChunk(nil), bm25Lists...), vectorLists...)
+	weights := make([]float64, len(lists))
+	searchScores := make(map[uint64]float32)
+	for l, list := range lists {
+		weights[l] = cfg.VectorWeight
+		if l < len(bm25Lists) {
+			weights[l] = cfg.BM25Weight
+		}
+		for _, hit := range list {
+			if _, ok := searchScores[hit.Chunk.ID]; !ok {
+				searchScores[hit.Chunk.ID] = hit.Score
 			}
 		}
 	}
 
-	var finalChunks []model.RetrievedChunk
-	for i := 0; i < len(ranked) && i < topN; i++ {
-		finalChunks = append(finalChunks, chunkMap[ranked[i].ID])
+	fused := model.FuseRankings(cfg.RRFK, weights, lists...)
+	fused = fused[:min(len(fused), max(cfg.RerankCandidates, 0))]
+	for idx := range fused {
+		fused[idx].Score = searchScores[fused[idx].Chunk.ID]
+	}
+	return fused
+}
+
+// newQuestion builds the log record for an incoming question.
+func newQuestion(in input.AskInput) *model.Question {
+	return &model.Question{
+		QueryID:  uuid.New(),
+		UserID:   in.UserID,
+		CourseID: in.CourseID,
+		RawQuery: in.Query,
 	}
+}
 
-	return finalChunks
+// saveQuestionAsync stores the question in the background while the RAG pipeline runs.
+// The returned channel yields the result of the write exactly once.
+func (i *qaInteractor) saveQuestionAsync(ctx context.Context, question *model.Question) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), qaLogTimeout)
+		defer cancel()
+		done <- i.qaLogRepo.CreateQuestion(logCtx, question)
+	}()
+	return done
 }
 
-// buildContextString creates a single string from the context chunks.
-func (i *qaInteractor) buildContextString(chunks []model.RetrievedChunk) string {
-	var sb strings.Builder
-	for i, chunk := range chunks {
-		sb.WriteString(fmt.Sprintf("---\nDocument Snippet %d---\n", i+1))
-		sb.WriteString(chunk.Chunk.Text)
-		sb.WriteString("\n\n")
+// saveAnswer stores the answer once the question has been stored and returns its ID.
+// The answer sources are written in the background. Logging failures never fail the
+// request; they are logged and reported as a zero answer ID.
+func (i *qaInteractor) saveAnswer(ctx context.Context, questionSaved <-chan error, question *model.Question, responseModel, response string, chunks []model.RetrievedChunk) uint64 {
+	if err := <-questionSaved; err != nil {
+		log.Printf("ERROR: failed to save question %s: %v", question.QueryID, err)
+		return 0
+	}
+
+	logCtx := context.WithoutCancel(ctx)
+	answer := &model.Answer{
+		QuestionID:    question.ID,
+		ResponseText:  response,
+		ResponseModel: responseModel,
+		ResponseParams: model.JSONB{
+			"context_chunks": len(chunks),
+		},
+	}
+	answerCtx, cancel := context.WithTimeout(logCtx, qaLogTimeout)
+	defer cancel()
+	if err := i.qaLogRepo.CreateAnswer(answerCtx, answer); err != nil {
+		log.Printf("ERROR: failed to save answer for question %s: %v", question.QueryID, err)
+		return 0
+	}
+
+	if len(chunks) > 0 {
+		sources := make([]*model.AnswerSource, len(chunks))
+		for rank, chunk := range chunks {
+			sources[rank] = &model.AnswerSource{
+				AnswerID:         answer.ID,
+				ChunkID:          chunk.Chunk.ID,
+				Score:            chunk.Score,
+				Rank:             rank + 1,
+				ExtractedSnippet: makeSnippet(chunk.Chunk.Text),
+			}
+		}
+		go func() {
+			sourcesCtx, cancel := context.WithTimeout(logCtx, qaLogTimeout)
+			defer cancel()
+			if err := i.qaLogRepo.CreateAnswerSources(sourcesCtx, sources); err != nil {
+				log.Printf("ERROR: failed to save sources for answer %d: %v", answer.ID, err)
+			}
+		}()
 	}
-	return sb.String()
+
+	return answer.ID
 }
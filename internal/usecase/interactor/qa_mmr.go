@@ -0,0 +1,118 @@
+// OpenRAGLecture/internal/usecase/interactor/qa_mmr.go
+package interactor
+
+import (
+	"context"
+	"log"
+	"math"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/repository"
+)
+
+// dedupeByVectorHash drops chunks whose VectorHash was already seen, keeping the
+// best-ranked copy. Chunks without a hash are always kept.
+func dedupeByVectorHash(chunks []model.RetrievedChunk) []model.RetrievedChunk {
+	seen := make(map[string]bool, len(chunks))
+	deduped := chunks[:0:0]
+	for _, chunk := range chunks {
+		if hash := chunk.Chunk.VectorHash; hash != "" {
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+		}
+		deduped = append(deduped, chunk)
+	}
+	return deduped
+}
+
+// diversify selects cfg.ContextSize of the ranked chunks with Maximal Marginal
+// Relevance, trading the rank of a chunk against its similarity to the chunks already
+// selected. The chunk vectors are fetched from the vector database; if that fails, or
+// diversification is disabled, the best-ranked chunks are returned.
+func (i *qaInteractor) diversify(ctx context.Context, vectorRepo repository.VectorRepository, cfg model.RetrievalConfig, ranked []model.RetrievedChunk) []model.RetrievedChunk {
+	if cfg.MMRLambda >= 1 || len(ranked) <= cfg.ContextSize {
+		return ranked[:min(len(ranked), cfg.ContextSize)]
+	}
+
+	ids := make([]string, 0, len(ranked))
+	for _, chunk := range ranked {
+		if chunk.Chunk.EmbeddingID != "" {
+			ids = append(ids, chunk.Chunk.EmbeddingID)
+		}
+	}
+	if len(ids) == 0 {
+		return ranked[:cfg.ContextSize]
+	}
+	stored, err := vectorRepo.GetVectors(ctx, ids)
+	if err != nil {
+		log.Printf("WARN: failed to load chunk vectors, skipping diversification: %v", err)
+		return ranked[:cfg.ContextSize]
+	}
+	byID := make(map[string][]float32, len(stored))
+	for _, cv := range stored {
+		byID[cv.Chunk.EmbeddingID] = cv.Vector
+	}
+	vectors := make([][]float32, len(ranked))
+	for idx, chunk := range ranked {
+		vectors[idx] = byID[chunk.Chunk.EmbeddingID]
+	}
+
+	return mmrSelect(ranked, vectors, cfg.MMRLambda, cfg.ContextSize)
+}
+
+// mmrSelect greedily picks k chunks maximizing
+//
+//	lambda * relevance - (1 - lambda) * max similarity to the selected chunks
+//
+// where relevance falls linearly from 1 for the best-ranked chunk to 1/n for the
+// last one, so that it does not depend on the score scale of the reranker. Chunks
+// without a vector are never considered redundant.
+func mmrSelect(ranked []model.RetrievedChunk, vectors [][]float32, lambda float64, k int) []model.RetrievedChunk {
+	n := len(ranked)
+	picked := make([]bool, n)
+	maxSim := make([]float64, n) // Highest similarity to any selected chunk
+	selected := make([]model.RetrievedChunk, 0, k)
+
+	for len(selected) < k && len(selected) < n {
+		best, bestScore := -1, math.Inf(-1)
+		for idx := range ranked {
+			if picked[idx] {
+				continue
+			}
+			relevance := float64(n-idx) / float64(n)
+			score := lambda*relevance - (1-lambda)*maxSim[idx]
+			if score > bestScore {
+				best, bestScore = idx, score
+			}
+		}
+
+		picked[best] = true
+		selected = append(selected, ranked[best])
+		for idx := range ranked {
+			if !picked[idx] {
+				maxSim[idx] = max(maxSim[idx], cosineSimilarity(vectors[idx], vectors[best]))
+			}
+		}
+	}
+	return selected
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if either is
+// missing or they differ in length.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for idx := range a {
+		dot += float64(a[idx]) * float64(b[idx])
+		normA += float64(a[idx]) * float64(a[idx])
+		normB += float64(b[idx]) * float64(b[idx])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,21 @@
+// OpenRAGLecture/internal/usecase/output/conversation_output.go
+package output
+
+import "time"
+
+// ConversationOutput represents a conversation session of a user.
+type ConversationOutput struct {
+	SessionID string          `json:"session_id"`
+	CourseID  uint64          `json:"course_id"`
+	Title     string          `json:"title"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Messages  []MessageOutput `json:"messages,omitempty"` // Only set when a single conversation is requested
+}
+
+// MessageOutput represents a single message of a conversation.
+type MessageOutput struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
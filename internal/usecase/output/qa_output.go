@@ -3,5 +3,23 @@ package output
 
 // AskOutput represents the data for a user's question.
 type AskOutput struct {
-	Answer string `json:"answer"`
+	Answer             string           `json:"answer"`
+	AnswerID           uint64           `json:"answer_id,omitempty"` // Zero if the answer could not be logged
+	QueryID            string           `json:"query_id"`
+	SessionID          string           `json:"session_id"`
+	NeedsClarification bool             `json:"needs_clarification"` // Answer is a clarifying question, the query was too ambiguous
+	Cached             bool             `json:"cached"`              // Answer was served from the answer cache
+	Citations          []CitationOutput `json:"citations"`
+}
+
+// CitationOutput represents a retrieved chunk that was given to the LLM as context.
+// Index is the number the answer uses to refer to it, e.g. "[1]".
+type CitationOutput struct {
+	Index         int     `json:"index"`
+	DocumentID    uint64  `json:"document_id"`
+	DocumentTitle string  `json:"document_title"`
+	PageNumber    int     `json:"page_number"`
+	ChunkID       uint64  `json:"chunk_id"`
+	Score         float32 `json:"score"`
+	Snippet       string  `json:"snippet"`
 }
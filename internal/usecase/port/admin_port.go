@@ -0,0 +1,23 @@
+// open-rag-lecture/internal/usecase/port/admin_port.go
+package port
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
+)
+
+// AdminUsecase defines the interface for administrative operations. Only users with the
+// admin role may perform them.
+type AdminUsecase interface {
+	// InvalidateAnswerCache drops all cached answers of a course.
+	InvalidateAnswerCache(ctx context.Context, userID, courseID uint64) error
+	// CreateVectorSpace creates the collection or shard key holding the vectors of a
+	// course, as configured by the tenancy of the vector store.
+	CreateVectorSpace(ctx context.Context, userID, courseID uint64) error
+	// DropVectorSpace deletes the vectors of a course along with their collection or
+	// shard key. The documents are kept and can be re-embedded.
+	DropVectorSpace(ctx context.Context, userID, courseID uint64) error
+	// VectorSpaceStats reports where the vectors of a course live and how many there are.
+	VectorSpaceStats(ctx context.Context, userID, courseID uint64) (*model.VectorSpaceStats, error)
+}
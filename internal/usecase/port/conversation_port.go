@@ -0,0 +1,18 @@
+// OpenRAGLecture/internal/usecase/port/conversation_port.go
+package port
+
+import (
+	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
+)
+
+// ConversationUsecase defines the interface for managing a user's conversation sessions.
+type ConversationUsecase interface {
+	// List returns the conversations of a user. A courseID of zero lists all courses.
+	List(ctx context.Context, userID, courseID uint64) ([]output.ConversationOutput, error)
+	// Get returns a conversation of the user together with all of its messages.
+	Get(ctx context.Context, userID uint64, sessionID string) (*output.ConversationOutput, error)
+	// Delete deletes a conversation of the user.
+	Delete(ctx context.Context, userID uint64, sessionID string) error
+}
@@ -3,10 +3,16 @@ package port
 
 import (
 	"context"
+
+	"github.com/takumi-1234/OpenRAGLecture/internal/domain/model"
 )
 
 // CourseUsecase defines the interface for course-related business logic, including enrollment.
 type CourseUsecase interface {
 	// EnrollUser enrolls the given user to the specified course.
 	EnrollUser(ctx context.Context, userID, courseID uint64) error
+	// GetRetrievalSettings returns the retrieval overrides of a course. Only its instructor may read them.
+	GetRetrievalSettings(ctx context.Context, userID, courseID uint64) (*model.RetrievalOverrides, error)
+	// UpdateRetrievalSettings replaces the retrieval overrides of a course. Only its instructor may change them.
+	UpdateRetrievalSettings(ctx context.Context, userID, courseID uint64, retrieval model.RetrievalOverrides) error
 }
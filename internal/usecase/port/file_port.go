@@ -12,4 +12,7 @@ import (
 type FileUsecase interface {
 	Upload(ctx context.Context, courseID uint64, fileName string, file io.Reader) (*model.Document, error)
 	Download(ctx context.Context, documentID uint64) ([]byte, *model.Document, error)
+	// Delete removes a document, its chunks and vectors and the stored file. Only the
+	// instructor of the document's course may delete it.
+	Delete(ctx context.Context, userID, documentID uint64) error
 }
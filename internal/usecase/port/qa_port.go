@@ -6,10 +6,19 @@ import (
 	"io"
 
 	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/input"
+	"github.com/takumi-1234/OpenRAGLecture/internal/usecase/output"
 )
 
 // QAUsecase defines the interface for the core question-answering logic.
 type QAUsecase interface {
-	Ask(ctx context.Context, in input.AskInput) (string, error)
-	AskStream(ctx context.Context, in input.AskInput, writer io.Writer) error
+	Ask(ctx context.Context, in input.AskInput) (*output.AskOutput, error)
+	AskStream(ctx context.Context, in input.AskInput, writer AskStreamWriter) (*output.AskOutput, error)
+}
+
+// AskStreamWriter receives the events produced by QAUsecase.AskStream.
+// Generated answer tokens are delivered through Write.
+type AskStreamWriter interface {
+	io.Writer
+	// WriteSources is called once, before any token, with the chunks used as context.
+	WriteSources(sources []output.CitationOutput) error
 }
@@ -15,10 +15,13 @@ type Config struct {
 	Server    ServerConfig    `mapstructure:"server"`
 	Database  DatabaseConfig  `mapstructure:"database"`
 	VectorDB  VectorDBConfig  `mapstructure:"vector_db"`
+	Retrieval RetrievalConfig `mapstructure:"retrieval"`
 	Cache     CacheConfig     `mapstructure:"cache"`
 	Auth      AuthConfig      `mapstructure:"auth"`
 	Storage   StorageConfig   `mapstructure:"storage"`
 	Google    GoogleConfig    `mapstructure:"google"`
+	LLM       LLMConfig       `mapstructure:"llm"`
+	Embedding EmbeddingConfig `mapstructure:"embedding"`
 	Logging   LoggingConfig   `mapstructure:"logging"`
 	Telemetry TelemetryConfig `mapstructure:"telemetry"`
 }
@@ -46,7 +49,14 @@ type MySQLConfig struct {
 }
 
 type VectorDBConfig struct {
-	Qdrant QdrantConfig `mapstructure:"qdrant"`
+	Type   string             `mapstructure:"type"` // "qdrant", "memory" or "mysql"
+	Qdrant QdrantConfig       `mapstructure:"qdrant"`
+	Memory MemoryVectorConfig `mapstructure:"memory"`
+	// IndexRefreshSeconds is how often servers look up which embedding index is active.
+	IndexRefreshSeconds int `mapstructure:"index_refresh_seconds"`
+	// BackupDir is where the batch task "snapshot-vectors" writes the backups of the
+	// active index, one directory per backup, and "restore-vectors" reads them from.
+	BackupDir string `mapstructure:"backup_dir"`
 }
 
 type QdrantConfig struct {
@@ -57,10 +67,53 @@ type QdrantConfig struct {
 	UseTLS         bool   `mapstructure:"use_tls"`
 	CollectionName string `mapstructure:"collection_name"`
 	VectorSize     uint64 `mapstructure:"vector_size"`
+	// SparseVector names the sparse vector of BM25 term weights stored next to the dense
+	// vector of each point; empty stores no sparse vectors. It is part of the embedding
+	// index, so changing it takes a new index.
+	SparseVector string `mapstructure:"sparse_vector"`
+	// SparseAvgTerms is the average number of terms in a chunk, which the BM25 weights
+	// normalize the length of a chunk by.
+	SparseAvgTerms float64 `mapstructure:"sparse_avg_terms"`
+	// Tenancy separates the vectors of courses: "shared" (one collection filtered by
+	// course), "collection" (a collection per course) or "shard_key" (a custom shard key
+	// per course).
+	Tenancy string `mapstructure:"tenancy"`
+}
+
+// MemoryVectorConfig configures the in-memory vector store. It takes the collection name
+// and vector size from QdrantConfig.
+type MemoryVectorConfig struct {
+	SnapshotDir string `mapstructure:"snapshot_dir"` // Directory of the collection snapshots; empty keeps the vectors in memory only
+}
+
+// RetrievalConfig holds the global retrieval parameters. Courses and single
+// requests can override them.
+type RetrievalConfig struct {
+	BM25TopK           int          `mapstructure:"bm25_top_k"`
+	VectorTopK         int          `mapstructure:"vector_top_k"`
+	BM25Weight         float64      `mapstructure:"bm25_weight"`
+	VectorWeight       float64      `mapstructure:"vector_weight"`
+	RRFK               float64      `mapstructure:"rrf_k"`
+	MinScore           float32      `mapstructure:"min_score"`
+	ContextTokenBudget int          `mapstructure:"context_token_budget"` // Estimated tokens the LLM context may use
+	NeighborChunks     int          `mapstructure:"neighbor_chunks"`      // Adjacent chunks added around each retrieved chunk
+	MMRLambda          float64      `mapstructure:"mmr_lambda"`           // Relevance weight of MMR diversification; 1 disables it
+	KeywordSearch      string       `mapstructure:"keyword_search"`       // "mysql" (FULLTEXT) or "sparse" (Qdrant sparse vectors)
+	Rerank             RerankConfig `mapstructure:"rerank"`
+}
+
+type RerankConfig struct {
+	Type       string `mapstructure:"type"`       // "rrf", "llm" or "lexical"
+	Candidates int    `mapstructure:"candidates"` // Number of fused candidates passed to the reranker
+	TopN       int    `mapstructure:"top_n"`      // Number of chunks kept as context
 }
 
 type CacheConfig struct {
-	Redis RedisConfig `mapstructure:"redis"`
+	Redis            RedisConfig `mapstructure:"redis"`
+	AnswerTTLMinutes int         `mapstructure:"answer_ttl_minutes"` // Lifetime of cached answers; 0 disables the answer cache
+	// SemanticThreshold is the minimum cosine similarity between two questions for the
+	// cached answer of one to be reused for the other; 0 disables the semantic cache.
+	SemanticThreshold float32 `mapstructure:"semantic_threshold"`
 }
 
 type RedisConfig struct {
@@ -107,6 +160,79 @@ type GoogleConfig struct {
 	LLMModel       string `mapstructure:"llm_model"`
 }
 
+// LLMConfig selects the provider of the LLM. The "google" provider is configured by GoogleConfig.
+type LLMConfig struct {
+	Provider   string           `mapstructure:"provider"`  // "google", "openai", "ollama" or "fake"
+	Fallbacks  []string         `mapstructure:"fallbacks"` // Providers tried in order when the previous one fails
+	Resilience ResilienceConfig `mapstructure:"resilience"`
+	OpenAI     OpenAIConfig     `mapstructure:"openai"`
+	Ollama     OllamaConfig     `mapstructure:"ollama"`
+	Fake       FakeLLMConfig    `mapstructure:"fake"`
+}
+
+// EmbeddingConfig selects the provider of the embedding model. The "google" provider is
+// configured by GoogleConfig.
+//
+// Fallback embedding providers must serve the same model as the primary one, e.g. a
+// self-hosted copy of it: vectors of different models cannot be searched together.
+type EmbeddingConfig struct {
+	Provider   string               `mapstructure:"provider"`  // "google", "openai", "ollama" or "fake"
+	Fallbacks  []string             `mapstructure:"fallbacks"` // Providers tried in order when the previous one fails
+	Resilience ResilienceConfig     `mapstructure:"resilience"`
+	Batch      EmbeddingBatchConfig `mapstructure:"batch"`
+	OpenAI     OpenAIConfig         `mapstructure:"openai"`
+	Ollama     OllamaConfig         `mapstructure:"ollama"`
+	Fake       FakeEmbeddingConfig  `mapstructure:"fake"`
+}
+
+// EmbeddingBatchConfig configures how embedding requests are split to stay within the
+// limits of the embedding API. Tokens are estimated with pkg/tokenizer.
+type EmbeddingBatchConfig struct {
+	MaxInstances    int `mapstructure:"max_instances"`     // Texts per request; 0 is unlimited
+	MaxTokens       int `mapstructure:"max_tokens"`        // Estimated tokens per request; 0 is unlimited
+	Concurrency     int `mapstructure:"concurrency"`       // Requests in flight per call
+	TokensPerMinute int `mapstructure:"tokens_per_minute"` // Rate limit of estimated tokens; 0 disables it
+}
+
+// ResilienceConfig configures how failing provider requests are retried and when a
+// failing provider is skipped.
+type ResilienceConfig struct {
+	MaxRetries              int `mapstructure:"max_retries"` // Retries per provider of transient failures
+	InitialBackoffMillis    int `mapstructure:"initial_backoff_ms"`
+	MaxBackoffMillis        int `mapstructure:"max_backoff_ms"`
+	BreakerFailureThreshold int `mapstructure:"breaker_failure_threshold"` // Consecutive failed requests that open the breaker; 0 disables it
+	BreakerCooldownSeconds  int `mapstructure:"breaker_cooldown_seconds"`  // Time a provider is skipped once its breaker is open
+}
+
+// OpenAIConfig configures a client of OpenAI or an OpenAI-compatible inference server.
+type OpenAIConfig struct {
+	BaseURL        string `mapstructure:"base_url"` // API root including the version, e.g. "https://api.openai.com/v1"
+	APIKey         string `mapstructure:"api_key"`
+	Model          string `mapstructure:"model"`
+	Dimensions     int    `mapstructure:"dimensions"` // Requested embedding size; 0 uses the model default
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+// OllamaConfig configures a client of a local Ollama server.
+type OllamaConfig struct {
+	BaseURL        string `mapstructure:"base_url"` // e.g. "http://localhost:11434"
+	Model          string `mapstructure:"model"`
+	Dimensions     int    `mapstructure:"dimensions"` // Embedding size of the model; must match the vector size
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+// FakeLLMConfig configures the deterministic fake LLM used for local development and tests.
+type FakeLLMConfig struct {
+	LatencyMillis     int `mapstructure:"latency_ms"`      // Delay before the answer starts
+	StreamChunkMillis int `mapstructure:"stream_chunk_ms"` // Delay between streamed words
+	MaxSentences      int `mapstructure:"max_sentences"`   // Context sentences quoted in an answer
+}
+
+// FakeEmbeddingConfig configures the deterministic fake embedding model.
+type FakeEmbeddingConfig struct {
+	Dimensions int `mapstructure:"dimensions"` // 0 uses the vector size of the collection
+}
+
 type LoggingConfig struct {
 	Level    string `mapstructure:"level"`
 	Encoding string `mapstructure:"encoding"`
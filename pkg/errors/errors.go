@@ -2,7 +2,10 @@
 
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// Generic errors
@@ -15,12 +18,27 @@ var (
 	ErrValidation          = errors.New("validation failed")
 
 	// Specific errors
-	ErrInvalidCredentials   = errors.New("invalid email or password")
-	ErrEmailAlreadyExists   = errors.New("email already exists")
-	ErrUserNotFound         = errors.New("user not found")
-	ErrDocumentNotFound     = errors.New("document not found")
-	ErrCourseNotFound       = errors.New("course not found")
-	ErrNotEnrolled          = errors.New("user not enrolled in this course")
-	ErrFileUploadFailed     = errors.New("file upload failed")
-	ErrFileProcessingFailed = errors.New("file processing failed")
+	ErrInvalidCredentials     = errors.New("invalid email or password")
+	ErrEmailAlreadyExists     = errors.New("email already exists")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrDocumentNotFound       = errors.New("document not found")
+	ErrCourseNotFound         = errors.New("course not found")
+	ErrNotEnrolled            = errors.New("user not enrolled in this course")
+	ErrFileUploadFailed       = errors.New("file upload failed")
+	ErrFileProcessingFailed   = errors.New("file processing failed")
+	ErrConversationNotFound   = errors.New("conversation not found")
+	ErrEmbeddingIndexNotFound = errors.New("embedding index not found")
+	// ErrHybridSearchUnsupported is returned by vector stores without sparse vectors.
+	ErrHybridSearchUnsupported = errors.New("hybrid search is not supported by this vector store")
 )
+
+// StatusError is returned by clients of external HTTP APIs when a request is answered
+// with an error status, so that callers can tell transient failures from permanent ones.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
@@ -0,0 +1,195 @@
+// OpenRAGLecture/pkg/tokenizer/sparse.go
+package tokenizer
+
+import (
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters of the document term weights.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SparseVector is a vector of term weights indexed by term hash, with the indices in
+// ascending order.
+type SparseVector struct {
+	Indices []uint32
+	Values  []float32
+}
+
+// Terms splits text into search terms: lower-cased runs of letters and digits, and
+// bigrams of adjacent CJK characters, since Japanese text does not separate words by
+// spaces. A CJK character standing alone is a term of its own.
+func Terms(text string) []string {
+	var terms []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch len(cjk) {
+		case 0:
+		case 1:
+			terms = append(terms, string(cjk))
+		default:
+			for i := 0; i+1 < len(cjk); i++ {
+				terms = append(terms, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return terms
+}
+
+// TermCounts are the occurrences of the terms of a document.
+type TermCounts struct {
+	Counts SparseVector // Number of occurrences by term index
+	Length int          // Number of terms
+}
+
+// CountTerms counts the terms of text.
+func CountTerms(text string) TermCounts {
+	terms := Terms(text)
+	return TermCounts{
+		Counts: sparseVector(termCounts(terms), func(count int) float32 { return float32(count) }),
+		Length: len(terms),
+	}
+}
+
+// BM25 returns the BM25 term-frequency weights of the document: the frequency of each
+// term, saturated by k1 and normalized by the length of the document relative to
+// avgTerms, the average length of the documents in the corpus. The inverse document
+// frequency is applied to the query, see BM25Scorer.
+func (d TermCounts) BM25(avgTerms float64) SparseVector {
+	lengthNorm := 1 - bm25B + bm25B*float64(d.Length)/avgTerms
+	v := SparseVector{Indices: d.Counts.Indices, Values: make([]float32, len(d.Counts.Values))}
+	for i, count := range d.Counts.Values {
+		tf := float64(count)
+		v.Values[i] = float32(tf * (bm25K1 + 1) / (tf + bm25K1*lengthNorm))
+	}
+	return v
+}
+
+// BM25Document returns the BM25 term-frequency weights of text, see TermCounts.BM25.
+func BM25Document(text string, avgTerms float64) SparseVector {
+	return CountTerms(text).BM25(avgTerms)
+}
+
+// BM25Query returns the query vector matching BM25Document: a weight of 1 for every
+// distinct term of the query. Qdrant multiplies it by the IDF of the term when the
+// sparse vector has the IDF modifier.
+func BM25Query(text string) SparseVector {
+	return sparseVector(termCounts(Terms(text)), func(int) float32 { return 1 })
+}
+
+// IDF returns the inverse document frequency of a term found in docFreq of docCount
+// documents, as Qdrant computes it for the IDF modifier.
+func IDF(docCount, docFreq int) float32 {
+	n, df := float64(docCount), float64(docFreq)
+	return float32(math.Log(1 + (n-df+0.5)/(df+0.5)))
+}
+
+// BM25Scorer scores documents for a query by BM25, with the average length of the
+// documents and the document frequencies of the query terms taken from their corpus.
+type BM25Scorer struct {
+	query    SparseVector // IDF by query term
+	avgTerms float64
+}
+
+// NewBM25Scorer creates the BM25Scorer of a query over a corpus.
+func NewBM25Scorer(query string, corpus []TermCounts) *BM25Scorer {
+	q := BM25Query(query)
+	docFreqs := make([]int, len(q.Indices))
+	totalTerms := 0
+	for _, doc := range corpus {
+		totalTerms += doc.Length
+		for i, index := range q.Indices {
+			if _, found := slices.BinarySearch(doc.Counts.Indices, index); found {
+				docFreqs[i]++
+			}
+		}
+	}
+	for i, docFreq := range docFreqs {
+		q.Values[i] = IDF(len(corpus), docFreq)
+	}
+	avgTerms := 1.0
+	if totalTerms > 0 {
+		avgTerms = float64(totalTerms) / float64(len(corpus))
+	}
+	return &BM25Scorer{query: q, avgTerms: avgTerms}
+}
+
+// Score returns the BM25 score of a document of the corpus.
+func (s *BM25Scorer) Score(doc TermCounts) float32 {
+	return doc.BM25(s.avgTerms).Dot(s.query)
+}
+
+// Dot returns the dot product of two sparse vectors.
+func (v SparseVector) Dot(other SparseVector) float32 {
+	var sum float32
+	for i, j := 0, 0; i < len(v.Indices) && j < len(other.Indices); {
+		switch {
+		case v.Indices[i] < other.Indices[j]:
+			i++
+		case v.Indices[i] > other.Indices[j]:
+			j++
+		default:
+			sum += v.Values[i] * other.Values[j]
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// termCounts counts the terms by term index. Terms whose hashes collide are counted
+// as one term.
+func termCounts(terms []string) map[uint32]int {
+	counts := make(map[uint32]int, len(terms))
+	for _, term := range terms {
+		counts[termIndex(term)]++
+	}
+	return counts
+}
+
+func termIndex(term string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(term))
+	return h.Sum32()
+}
+
+func sparseVector(counts map[uint32]int, weight func(count int) float32) SparseVector {
+	v := SparseVector{Indices: make([]uint32, 0, len(counts)), Values: make([]float32, 0, len(counts))}
+	for index := range counts {
+		v.Indices = append(v.Indices, index)
+	}
+	slices.Sort(v.Indices)
+	for _, index := range v.Indices {
+		v.Values = append(v.Values, weight(counts[index]))
+	}
+	return v
+}
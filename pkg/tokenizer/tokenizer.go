@@ -0,0 +1,26 @@
+// OpenRAGLecture/pkg/tokenizer/tokenizer.go
+package tokenizer
+
+import "unicode"
+
+// charsPerToken is the average number of non-CJK characters per LLM token.
+const charsPerToken = 4
+
+// EstimateTokens returns a rough, model-independent estimate of the number of
+// LLM tokens in text. CJK characters count as one token each, all other
+// characters as a quarter token, which is close enough for budgeting prompts.
+func EstimateTokens(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+charsPerToken-1)/charsPerToken
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,4 @@
+-- 000002_conversations.down.sql
+
+DROP TABLE IF EXISTS `conversation_messages`;
+DROP TABLE IF EXISTS `conversations`;
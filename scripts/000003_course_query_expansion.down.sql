@@ -0,0 +1,3 @@
+-- 000003_course_query_expansion.down.sql
+
+ALTER TABLE `courses` DROP COLUMN `use_llm_expansion`;
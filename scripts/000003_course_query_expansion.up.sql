@@ -0,0 +1,3 @@
+-- 000003_course_query_expansion.up.sql
+
+ALTER TABLE `courses` ADD COLUMN `use_llm_expansion` tinyint(1) NOT NULL DEFAULT '0' AFTER `is_active`;
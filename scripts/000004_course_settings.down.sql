@@ -0,0 +1,3 @@
+-- 000004_course_settings.down.sql
+
+DROP TABLE IF EXISTS `course_settings`;
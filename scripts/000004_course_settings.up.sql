@@ -0,0 +1,21 @@
+-- 000004_course_settings.up.sql
+
+CREATE TABLE `course_settings` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NOT NULL,
+  `updated_at` datetime(3) NOT NULL,
+  `deleted_at` datetime(3) DEFAULT NULL,
+  `course_id` bigint unsigned NOT NULL,
+  `retrieval_bm25_top_k` bigint DEFAULT NULL,
+  `retrieval_vector_top_k` bigint DEFAULT NULL,
+  `retrieval_bm25_weight` double DEFAULT NULL,
+  `retrieval_vector_weight` double DEFAULT NULL,
+  `retrieval_rrf_k` double DEFAULT NULL,
+  `retrieval_rerank_candidates` bigint DEFAULT NULL,
+  `retrieval_context_size` bigint DEFAULT NULL,
+  `retrieval_min_score` float DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_course_settings_course_id` (`course_id`),
+  KEY `idx_course_settings_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_course_settings_course` FOREIGN KEY (`course_id`) REFERENCES `courses` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
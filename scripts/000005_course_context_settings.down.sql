@@ -0,0 +1,5 @@
+-- 000005_course_context_settings.down.sql
+
+ALTER TABLE `course_settings`
+  DROP COLUMN `retrieval_neighbor_chunks`,
+  DROP COLUMN `retrieval_context_token_budget`;
@@ -0,0 +1,5 @@
+-- 000005_course_context_settings.up.sql
+
+ALTER TABLE `course_settings`
+  ADD COLUMN `retrieval_context_token_budget` bigint DEFAULT NULL,
+  ADD COLUMN `retrieval_neighbor_chunks` bigint DEFAULT NULL;
@@ -0,0 +1,3 @@
+-- 000006_course_mmr_settings.down.sql
+
+ALTER TABLE `course_settings` DROP COLUMN `retrieval_mmr_lambda`;
@@ -0,0 +1,3 @@
+-- 000006_course_mmr_settings.up.sql
+
+ALTER TABLE `course_settings` ADD COLUMN `retrieval_mmr_lambda` double DEFAULT NULL;
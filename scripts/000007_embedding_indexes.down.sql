@@ -0,0 +1,3 @@
+-- 000007_embedding_indexes.down.sql
+
+DROP TABLE IF EXISTS `embedding_indexes`;
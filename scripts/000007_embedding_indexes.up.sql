@@ -0,0 +1,21 @@
+-- 000007_embedding_indexes.up.sql
+
+CREATE TABLE `embedding_indexes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NOT NULL,
+  `updated_at` datetime(3) NOT NULL,
+  `deleted_at` datetime(3) DEFAULT NULL,
+  `version` varchar(191) NOT NULL,
+  `provider` varchar(32) NOT NULL,
+  `model` varchar(128) NOT NULL,
+  `dimensions` bigint NOT NULL,
+  `collection_name` varchar(255) NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `last_chunk_id` bigint unsigned DEFAULT NULL,
+  `chunk_count` bigint DEFAULT NULL,
+  `activated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_embedding_indexes_version` (`version`),
+  KEY `idx_embedding_indexes_deleted_at` (`deleted_at`),
+  KEY `idx_embedding_indexes_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
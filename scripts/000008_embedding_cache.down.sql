@@ -0,0 +1,3 @@
+-- 000008_embedding_cache.down.sql
+
+DROP TABLE IF EXISTS `embedding_cache_entries`;
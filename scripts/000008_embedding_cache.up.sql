@@ -0,0 +1,9 @@
+-- 000008_embedding_cache.up.sql
+
+CREATE TABLE `embedding_cache_entries` (
+  `vector_hash` varchar(128) NOT NULL,
+  `model` varchar(128) NOT NULL,
+  `vector` json NOT NULL,
+  `created_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`vector_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,3 @@
+-- 000009_vector_records.down.sql
+
+DROP TABLE IF EXISTS `vector_records`;
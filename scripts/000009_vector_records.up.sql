@@ -0,0 +1,16 @@
+-- 000009_vector_records.up.sql
+
+CREATE TABLE `vector_records` (
+  `collection_name` varchar(255) NOT NULL,
+  `chunk_id` bigint unsigned NOT NULL,
+  `course_id` bigint unsigned NOT NULL,
+  `document_id` bigint unsigned NOT NULL,
+  `embedding_id` varchar(128) NOT NULL,
+  `vector_hash` varchar(128) DEFAULT NULL,
+  `vector` longblob NOT NULL,
+  `updated_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`collection_name`,`chunk_id`),
+  KEY `idx_vector_records_course` (`collection_name`,`course_id`),
+  KEY `idx_vector_records_document` (`collection_name`,`document_id`),
+  KEY `idx_vector_records_embedding_id` (`embedding_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,5 @@
+-- 000010_vector_record_filters.down.sql
+
+ALTER TABLE `vector_records`
+  DROP COLUMN `doc_type`,
+  DROP COLUMN `semester_id`;
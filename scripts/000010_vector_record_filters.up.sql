@@ -0,0 +1,11 @@
+-- 000010_vector_record_filters.up.sql
+
+ALTER TABLE `vector_records`
+  ADD COLUMN `semester_id` bigint unsigned NOT NULL DEFAULT 0 AFTER `document_id`,
+  ADD COLUMN `doc_type` varchar(16) NOT NULL DEFAULT '' AFTER `semester_id`;
+
+-- Vectors stored before the columns existed take them from their chunk and document.
+UPDATE `vector_records` v
+  INNER JOIN `chunks` c ON c.id = v.chunk_id
+  INNER JOIN `documents` d ON d.id = c.document_id
+SET v.semester_id = c.semester_id, v.doc_type = d.doc_type;
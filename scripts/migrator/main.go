@@ -57,6 +57,7 @@ func runAutoMigration(db *gorm.DB) {
 		&model.Semester{},
 		&model.User{},
 		&model.Course{},
+		&model.CourseSettings{},
 		&model.Enrollment{},
 		&model.Document{},
 		&model.Page{},
@@ -65,6 +66,11 @@ func runAutoMigration(db *gorm.DB) {
 		&model.Answer{},
 		&model.AnswerSource{},
 		&model.Feedback{},
+		&model.Conversation{},
+		&model.ConversationMessage{},
+		&model.EmbeddingIndex{},
+		&model.EmbeddingCacheEntry{},
+		&model.VectorRecord{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto migrate database: %v", err)